@@ -8,9 +8,10 @@ import (
 // aircraft model
 type Aircraft struct {
 	ID          primitive.ObjectID       `json:"id,omitempty" bson:"_id,omitempty"`
-	General     *General                 `json:"general,omitempty" bson:"general,omitempty"`
+	General     *General                 `json:"general,omitempty" bson:"general,omitempty" create:"required"`
 	Airframe    *Airframe                `json:"airframe,omitempty" bson:"airframe,omitempty"`
 	Engines     []primitive.ObjectID     `json:"engines" bson:"engines"`
+	APU         *APU                     `json:"apu,omitempty" bson:"apu,omitempty"`
 	Exterior    *Exterior                `json:"exterior,omitempty" bson:"exterior,omitempty"`
 	Interior    *Interior                `json:"interior,omitempty" bson:"interior,omitempty"`
 	Cockpit     *Cockpit                 `json:"cockpit,omitempty" bson:"cockpit,omitempty"`
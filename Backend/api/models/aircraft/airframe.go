@@ -1,8 +1,21 @@
 package aircraft
 
-// airframe model
+import "time"
+
+// airframe model, total time is in hours and landings are cycles
 type Airframe struct {
-	TotalTime     *uint16 `json:"totalTime,omitempty" bson:"totalTime,omitempty"`
-	TotalLandings *uint16 `json:"totalLandings,omitempty" bson:"totalLandings,omitempty"`
-	AirframeNotes string  `json:"airframeNotes,omitempty" bson:"airframeNotes,omitempty"`
+	TotalTime      *float64           `json:"totalTime,omitempty" bson:"totalTime,omitempty" binding:"omitempty,gte=0"`
+	TotalLandings  *uint32            `json:"totalLandings,omitempty" bson:"totalLandings,omitempty"`
+	AirframeNotes  string             `json:"airframeNotes,omitempty" bson:"airframeNotes,omitempty"`
+	Check          *ScheduledCheck    `json:"check,omitempty" bson:"check,omitempty"`
+	MaintenanceLog []MaintenanceEntry `json:"maintenanceLog" bson:"maintenanceLog"`
+}
+
+// a check the aircraft is in or is going to be in, the aircraft is out of service from start to end
+type ScheduledCheck struct {
+	Type    string    `json:"type" bson:"type"`
+	Start   time.Time `json:"start" bson:"start"`
+	End     time.Time `json:"end" bson:"end"`
+	Cost    int       `json:"cost" bson:"cost"`
+	Started bool      `json:"started" bson:"started"`
 }
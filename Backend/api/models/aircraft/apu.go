@@ -1,6 +1,10 @@
 package aircraft
 
+// APU model, times are in hours
 type APU struct {
-	TotalTime uint16 `json:"totalTime,omitempty" bson:"totalTime,omitempty"`
-	Notes     string `json:"notes,omitempty" bson:"notes,omitempty"`
+	TotalTime         *float64           `json:"totalTime,omitempty" bson:"totalTime,omitempty" binding:"omitempty,gte=0"`
+	SinceMaintenance  *float64           `json:"sinceMaintenance,omitempty" bson:"sinceMaintenance,omitempty" binding:"omitempty,gte=0"`
+	Notes             string             `json:"notes,omitempty" bson:"notes,omitempty"`
+	MaintenanceLog    []MaintenanceEntry `json:"maintenanceLog" bson:"maintenanceLog"`
+	TimeToMaintenance *float64           `json:"timeToMaintenance,omitempty" bson:"-"`
 }
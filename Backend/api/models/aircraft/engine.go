@@ -2,12 +2,17 @@ package aircraft
 
 import "go.mongodb.org/mongo-driver/bson/primitive"
 
-// engine model
+// engine model, times are in hours
 type Engine struct {
-	ID             primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
-	OwningAircraft primitive.ObjectID `json:"owningAircraft,omitempty" bson:"owningAircraft,omitempty"`
-	Model          string             `json:"model,omitempty" bson:"model,omitempty"`
-	TotalTime      *uint16            `json:"totalTime,omitempty" bson:"totalTime,omitempty"`
-	TBO            *uint16            `json:"tbo,omitempty" bson:"tbo,omitempty"`
-	HST            *uint16            `json:"hst,omitempty" bson:"hst,omitempty"`
+	ID               primitive.ObjectID `json:"id,omitempty" bson:"_id,omitempty"`
+	OwningAircraft   primitive.ObjectID `json:"owningAircraft,omitempty" bson:"owningAircraft,omitempty"`
+	Model            string             `json:"model,omitempty" bson:"model,omitempty" binding:"omitempty,max=100" create:"required"`
+	TotalTime        *float64           `json:"totalTime,omitempty" bson:"totalTime,omitempty" binding:"omitempty,gte=0"`
+	TBO              *uint16            `json:"tbo,omitempty" bson:"tbo,omitempty" binding:"omitempty,gt=0"`
+	HST              *uint16            `json:"hst,omitempty" bson:"hst,omitempty" binding:"omitempty,gt=0"`
+	SinceOverhaul    *float64           `json:"sinceOverhaul,omitempty" bson:"sinceOverhaul,omitempty" binding:"omitempty,gte=0"`
+	SinceHotSection  *float64           `json:"sinceHotSection,omitempty" bson:"sinceHotSection,omitempty" binding:"omitempty,gte=0"`
+	MaintenanceLog   []MaintenanceEntry `json:"maintenanceLog" bson:"maintenanceLog"`
+	TimeToOverhaul   *float64           `json:"timeToOverhaul,omitempty" bson:"-"`
+	TimeToHotSection *float64           `json:"timeToHotSection,omitempty" bson:"-"`
 }
@@ -1,6 +1,6 @@
 package aircraft
 
 type Exterior struct {
-	YearPainted *uint16 `json:"yearPainted,omitempty" bson:"yearPainted,omitempty"`
+	YearPainted *uint16 `json:"yearPainted,omitempty" bson:"yearPainted,omitempty" binding:"omitempty,gte=1903"`
 	Notes       string  `json:"notes,omitempty" bson:"notes,omitempty"`
 }
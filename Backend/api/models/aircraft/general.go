@@ -4,16 +4,17 @@ import "go.mongodb.org/mongo-driver/bson/primitive"
 
 // aircraft general info model
 type General struct {
-	Name         string               `json:"name,omitempty" bson:"name,omitempty"`
+	Name         string               `json:"name,omitempty" bson:"name,omitempty" binding:"omitempty,max=100"`
 	Icon         string               `json:"icon,omitempty" bson:"icon,omitempty"`
-	Year         *uint16              `json:"year,omitempty" bson:"year,omitempty"`
-	Manufacturer string               `json:"manufacturer,omitempty" bson:"manufacturer,omitempty"`
-	Model        string               `json:"model,omitempty" bson:"model,omitempty"`
-	Registration string               `json:"registration,omitempty" bson:"registration,omitempty"`
+	Year         *uint16              `json:"year,omitempty" bson:"year,omitempty" binding:"omitempty,gte=1903"`
+	Manufacturer string               `json:"manufacturer,omitempty" bson:"manufacturer,omitempty" binding:"omitempty,max=100"`
+	Model        string               `json:"model,omitempty" bson:"model,omitempty" binding:"omitempty,max=100" create:"required"`
+	Registration string               `json:"registration,omitempty" bson:"registration,omitempty" binding:"omitempty,tailnumber" create:"required"`
 	Condition    string               `json:"condition,omitempty" bson:"condition,omitempty"`
 	Description  string               `json:"description,omitempty" bson:"description,omitempty"`
 	Location     string               `json:"location,omitempty" bson:"location,omitempty"`
 	IsOperating  *bool                `json:"isOperating,omitempty" bson:"isOperating,omitempty"`
 	History      []primitive.ObjectID `json:"history" bson:"history"`
-	Price        *float32             `json:"price,omitempty" bson:"price,omitempty"`
+	Price        *float32             `json:"price,omitempty" bson:"price,omitempty" binding:"omitempty,gte=0"`
+	ForSale      *bool                `json:"forSale,omitempty" bson:"forSale,omitempty"`
 }
@@ -1,6 +1,6 @@
 package aircraft
 
 type Interior struct {
-	YearInterior  *uint16 `json:"yearInterior,omitempty" bson:"yearInterior,omitempty"`
-	NumberOfSeats *uint16 `json:"numberOfSeats,omitempty" bson:"numberOfSeats,omitempty"`
+	YearInterior  *uint16 `json:"yearInterior,omitempty" bson:"yearInterior,omitempty" binding:"omitempty,gte=1903"`
+	NumberOfSeats *uint16 `json:"numberOfSeats,omitempty" bson:"numberOfSeats,omitempty" binding:"omitempty,lte=1000"`
 }
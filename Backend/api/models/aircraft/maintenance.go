@@ -0,0 +1,44 @@
+package aircraft
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maintenance event types
+const (
+	Overhaul   = "overhaul"
+	HotSection = "hotSection"
+	APUService = "apuService"
+)
+
+// airframe checks from the lightest to the heaviest, a check also counts as every lighter one
+var Checks = []string{"A", "B", "C", "D"}
+
+// maintenance log entry, total time and cycles are the ones of the part when the work was done
+type MaintenanceEntry struct {
+	Type      string    `json:"type" bson:"type"`
+	Date      time.Time `json:"date" bson:"date"`
+	TotalTime float64   `json:"totalTime" bson:"totalTime"`
+	Cycles    *uint32   `json:"cycles,omitempty" bson:"cycles,omitempty"`
+	Notes     string    `json:"notes,omitempty" bson:"notes,omitempty"`
+}
+
+// check intervals of an aircraft model
+type CheckProgram struct {
+	ID     primitive.ObjectID `json:"id" bson:"_id"`
+	Model  string             `json:"model" bson:"model"`
+	Checks []CheckInterval    `json:"checks" bson:"checks" binding:"dive"`
+}
+
+// a check is due on whichever of hours, cycles or months comes first,
+// it keeps the aircraft out of service for duration hours
+type CheckInterval struct {
+	Type     string   `json:"type" bson:"type"`
+	Hours    *float64 `json:"hours,omitempty" bson:"hours,omitempty" binding:"omitempty,gt=0"`
+	Cycles   *uint32  `json:"cycles,omitempty" bson:"cycles,omitempty" binding:"omitempty,gt=0"`
+	Months   *uint16  `json:"months,omitempty" bson:"months,omitempty" binding:"omitempty,gt=0"`
+	Duration float64  `json:"duration" bson:"duration" binding:"gt=0"`
+	Cost     int      `json:"cost" bson:"cost" binding:"gte=0"`
+}
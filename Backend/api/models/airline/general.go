@@ -1,10 +1,10 @@
 package airline
 
 type General struct {
-	Name   string  `json:"name,omitempty" bson:"name,omitempty"`
+	Name   string  `json:"name,omitempty" bson:"name,omitempty" binding:"omitempty,max=100" create:"required"`
 	Logo   string  `json:"logo,omitempty" bson:"logo,omitempty"`
-	IATA   string  `json:"iata,omitempty" bson:"iata,omitempty"`
-	ICAO   string  `json:"icao,omitempty" bson:"icao,omitempty"`
+	IATA   string  `json:"iata,omitempty" bson:"iata,omitempty" binding:"omitempty,iata=airline"`
+	ICAO   string  `json:"icao,omitempty" bson:"icao,omitempty" binding:"omitempty,icao=airline"`
 	Fleet  *uint16 `json:"fleet,omitempty" bson:"fleet,omitempty"`
-	Rating *uint8  `json:"rating,omitempty" bson:"rating,omitempty"`
+	Rating *uint8  `json:"rating,omitempty" bson:"rating,omitempty" binding:"omitempty,min=1,max=5"`
 }
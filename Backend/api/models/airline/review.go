@@ -1,11 +1,19 @@
 package airline
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Review struct {
-	ID      primitive.ObjectID `json:"id" bson:"_id"`
-	User    string             `json:"user,omitempty" bson:"user,omitempty"`
-	Avatar  string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
-	Comment string             `json:"comment,omitempty" bson:"comment,omitempty"`
-	Rating  *uint8             `json:"rating,omitempty" bson:"rating,omitempty"`
+	ID        primitive.ObjectID `json:"id" bson:"_id"`
+	User      string             `json:"user,omitempty" bson:"user,omitempty"`
+	Avatar    string             `json:"avatar,omitempty" bson:"avatar,omitempty"`
+	Comment   string             `json:"comment,omitempty" bson:"comment,omitempty" binding:"omitempty,max=2000"`
+	Rating    *uint8             `json:"rating,omitempty" bson:"rating,omitempty" binding:"omitempty,min=1,max=5" create:"required"`
+	Airline   primitive.ObjectID `json:"airline,omitempty" bson:"airline,omitempty"`
+	Author    primitive.ObjectID `json:"author,omitempty" bson:"author,omitempty"`
+	Hidden    bool               `json:"hidden" bson:"hidden"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
 }
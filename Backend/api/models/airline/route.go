@@ -1,13 +1,15 @@
 package airline
 
 import (
-	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// route model, distance is in nautical miles
 type Route struct {
-	ID      primitive.ObjectID   `json:"id" bson:"_id"`
-	From    *airport.Airport     `json:"from,omitempty" bson:"from,omitempty"`
-	To      *airport.Airport     `json:"to,omitempty" bson:"to,omitempty"`
-	Flights []primitive.ObjectID `json:"flights" bson:"flights"`
+	ID       primitive.ObjectID   `json:"id" bson:"_id"`
+	From     primitive.ObjectID   `json:"from,omitempty" bson:"from,omitempty" create:"required"`
+	To       primitive.ObjectID   `json:"to,omitempty" bson:"to,omitempty" create:"required,nefield=From"`
+	Flights  []primitive.ObjectID `json:"flights" bson:"flights"`
+	Airline  primitive.ObjectID   `json:"airline,omitempty" bson:"airline,omitempty"`
+	Distance float64              `json:"distance,omitempty" bson:"distance,omitempty"`
 }
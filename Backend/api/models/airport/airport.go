@@ -6,11 +6,20 @@ import (
 )
 
 type Airport struct {
-	ID         primitive.ObjectID   `json:"id" bson:"_id"`
-	ICAO       string               `json:"icao,omitempty" bson:"icao,omitempty"`
-	IATA       string               `json:"iata,omitempty" bson:"iata,omitempty"`
-	Weather    primitive.ObjectID   `json:"weather,omitempty" bson:"weather,omitempty"`
-	Arrivals   []flight.Flight      `json:"arrivals" bson:"arrivals"`
-	Departures []flight.Flight      `json:"departures" bson:"departures"`
-	TopTraffic []primitive.ObjectID `json:"topTraffic" bson:"topTraffic"`
+	ID           primitive.ObjectID   `json:"id" bson:"_id"`
+	ICAO         string               `json:"icao,omitempty" bson:"icao,omitempty"`
+	IATA         string               `json:"iata,omitempty" bson:"iata,omitempty"`
+	Name         string               `json:"name,omitempty" bson:"name,omitempty"`
+	Type         string               `json:"type,omitempty" bson:"type,omitempty"`
+	Latitude     float64              `json:"latitude" bson:"latitude"`
+	Longitude    float64              `json:"longitude" bson:"longitude"`
+	Elevation    *int32               `json:"elevation,omitempty" bson:"elevation,omitempty"`
+	Country      string               `json:"country,omitempty" bson:"country,omitempty"`
+	Region       string               `json:"region,omitempty" bson:"region,omitempty"`
+	Municipality string               `json:"municipality,omitempty" bson:"municipality,omitempty"`
+	Runways      []Runway             `json:"runways" bson:"runways"`
+	Weather      primitive.ObjectID   `json:"weather,omitempty" bson:"weather,omitempty"`
+	Arrivals     []flight.Flight      `json:"arrivals" bson:"arrivals"`
+	Departures   []flight.Flight      `json:"departures" bson:"departures"`
+	TopTraffic   []primitive.ObjectID `json:"topTraffic" bson:"topTraffic"`
 }
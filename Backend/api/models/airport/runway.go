@@ -0,0 +1,12 @@
+package airport
+
+// runway model, lengths are in feet
+type Runway struct {
+	LowEnd  string `json:"lowEnd,omitempty" bson:"lowEnd,omitempty"`
+	HighEnd string `json:"highEnd,omitempty" bson:"highEnd,omitempty"`
+	Length  *int32 `json:"length,omitempty" bson:"length,omitempty"`
+	Width   *int32 `json:"width,omitempty" bson:"width,omitempty"`
+	Surface string `json:"surface,omitempty" bson:"surface,omitempty"`
+	Lighted bool   `json:"lighted" bson:"lighted"`
+	Closed  bool   `json:"closed" bson:"closed"`
+}
@@ -1,18 +1,34 @@
 package flight
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// flight statuses
+const (
+	Scheduled = "Scheduled"
+	Cancelled = "Cancelled"
+	Completed = "Completed"
+)
+
+// distance is in nautical miles, flight and block times are in minutes
 type Flight struct {
 	ID                  primitive.ObjectID `json:"id" bson:"_id"`
 	FlightNumber        string             `json:"flightNumber,omitempty" bson:"flightNumber,omitempty"`
 	Callsign            string             `json:"callsign,omitempty" bson:"callsign,omitempty"`
 	Departure           primitive.ObjectID `json:"departure,omitempty" bson:"departure,omitempty"`
 	Arrival             primitive.ObjectID `json:"arrival,omitempty" bson:"arrival,omitempty"`
-	Distance            string             `json:"distance,omitempty" bson:"distance,omitempty"`
-	FlightTime          string             `json:"flightTime,omitempty" bson:"flightTime,omitempty"`
+	Distance            float64            `json:"distance,omitempty" bson:"distance,omitempty" binding:"omitempty,gte=0"`
+	FlightTime          uint16             `json:"flightTime,omitempty" bson:"flightTime,omitempty"`
+	BlockTime           uint16             `json:"blockTime,omitempty" bson:"blockTime,omitempty"`
 	AverageArrivalDelay string             `json:"averageArrivalDelay,omitempty" bson:"averageArrivalDelay,omitempty"`
 	DepartureTime       map[string]string  `json:"departureTime" bson:"departureTime"`
 	ArrivalTime         map[string]string  `json:"arrivalTime" bson:"arrivalTime"`
-	Airline             primitive.ObjectID `json:"airline,omitempty" bson:"airline,omitempty"`
+	Airline             primitive.ObjectID `json:"airline,omitempty" bson:"airline,omitempty" create:"required"`
 	Aircraft            primitive.ObjectID `json:"aircraft,omitempty" bson:"aircraft,omitempty"`
+	Route               primitive.ObjectID `json:"route,omitempty" bson:"route,omitempty"`
+	Status              string             `json:"status,omitempty" bson:"status,omitempty" binding:"omitempty,oneof=Scheduled Cancelled Completed"`
+	CompletedAt         *time.Time         `json:"completedAt,omitempty" bson:"completedAt,omitempty"`
 }
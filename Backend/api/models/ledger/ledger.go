@@ -0,0 +1,55 @@
+package ledger
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// account types
+const (
+	User    = "user"
+	Airline = "airline"
+	// counterparty of money entering or leaving the game
+	System = "system"
+)
+
+// entry types
+const (
+	Opening          = "opening"
+	Adjustment       = "adjustment"
+	AircraftPurchase = "aircraftPurchase"
+	Maintenance      = "maintenance"
+	// the remaining balance of a deleted account
+	Closing = "closing"
+)
+
+// one leg of a posting, credits are positive and debits negative amounts.
+// The legs of a posting share the transaction and sum up to zero
+type Entry struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	Transaction primitive.ObjectID `json:"transaction" bson:"transaction"`
+	AccountType string             `json:"accountType" bson:"accountType"`
+	Account     primitive.ObjectID `json:"account,omitempty" bson:"account,omitempty"`
+	Type        string             `json:"type" bson:"type"`
+	Amount      int                `json:"amount" bson:"amount"`
+	Balance     int                `json:"balance" bson:"balance"`
+	Reference   primitive.ObjectID `json:"reference,omitempty" bson:"reference,omitempty"`
+	Description string             `json:"description,omitempty" bson:"description,omitempty"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+type Account struct {
+	Type string
+	ID   primitive.ObjectID
+}
+
+// money moving from one account to another
+type Transfer struct {
+	From        Account
+	To          Account
+	Amount      int
+	Type        string
+	Reference   primitive.ObjectID
+	Description string
+}
@@ -1,14 +1,27 @@
 package user
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"encoding/json"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 // user model
 type User struct {
 	ID       primitive.ObjectID   `json:"id" bson:"_id"`
-	Name     string               `json:"name" bson:"name"`
-	Email    string               `json:"email" bson:"email"`
-	Password string               `json:"password" bson:"password"`
+	Name     string               `json:"name" bson:"name" binding:"omitempty,max=64" create:"required"`
+	Email    string               `json:"email" bson:"email" binding:"omitempty,email" create:"required"`
+	Password string               `json:"password" bson:"password" binding:"omitempty,max=72" create:"required,min=8"`
 	IsAdmin  *bool                `json:"isAdmin" bson:"isAdmin"`
 	Balance  *int                 `json:"balance" bson:"balance"`
 	Airlines []primitive.ObjectID `json:"airlines" bson:"airlines"`
 }
+
+// passwords are read from requests but never written to responses
+func (u User) MarshalJSON() ([]byte, error) {
+	type user User
+	return json.Marshal(struct {
+		user
+		Password string `json:"password,omitempty"`
+	}{user: user(u)})
+}
@@ -1,9 +1,65 @@
 package weather
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
 
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// flight categories
+const (
+	VFR  = "VFR"
+	MVFR = "MVFR"
+	IFR  = "IFR"
+	LIFR = "LIFR"
+)
+
+// current conditions of an airport, visibility is in statute miles, heights are in feet,
+// temperatures in degrees Celsius and QNH in hectopascals
 type Weather struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id"`
-	Condition   string             `json:"condition,omitempty" bson:"condition,omitempty"`
-	Temperature int8               `json:"temperature,omitempty" bson:"temperature,omitempty"`
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	Airport        string             `json:"airport,omitempty" bson:"airport,omitempty"`
+	Condition      string             `json:"condition,omitempty" bson:"condition,omitempty"`
+	Temperature    *int8              `json:"temperature,omitempty" bson:"temperature,omitempty"`
+	Dewpoint       *int8              `json:"dewpoint,omitempty" bson:"dewpoint,omitempty"`
+	Wind           *Wind              `json:"wind,omitempty" bson:"wind,omitempty"`
+	Visibility     *float64           `json:"visibility,omitempty" bson:"visibility,omitempty"`
+	Ceiling        *int32             `json:"ceiling,omitempty" bson:"ceiling,omitempty"`
+	Clouds         []Cloud            `json:"clouds" bson:"clouds"`
+	QNH            *float64           `json:"qnh,omitempty" bson:"qnh,omitempty"`
+	FlightCategory string             `json:"flightCategory,omitempty" bson:"flightCategory,omitempty"`
+	ObservedAt     time.Time          `json:"observedAt" bson:"observedAt"`
+	Metar          string             `json:"metar,omitempty" bson:"metar,omitempty"`
+	Taf            string             `json:"taf,omitempty" bson:"taf,omitempty"`
+	TafIssuedAt    *time.Time         `json:"tafIssuedAt,omitempty" bson:"tafIssuedAt,omitempty"`
+	Forecast       []Forecast         `json:"forecast" bson:"forecast"`
+}
+
+// wind speeds are in knots, a nil direction means variable wind
+type Wind struct {
+	Direction    *int16 `json:"direction,omitempty" bson:"direction,omitempty"`
+	Speed        int16  `json:"speed" bson:"speed"`
+	Gust         *int16 `json:"gust,omitempty" bson:"gust,omitempty"`
+	VariableFrom *int16 `json:"variableFrom,omitempty" bson:"variableFrom,omitempty"`
+	VariableTo   *int16 `json:"variableTo,omitempty" bson:"variableTo,omitempty"`
+}
+
+type Cloud struct {
+	Cover string `json:"cover" bson:"cover"`
+	Base  int32  `json:"base" bson:"base"`
+	Type  string `json:"type,omitempty" bson:"type,omitempty"`
+}
+
+// one period of a TAF
+type Forecast struct {
+	Change         string    `json:"change" bson:"change"`
+	Probability    *uint8    `json:"probability,omitempty" bson:"probability,omitempty"`
+	From           time.Time `json:"from" bson:"from"`
+	To             time.Time `json:"to" bson:"to"`
+	Condition      string    `json:"condition,omitempty" bson:"condition,omitempty"`
+	Wind           *Wind     `json:"wind,omitempty" bson:"wind,omitempty"`
+	Visibility     *float64  `json:"visibility,omitempty" bson:"visibility,omitempty"`
+	Ceiling        *int32    `json:"ceiling,omitempty" bson:"ceiling,omitempty"`
+	Clouds         []Cloud   `json:"clouds" bson:"clouds"`
+	FlightCategory string    `json:"flightCategory,omitempty" bson:"flightCategory,omitempty"`
 }
@@ -0,0 +1,142 @@
+package apierrors
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// error codes of responses, clients tell errors apart by them
+const (
+	CodeValidation        = "validation"
+	CodeUnauthorized      = "unauthorized"
+	CodeInsufficientFunds = "insufficient_funds"
+	CodeForbidden         = "forbidden"
+	CodeNotFound          = "not_found"
+	CodeConflict          = "conflict"
+	CodeInternal          = "internal"
+)
+
+var statuses = map[string]int{
+	CodeValidation:        http.StatusBadRequest,
+	CodeUnauthorized:      http.StatusUnauthorized,
+	CodeInsufficientFunds: http.StatusPaymentRequired,
+	CodeForbidden:         http.StatusForbidden,
+	CodeNotFound:          http.StatusNotFound,
+	CodeConflict:          http.StatusConflict,
+	CodeInternal:          http.StatusInternalServerError,
+}
+
+// the error a request fails with and the body of its response, details are nil
+// unless the code has some, e.g. the failing fields of a validation error
+type Error struct {
+	Code    string      `json:"code"`
+	Message string      `json:"message"`
+	Details interface{} `json:"details"`
+	// the cause, logged but never sent
+	Err error `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// the HTTP status of the code
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func Validation(message string) *Error {
+	return &Error{Code: CodeValidation, Message: message}
+}
+
+func Unauthorized(message string) *Error {
+	return &Error{Code: CodeUnauthorized, Message: message}
+}
+
+func InsufficientFunds(message string) *Error {
+	return &Error{Code: CodeInsufficientFunds, Message: message}
+}
+
+func Forbidden(message string) *Error {
+	return &Error{Code: CodeForbidden, Message: message}
+}
+
+func NotFound(message string) *Error {
+	return &Error{Code: CodeNotFound, Message: message}
+}
+
+func Conflict(message string) *Error {
+	return &Error{Code: CodeConflict, Message: message}
+}
+
+func Internal(err error) *Error {
+	return &Error{Code: CodeInternal, Message: "Internal server error", Err: err}
+}
+
+// From maps an error to the error model: missing documents are not found, duplicate keys conflicts,
+// malformed cursors validation errors and everything else not already an Error is internal
+func From(err error) *Error {
+	var apiError *Error
+	switch {
+	case errors.As(err, &apiError):
+		return apiError
+	case errors.Is(err, repositories.ErrNotFound), errors.Is(err, mongo.ErrNoDocuments):
+		return &Error{Code: CodeNotFound, Message: "No such document", Err: err}
+	case mongo.IsDuplicateKeyError(err):
+		return &Error{Code: CodeConflict, Message: "The document already exists", Err: err}
+	case errors.Is(err, repositories.ErrInvalidCursor):
+		return &Error{Code: CodeValidation, Message: err.Error(), Err: err}
+	}
+	return Internal(err)
+}
+
+// ObjectID reads an id, a malformed one is a validation error failing the field like the ones of request bodies
+func ObjectID(field string, hex string) (primitive.ObjectID, error) {
+	objectId, err := primitive.ObjectIDFromHex(hex)
+	if err != nil {
+		return objectId, &Error{
+			Code:    CodeValidation,
+			Message: "Validation failed",
+			Details: []gin.H{{"field": field, "rule": "objectid", "message": "must be a 24 character hex ObjectID"}},
+			Err:     err}
+	}
+	return objectId, nil
+}
+
+// Abort ends the request with err, the error handler writes the response
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// Handler writes the response of a request that failed with an error, it comes before every other handler.
+// Handlers report errors with c.Error and return without responding
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := From(c.Errors.Last().Err)
+		if err.Code == CodeInternal {
+			log.Printf("%s %s: %v", c.Request.Method, c.Request.URL.Path, err.Err)
+		}
+		c.JSON(err.Status(), err)
+	}
+}
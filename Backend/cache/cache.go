@@ -0,0 +1,30 @@
+package cache
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// returned by Get when there is no entry for the key
+var ErrMiss = errors.New("Cache miss")
+
+// values are stored as JSON, tags group entries so they can be evicted together
+type Cache interface {
+	// decodes the entry of key into value, ErrMiss when there is none
+	Get(key string, value interface{}) error
+	Set(key string, value interface{}, ttl time.Duration, tags ...string) error
+	Delete(keys ...string) error
+	// evicts every entry set with any of the tags
+	Invalidate(tags ...string) error
+}
+
+// builds a namespaced key like "aircraft:id:<id>", the first part is the entity
+func Key(entity string, parts ...string) string {
+	return strings.Join(append([]string{entity}, parts...), ":")
+}
+
+// the tag of every entry of an entity, or with an id the tag of the entries showing that document
+func Tag(entity string, id ...string) string {
+	return "tag:" + Key(entity, id...)
+}
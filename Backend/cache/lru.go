@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+type lruEntry struct {
+	key     string
+	data    []byte
+	expires time.Time
+	tags    []string
+}
+
+// in-process cache that evicts the least recently used entry once it holds size entries
+type LRU struct {
+	mutex   sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	tags    map[string]map[string]bool
+}
+
+func NewLRU(size int) *LRU {
+	if size <= 0 {
+		size = 1000
+	}
+	return &LRU{
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		tags:    make(map[string]map[string]bool)}
+}
+
+func (c *LRU) Get(key string, value interface{}) error {
+	c.mutex.Lock()
+	element, ok := c.entries[key]
+	if !ok {
+		c.mutex.Unlock()
+		return ErrMiss
+	}
+	entry := element.Value.(*lruEntry)
+	if time.Now().After(entry.expires) {
+		c.remove(element)
+		c.mutex.Unlock()
+		return ErrMiss
+	}
+	c.order.MoveToFront(element)
+	data := entry.data
+	c.mutex.Unlock()
+	return json.Unmarshal(data, value)
+}
+
+func (c *LRU) Set(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.remove(element)
+	}
+	entry := &lruEntry{key: key, data: data, expires: time.Now().Add(ttl), tags: tags}
+	c.entries[key] = c.order.PushFront(entry)
+	for _, x := range tags {
+		if c.tags[x] == nil {
+			c.tags[x] = make(map[string]bool)
+		}
+		c.tags[x][key] = true
+	}
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+	return nil
+}
+
+func (c *LRU) Delete(keys ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, x := range keys {
+		if element, ok := c.entries[x]; ok {
+			c.remove(element)
+		}
+	}
+	return nil
+}
+
+func (c *LRU) Invalidate(tags ...string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, x := range tags {
+		for key := range c.tags[x] {
+			if element, ok := c.entries[key]; ok {
+				c.remove(element)
+			}
+		}
+		delete(c.tags, x)
+	}
+	return nil
+}
+
+// must be called with the mutex held
+func (c *LRU) remove(element *list.Element) {
+	entry := element.Value.(*lruEntry)
+	c.order.Remove(element)
+	delete(c.entries, entry.key)
+	for _, x := range entry.tags {
+		delete(c.tags[x], entry.key)
+		if len(c.tags[x]) == 0 {
+			delete(c.tags, x)
+		}
+	}
+}
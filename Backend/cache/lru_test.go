@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// the keys of the cache that are still there
+func cached(c *LRU, keys ...string) []string {
+	found := make([]string, 0)
+	for _, x := range keys {
+		var value string
+		if c.Get(x, &value) == nil {
+			found = append(found, x)
+		}
+	}
+	return found
+}
+
+func equalKeys(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestLRUEviction(t *testing.T) {
+	tests := []struct {
+		name string
+		// keys set in order into a cache of 3 entries, "get:" reads a key instead
+		steps []string
+		kept  []string
+	}{
+		{"under the size", []string{"a", "b"}, []string{"a", "b"}},
+		{"least recently set goes first", []string{"a", "b", "c", "d"}, []string{"b", "c", "d"}},
+		{"a read keeps an entry", []string{"a", "b", "c", "get:a", "d"}, []string{"a", "c", "d"}},
+		{"setting again keeps an entry", []string{"a", "b", "c", "a", "d", "e"}, []string{"a", "d", "e"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLRU(3)
+			for _, x := range test.steps {
+				if strings.HasPrefix(x, "get:") {
+					var value string
+					if err := c.Get(strings.TrimPrefix(x, "get:"), &value); err != nil {
+						t.Fatal(err)
+					}
+					continue
+				}
+				if err := c.Set(x, x, time.Minute); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if kept := cached(c, "a", "b", "c", "d", "e"); !equalKeys(kept, test.kept) {
+				t.Errorf("kept %v, want %v", kept, test.kept)
+			}
+		})
+	}
+}
+
+func TestLRUGet(t *testing.T) {
+	c := NewLRU(10)
+	type aircraft struct {
+		Name  string
+		Hours float64
+	}
+	if err := c.Set(Key("aircraft", "id", "1"), aircraft{"Example", 1200.5}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	var got aircraft
+	if err := c.Get(Key("aircraft", "id", "1"), &got); err != nil || got.Name != "Example" || got.Hours != 1200.5 {
+		t.Errorf("%+v (%v), want the entry as it was set", got, err)
+	}
+	if err := c.Get(Key("aircraft", "id", "2"), &got); err != ErrMiss {
+		t.Errorf("error %v, want %v", err, ErrMiss)
+	}
+	if err := c.Set("expired", "value", -time.Second); err != nil {
+		t.Fatal(err)
+	}
+	var value string
+	if err := c.Get("expired", &value); err != ErrMiss {
+		t.Errorf("error %v for an expired entry, want %v", err, ErrMiss)
+	}
+	if err := c.Delete(Key("aircraft", "id", "1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Get(Key("aircraft", "id", "1"), &got); err != ErrMiss {
+		t.Errorf("error %v after delete, want %v", err, ErrMiss)
+	}
+}
+
+func TestLRUInvalidate(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		kept []string
+	}{
+		{"entity tag", []string{Tag("aircraft")}, []string{"airlines:list", "airlines:id:1"}},
+		{"document tag", []string{Tag("aircraft", "1")}, []string{"aircraft:list", "aircraft:id:2", "airlines:list", "airlines:id:1"}},
+		// the airline view shows aircraft 2 too
+		{"shared entry", []string{Tag("aircraft", "2")}, []string{"aircraft:list", "aircraft:id:1", "airlines:list"}},
+		{"unknown tag", []string{Tag("engines")}, []string{"aircraft:list", "aircraft:id:1", "aircraft:id:2", "airlines:list", "airlines:id:1"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewLRU(10)
+			for key, tags := range map[string][]string{
+				"aircraft:list": {Tag("aircraft")},
+				"aircraft:id:1": {Tag("aircraft"), Tag("aircraft", "1")},
+				"aircraft:id:2": {Tag("aircraft"), Tag("aircraft", "2")},
+				"airlines:list": {Tag("airlines")},
+				"airlines:id:1": {Tag("airlines", "1"), Tag("aircraft", "2")},
+			} {
+				if err := c.Set(key, key, time.Minute, tags...); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.Invalidate(test.tags...); err != nil {
+				t.Fatal(err)
+			}
+			kept := cached(c, "aircraft:list", "aircraft:id:1", "aircraft:id:2", "airlines:list", "airlines:id:1")
+			if !equalKeys(kept, test.kept) {
+				t.Errorf("kept %v, want %v", kept, test.kept)
+			}
+		})
+	}
+}
+
+// evicted entries leave no tags behind that would grow the cache forever
+func TestLRUEvictionDropsTags(t *testing.T) {
+	c := NewLRU(1)
+	for _, x := range []string{"a", "b", "c"} {
+		if err := c.Set(x, x, time.Minute, Tag("entity", x)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(c.tags) != 1 || len(c.entries) != 1 || c.order.Len() != 1 {
+		t.Errorf("%d tags, %d entries and %d in order, want 1 each", len(c.tags), len(c.entries), c.order.Len())
+	}
+}
@@ -0,0 +1,28 @@
+package cache
+
+import (
+	"time"
+)
+
+// caches nothing, every Get is a miss
+type Noop struct{}
+
+func NewNoop() Noop {
+	return Noop{}
+}
+
+func (Noop) Get(key string, value interface{}) error {
+	return ErrMiss
+}
+
+func (Noop) Set(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	return nil
+}
+
+func (Noop) Delete(keys ...string) error {
+	return nil
+}
+
+func (Noop) Invalidate(tags ...string) error {
+	return nil
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// keys live under "cache:" so they never collide with sessions or refresh tokens,
+// a tag is a Redis set of the keys set with it
+type Redis struct {
+	client *redis.Client
+}
+
+const redisPrefix = "cache:"
+
+func NewRedis(client *redis.Client) Redis {
+	return Redis{client: client}
+}
+
+func (c Redis) Get(key string, value interface{}) error {
+	data, err := c.client.Get(redisPrefix + key).Bytes()
+	if err == redis.Nil {
+		return ErrMiss
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
+
+func (c Redis) Set(key string, value interface{}, ttl time.Duration, tags ...string) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	pipe := c.client.TxPipeline()
+	pipe.Set(redisPrefix+key, data, ttl)
+	for _, x := range tags {
+		pipe.SAdd(redisPrefix+x, key)
+		// a tag has to outlive every entry it holds
+		if c.client.TTL(redisPrefix+x).Val() < ttl {
+			pipe.Expire(redisPrefix+x, ttl)
+		}
+	}
+	_, err = pipe.Exec()
+	return err
+}
+
+func (c Redis) Delete(keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, x := range keys {
+		prefixed[i] = redisPrefix + x
+	}
+	return c.client.Del(prefixed...).Err()
+}
+
+func (c Redis) Invalidate(tags ...string) error {
+	for _, x := range tags {
+		keys, err := c.client.SMembers(redisPrefix + x).Result()
+		if err != nil {
+			return err
+		}
+		if err = c.Delete(append(keys, x)...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Package client calls the X-Airlines API. The types and methods of client_gen.go are generated
+// from openapi.json, go generate refreshes both after routes or models change
+package client
+
+//go:generate sh -c "cd .. && go run . -profile dev openapi > openapi.json"
+//go:generate go run ../openapi/clientgen -spec ../openapi.json -out client_gen.go
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type Client struct {
+	// e.g. http://localhost:3000
+	BaseURL string
+	// the access token of SignIn or Refresh, sent as a Bearer token
+	Token      string
+	HTTPClient *http.Client
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// ResponseError is a response with an error status, Body is the error the API sent
+type ResponseError struct {
+	StatusCode int
+	Body       Error
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Body.Code, e.Body.Message)
+}
+
+// sends body as JSON and decodes the response into out, a nil out discards it
+func (c *Client) do(ctx context.Context, method string, path string, query url.Values, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	target := c.BaseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		responseError := &ResponseError{StatusCode: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(&responseError.Body)
+		return responseError
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
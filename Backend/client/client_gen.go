@@ -0,0 +1,1502 @@
+// Code generated by clientgen from the OpenAPI document. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+var (
+	_ json.RawMessage
+	_ time.Time
+	_ url.Values
+)
+
+type APU struct {
+	MaintenanceLog    []MaintenanceEntry `json:"maintenanceLog,omitempty"`
+	Notes             string             `json:"notes,omitempty"`
+	SinceMaintenance  *float64           `json:"sinceMaintenance,omitempty"`
+	TimeToMaintenance *float64           `json:"timeToMaintenance,omitempty"`
+	TotalTime         *float64           `json:"totalTime,omitempty"`
+}
+
+type Aircraft struct {
+	Airframe    *Airframe        `json:"airframe,omitempty"`
+	APU         *APU             `json:"apu,omitempty"`
+	Cockpit     *Cockpit         `json:"cockpit,omitempty"`
+	Engines     []string         `json:"engines,omitempty"`
+	Exterior    *Exterior        `json:"exterior,omitempty"`
+	General     *AircraftGeneral `json:"general,omitempty"`
+	ID          string           `json:"id,omitempty"`
+	Interior    *Interior        `json:"interior,omitempty"`
+	Owner       string           `json:"owner,omitempty"`
+	Performance *Performance     `json:"performance,omitempty"`
+	Tags        []string         `json:"tags,omitempty"`
+	TrackerData *TrackerData     `json:"trackerData,omitempty"`
+}
+
+type AircraftChecks struct {
+	Checks  []CheckStatus   `json:"checks,omitempty"`
+	Current *ScheduledCheck `json:"current,omitempty"`
+}
+
+type AircraftCreate struct {
+	Airframe    *Airframe             `json:"airframe,omitempty"`
+	APU         *APU                  `json:"apu,omitempty"`
+	Cockpit     *Cockpit              `json:"cockpit,omitempty"`
+	Engines     []string              `json:"engines,omitempty"`
+	Exterior    *Exterior             `json:"exterior,omitempty"`
+	General     AircraftGeneralCreate `json:"general"`
+	ID          string                `json:"id,omitempty"`
+	Interior    *Interior             `json:"interior,omitempty"`
+	Owner       string                `json:"owner,omitempty"`
+	Performance *Performance          `json:"performance,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	TrackerData *TrackerData          `json:"trackerData,omitempty"`
+}
+
+type AircraftGeneral struct {
+	Condition    string   `json:"condition,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	ForSale      *bool    `json:"forSale,omitempty"`
+	History      []string `json:"history,omitempty"`
+	Icon         string   `json:"icon,omitempty"`
+	IsOperating  *bool    `json:"isOperating,omitempty"`
+	Location     string   `json:"location,omitempty"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Price        *float64 `json:"price,omitempty"`
+	Registration string   `json:"registration,omitempty"`
+	Year         *int64   `json:"year,omitempty"`
+}
+
+type AircraftGeneralCreate struct {
+	Condition    string   `json:"condition,omitempty"`
+	Description  string   `json:"description,omitempty"`
+	ForSale      *bool    `json:"forSale,omitempty"`
+	History      []string `json:"history,omitempty"`
+	Icon         string   `json:"icon,omitempty"`
+	IsOperating  *bool    `json:"isOperating,omitempty"`
+	Location     string   `json:"location,omitempty"`
+	Manufacturer string   `json:"manufacturer,omitempty"`
+	Model        string   `json:"model"`
+	Name         string   `json:"name,omitempty"`
+	Price        *float64 `json:"price,omitempty"`
+	Registration string   `json:"registration"`
+	Year         *int64   `json:"year,omitempty"`
+}
+
+type AircraftPage struct {
+	Items []Aircraft `json:"items"`
+	Next  string     `json:"next,omitempty"`
+	Total int64      `json:"total,omitempty"`
+}
+
+type Airframe struct {
+	AirframeNotes  string             `json:"airframeNotes,omitempty"`
+	Check          *ScheduledCheck    `json:"check,omitempty"`
+	MaintenanceLog []MaintenanceEntry `json:"maintenanceLog,omitempty"`
+	TotalLandings  *int64             `json:"totalLandings,omitempty"`
+	TotalTime      *float64           `json:"totalTime,omitempty"`
+}
+
+type Airline struct {
+	Balance *int64          `json:"balance,omitempty"`
+	Fleet   []string        `json:"fleet,omitempty"`
+	General *AirlineGeneral `json:"general,omitempty"`
+	ID      string          `json:"id,omitempty"`
+	Owner   string          `json:"owner,omitempty"`
+	Reviews []string        `json:"reviews,omitempty"`
+	Routes  []string        `json:"routes,omitempty"`
+}
+
+type AirlineCreate struct {
+	Balance *int64               `json:"balance,omitempty"`
+	Fleet   []string             `json:"fleet,omitempty"`
+	General AirlineGeneralCreate `json:"general"`
+	ID      string               `json:"id,omitempty"`
+	Owner   string               `json:"owner,omitempty"`
+	Reviews []string             `json:"reviews,omitempty"`
+	Routes  []string             `json:"routes,omitempty"`
+}
+
+type AirlineGeneral struct {
+	Fleet  *int64 `json:"fleet,omitempty"`
+	IATA   string `json:"iata,omitempty"`
+	ICAO   string `json:"icao,omitempty"`
+	Logo   string `json:"logo,omitempty"`
+	Name   string `json:"name,omitempty"`
+	Rating *int64 `json:"rating,omitempty"`
+}
+
+type AirlineGeneralCreate struct {
+	Fleet  *int64 `json:"fleet,omitempty"`
+	IATA   string `json:"iata,omitempty"`
+	ICAO   string `json:"icao,omitempty"`
+	Logo   string `json:"logo,omitempty"`
+	Name   string `json:"name"`
+	Rating *int64 `json:"rating,omitempty"`
+}
+
+type AirlinePage struct {
+	Items []Airline `json:"items"`
+	Next  string    `json:"next,omitempty"`
+	Total int64     `json:"total,omitempty"`
+}
+
+type Airport struct {
+	Arrivals     []Flight `json:"arrivals,omitempty"`
+	Country      string   `json:"country,omitempty"`
+	Departures   []Flight `json:"departures,omitempty"`
+	Elevation    *int64   `json:"elevation,omitempty"`
+	IATA         string   `json:"iata,omitempty"`
+	ICAO         string   `json:"icao,omitempty"`
+	ID           string   `json:"id,omitempty"`
+	Latitude     float64  `json:"latitude,omitempty"`
+	Longitude    float64  `json:"longitude,omitempty"`
+	Municipality string   `json:"municipality,omitempty"`
+	Name         string   `json:"name,omitempty"`
+	Region       string   `json:"region,omitempty"`
+	Runways      []Runway `json:"runways,omitempty"`
+	TopTraffic   []string `json:"topTraffic,omitempty"`
+	Type         string   `json:"type,omitempty"`
+	Weather      string   `json:"weather,omitempty"`
+}
+
+type BalanceAdjustment struct {
+	Amount      int64  `json:"amount"`
+	Description string `json:"description,omitempty"`
+}
+
+type CheckInterval struct {
+	Cost     int64    `json:"cost,omitempty"`
+	Cycles   *int64   `json:"cycles,omitempty"`
+	Duration float64  `json:"duration,omitempty"`
+	Hours    *float64 `json:"hours,omitempty"`
+	Months   *int64   `json:"months,omitempty"`
+	Type     string   `json:"type,omitempty"`
+}
+
+type CheckProgram struct {
+	Checks []CheckInterval `json:"checks,omitempty"`
+	ID     string          `json:"id,omitempty"`
+	Model  string          `json:"model,omitempty"`
+}
+
+type CheckStatus struct {
+	DueCycles       *int64            `json:"dueCycles,omitempty"`
+	DueDate         *time.Time        `json:"dueDate,omitempty"`
+	DueHours        *float64          `json:"dueHours,omitempty"`
+	Last            *MaintenanceEntry `json:"last,omitempty"`
+	Overdue         bool              `json:"overdue,omitempty"`
+	RemainingCycles *int64            `json:"remainingCycles,omitempty"`
+	RemainingHours  *float64          `json:"remainingHours,omitempty"`
+	Type            string            `json:"type,omitempty"`
+}
+
+type Cloud struct {
+	Base  int64  `json:"base,omitempty"`
+	Cover string `json:"cover,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type Cockpit struct {
+	GlassCockpit *bool `json:"glassCockpit,omitempty"`
+}
+
+type Count struct {
+	Count int64  `json:"count,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+type Engine struct {
+	HST              *int64             `json:"hst,omitempty"`
+	ID               string             `json:"id,omitempty"`
+	MaintenanceLog   []MaintenanceEntry `json:"maintenanceLog,omitempty"`
+	Model            string             `json:"model,omitempty"`
+	OwningAircraft   string             `json:"owningAircraft,omitempty"`
+	SinceHotSection  *float64           `json:"sinceHotSection,omitempty"`
+	SinceOverhaul    *float64           `json:"sinceOverhaul,omitempty"`
+	TBO              *int64             `json:"tbo,omitempty"`
+	TimeToHotSection *float64           `json:"timeToHotSection,omitempty"`
+	TimeToOverhaul   *float64           `json:"timeToOverhaul,omitempty"`
+	TotalTime        *float64           `json:"totalTime,omitempty"`
+}
+
+type EngineCreate struct {
+	HST              *int64             `json:"hst,omitempty"`
+	ID               string             `json:"id,omitempty"`
+	MaintenanceLog   []MaintenanceEntry `json:"maintenanceLog,omitempty"`
+	Model            string             `json:"model"`
+	OwningAircraft   string             `json:"owningAircraft,omitempty"`
+	SinceHotSection  *float64           `json:"sinceHotSection,omitempty"`
+	SinceOverhaul    *float64           `json:"sinceOverhaul,omitempty"`
+	TBO              *int64             `json:"tbo,omitempty"`
+	TimeToHotSection *float64           `json:"timeToHotSection,omitempty"`
+	TimeToOverhaul   *float64           `json:"timeToOverhaul,omitempty"`
+	TotalTime        *float64           `json:"totalTime,omitempty"`
+}
+
+type EnginePage struct {
+	Items []Engine `json:"items"`
+	Next  string   `json:"next,omitempty"`
+	Total int64    `json:"total,omitempty"`
+}
+
+type Entry struct {
+	Account     string     `json:"account,omitempty"`
+	AccountType string     `json:"accountType,omitempty"`
+	Amount      int64      `json:"amount,omitempty"`
+	Balance     int64      `json:"balance,omitempty"`
+	CreatedAt   *time.Time `json:"createdAt,omitempty"`
+	Description string     `json:"description,omitempty"`
+	ID          string     `json:"id,omitempty"`
+	Reference   string     `json:"reference,omitempty"`
+	Transaction string     `json:"transaction,omitempty"`
+	Type        string     `json:"type,omitempty"`
+}
+
+type Error struct {
+	Code    string          `json:"code,omitempty"`
+	Details json.RawMessage `json:"details,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+type Exterior struct {
+	Notes       string `json:"notes,omitempty"`
+	YearPainted *int64 `json:"yearPainted,omitempty"`
+}
+
+type Flight struct {
+	Aircraft            string            `json:"aircraft,omitempty"`
+	Airline             string            `json:"airline,omitempty"`
+	Arrival             string            `json:"arrival,omitempty"`
+	ArrivalTime         map[string]string `json:"arrivalTime,omitempty"`
+	AverageArrivalDelay string            `json:"averageArrivalDelay,omitempty"`
+	BlockTime           int64             `json:"blockTime,omitempty"`
+	Callsign            string            `json:"callsign,omitempty"`
+	CompletedAt         *time.Time        `json:"completedAt,omitempty"`
+	Departure           string            `json:"departure,omitempty"`
+	DepartureTime       map[string]string `json:"departureTime,omitempty"`
+	Distance            float64           `json:"distance,omitempty"`
+	FlightNumber        string            `json:"flightNumber,omitempty"`
+	FlightTime          int64             `json:"flightTime,omitempty"`
+	ID                  string            `json:"id,omitempty"`
+	Route               string            `json:"route,omitempty"`
+	Status              string            `json:"status,omitempty"`
+}
+
+type FlightCreate struct {
+	Aircraft            string            `json:"aircraft,omitempty"`
+	Airline             string            `json:"airline"`
+	Arrival             string            `json:"arrival,omitempty"`
+	ArrivalTime         map[string]string `json:"arrivalTime,omitempty"`
+	AverageArrivalDelay string            `json:"averageArrivalDelay,omitempty"`
+	BlockTime           int64             `json:"blockTime,omitempty"`
+	Callsign            string            `json:"callsign,omitempty"`
+	CompletedAt         *time.Time        `json:"completedAt,omitempty"`
+	Departure           string            `json:"departure,omitempty"`
+	DepartureTime       map[string]string `json:"departureTime,omitempty"`
+	Distance            float64           `json:"distance,omitempty"`
+	FlightNumber        string            `json:"flightNumber,omitempty"`
+	FlightTime          int64             `json:"flightTime,omitempty"`
+	ID                  string            `json:"id,omitempty"`
+	Route               string            `json:"route,omitempty"`
+	Status              string            `json:"status,omitempty"`
+}
+
+type FlightPage struct {
+	Items []Flight `json:"items"`
+	Next  string   `json:"next,omitempty"`
+	Total int64    `json:"total,omitempty"`
+}
+
+type Forecast struct {
+	Ceiling        *int64     `json:"ceiling,omitempty"`
+	Change         string     `json:"change,omitempty"`
+	Clouds         []Cloud    `json:"clouds,omitempty"`
+	Condition      string     `json:"condition,omitempty"`
+	FlightCategory string     `json:"flightCategory,omitempty"`
+	From           *time.Time `json:"from,omitempty"`
+	Probability    *int64     `json:"probability,omitempty"`
+	To             *time.Time `json:"to,omitempty"`
+	Visibility     *float64   `json:"visibility,omitempty"`
+	Wind           *Wind      `json:"wind,omitempty"`
+}
+
+type HealthReport struct {
+	Checks map[string]Status `json:"checks,omitempty"`
+	Status string            `json:"status,omitempty"`
+}
+
+type ImportAirportsRequest struct {
+	Airports string   `json:"airports,omitempty"`
+	Runways  string   `json:"runways,omitempty"`
+	Types    []string `json:"types,omitempty"`
+}
+
+type IngestedStations struct {
+	Stations []string `json:"stations,omitempty"`
+}
+
+type IntegrityReport struct {
+	Fixed  bool    `json:"fixed,omitempty"`
+	Issues []Issue `json:"issues,omitempty"`
+}
+
+type Interior struct {
+	NumberOfSeats *int64 `json:"numberOfSeats,omitempty"`
+	YearInterior  *int64 `json:"yearInterior,omitempty"`
+}
+
+type Issue struct {
+	Collection string `json:"collection,omitempty"`
+	Document   string `json:"document,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Reference  string `json:"reference,omitempty"`
+	Repair     string `json:"repair,omitempty"`
+}
+
+type JWTOutput struct {
+	Expires        *time.Time `json:"expires,omitempty"`
+	RefreshExpires *time.Time `json:"refreshExpires,omitempty"`
+	RefreshToken   string     `json:"refreshToken,omitempty"`
+	Token          string     `json:"token,omitempty"`
+}
+
+type LedgerStatement struct {
+	Balance int64   `json:"balance,omitempty"`
+	Entries []Entry `json:"entries,omitempty"`
+}
+
+type MaintenanceEntry struct {
+	Cycles    *int64     `json:"cycles,omitempty"`
+	Date      *time.Time `json:"date,omitempty"`
+	Notes     string     `json:"notes,omitempty"`
+	TotalTime float64    `json:"totalTime,omitempty"`
+	Type      string     `json:"type,omitempty"`
+}
+
+type Message struct {
+	Message string `json:"message,omitempty"`
+}
+
+type OverhaulEngineRequest struct {
+	Notes string `json:"notes,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+type Performance struct {
+	Ceiling           *float64 `json:"ceiling,omitempty"`
+	CruiseSpeed       *int64   `json:"cruiseSpeed,omitempty"`
+	FuelCapacity      *float64 `json:"fuelCapacity,omitempty"`
+	MaxLandingWeight  *float64 `json:"maxLandingWeight,omitempty"`
+	MaxSpeed          *int64   `json:"maxSpeed,omitempty"`
+	MaxTakeoffWeight  *float64 `json:"maxTakeoffWeight,omitempty"`
+	MaxZeroFuelWeight *float64 `json:"maxZeroFuelWeight,omitempty"`
+	Range             *float64 `json:"range,omitempty"`
+	TakeoffDistance   *float64 `json:"takeoffDistance,omitempty"`
+	Wingspan          *float64 `json:"wingspan,omitempty"`
+}
+
+type PurchaseReceipt struct {
+	Message string `json:"message,omitempty"`
+	Price   int64  `json:"price,omitempty"`
+}
+
+type PurchaseRequest struct {
+	Airline string `json:"airline,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type Result struct {
+	Inserted int64 `json:"inserted,omitempty"`
+	Read     int64 `json:"read,omitempty"`
+	Skipped  int64 `json:"skipped,omitempty"`
+	Updated  int64 `json:"updated,omitempty"`
+}
+
+type Review struct {
+	Airline   string     `json:"airline,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Avatar    string     `json:"avatar,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Hidden    bool       `json:"hidden,omitempty"`
+	ID        string     `json:"id,omitempty"`
+	Rating    *int64     `json:"rating,omitempty"`
+	User      string     `json:"user,omitempty"`
+}
+
+type ReviewCreate struct {
+	Airline   string     `json:"airline,omitempty"`
+	Author    string     `json:"author,omitempty"`
+	Avatar    string     `json:"avatar,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	Hidden    bool       `json:"hidden,omitempty"`
+	ID        string     `json:"id,omitempty"`
+	Rating    *int64     `json:"rating"`
+	User      string     `json:"user,omitempty"`
+}
+
+type ReviewModeration struct {
+	Hidden *bool `json:"hidden"`
+}
+
+type Route struct {
+	Airline  string   `json:"airline,omitempty"`
+	Distance float64  `json:"distance,omitempty"`
+	Flights  []string `json:"flights,omitempty"`
+	From     string   `json:"from,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	To       string   `json:"to,omitempty"`
+}
+
+type RouteCreate struct {
+	Airline  string   `json:"airline,omitempty"`
+	Distance float64  `json:"distance,omitempty"`
+	Flights  []string `json:"flights,omitempty"`
+	From     string   `json:"from"`
+	ID       string   `json:"id,omitempty"`
+	To       string   `json:"to"`
+}
+
+type RouteData struct {
+	Airline    string   `json:"airline,omitempty"`
+	Distance   float64  `json:"distance,omitempty"`
+	FlightData []Flight `json:"flightData,omitempty"`
+	Flights    []string `json:"flights,omitempty"`
+	From       *Airport `json:"from,omitempty"`
+	ID         string   `json:"id,omitempty"`
+	To         *Airport `json:"to,omitempty"`
+}
+
+type Runway struct {
+	Closed  bool   `json:"closed,omitempty"`
+	HighEnd string `json:"highEnd,omitempty"`
+	Length  *int64 `json:"length,omitempty"`
+	Lighted bool   `json:"lighted,omitempty"`
+	LowEnd  string `json:"lowEnd,omitempty"`
+	Surface string `json:"surface,omitempty"`
+	Width   *int64 `json:"width,omitempty"`
+}
+
+type SaleListing struct {
+	Price *float64 `json:"price,omitempty"`
+}
+
+type ScheduleCheckRequest struct {
+	Start *time.Time `json:"start,omitempty"`
+	Type  string     `json:"type,omitempty"`
+}
+
+type ScheduledCheck struct {
+	Cost    int64      `json:"cost,omitempty"`
+	End     *time.Time `json:"end,omitempty"`
+	Start   *time.Time `json:"start,omitempty"`
+	Started bool       `json:"started,omitempty"`
+	Type    string     `json:"type,omitempty"`
+}
+
+type SearchResult struct {
+	Aircraft []Aircraft         `json:"aircraft,omitempty"`
+	Airlines []Airline          `json:"airlines,omitempty"`
+	Airports []Airport          `json:"airports,omitempty"`
+	Facets   map[string][]Count `json:"facets,omitempty"`
+	Total    map[string]int64   `json:"total,omitempty"`
+}
+
+type ServiceAPURequest struct {
+	Notes string `json:"notes,omitempty"`
+}
+
+type SignOutRequest struct {
+	All          bool   `json:"all,omitempty"`
+	RefreshToken string `json:"refreshToken,omitempty"`
+}
+
+type Status struct {
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latencyMs,omitempty"`
+	Status    string  `json:"status,omitempty"`
+}
+
+type TrackerData struct {
+	FlightHistory []string `json:"flightHistory,omitempty"`
+}
+
+type User struct {
+	Airlines []string `json:"airlines,omitempty"`
+	Balance  *int64   `json:"balance,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	IsAdmin  *bool    `json:"isAdmin,omitempty"`
+	Name     string   `json:"name,omitempty"`
+	Password string   `json:"password,omitempty"`
+}
+
+type UserCreate struct {
+	Airlines []string `json:"airlines,omitempty"`
+	Balance  *int64   `json:"balance,omitempty"`
+	Email    string   `json:"email"`
+	ID       string   `json:"id,omitempty"`
+	IsAdmin  *bool    `json:"isAdmin,omitempty"`
+	Name     string   `json:"name"`
+	Password string   `json:"password"`
+}
+
+type UserPage struct {
+	Items []User `json:"items"`
+	Next  string `json:"next,omitempty"`
+	Total int64  `json:"total,omitempty"`
+}
+
+type Weather struct {
+	Airport        string     `json:"airport,omitempty"`
+	Ceiling        *int64     `json:"ceiling,omitempty"`
+	Clouds         []Cloud    `json:"clouds,omitempty"`
+	Condition      string     `json:"condition,omitempty"`
+	Dewpoint       *int64     `json:"dewpoint,omitempty"`
+	FlightCategory string     `json:"flightCategory,omitempty"`
+	Forecast       []Forecast `json:"forecast,omitempty"`
+	ID             string     `json:"id,omitempty"`
+	Metar          string     `json:"metar,omitempty"`
+	ObservedAt     *time.Time `json:"observedAt,omitempty"`
+	Qnh            *float64   `json:"qnh,omitempty"`
+	Taf            string     `json:"taf,omitempty"`
+	TafIssuedAt    *time.Time `json:"tafIssuedAt,omitempty"`
+	Temperature    *int64     `json:"temperature,omitempty"`
+	Visibility     *float64   `json:"visibility,omitempty"`
+	Wind           *Wind      `json:"wind,omitempty"`
+}
+
+type WeatherReports struct {
+	Metar string `json:"metar,omitempty"`
+	Taf   string `json:"taf,omitempty"`
+}
+
+type Wind struct {
+	Direction    *int64 `json:"direction,omitempty"`
+	Gust         *int64 `json:"gust,omitempty"`
+	Speed        int64  `json:"speed,omitempty"`
+	VariableFrom *int64 `json:"variableFrom,omitempty"`
+	VariableTo   *int64 `json:"variableTo,omitempty"`
+}
+
+// Welcome: greets
+//
+// GET /
+func (c *Client) Welcome(ctx context.Context) error {
+	return c.do(ctx, "GET", "/", nil, nil, nil)
+}
+
+// GetAircraft: lists aircraft, filtered by any other query parameter
+//
+// GET /aircraft?limit&after&sort
+func (c *Client) GetAircraft(ctx context.Context, query url.Values) (*AircraftPage, error) {
+	var out AircraftPage
+	if err := c.do(ctx, "GET", "/aircraft", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAircraft: creates an aircraft owned by the signed in user
+//
+// POST /aircraft
+func (c *Client) CreateAircraft(ctx context.Context, body AircraftCreate) (*Aircraft, error) {
+	var out Aircraft
+	if err := c.do(ctx, "POST", "/aircraft", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAircraftByType: lists aircraft by name
+//
+// GET /aircraft/aircraft_filter?aircraft
+func (c *Client) GetAircraftByType(ctx context.Context, query url.Values) ([]Aircraft, error) {
+	var out []Aircraft
+	err := c.do(ctx, "GET", "/aircraft/aircraft_filter", query, nil, &out)
+	return out, err
+}
+
+// DeleteAircraft: deletes an aircraft
+//
+// DELETE /aircraft/{id}
+func (c *Client) DeleteAircraft(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/aircraft/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAircraftById: gets an aircraft
+//
+// GET /aircraft/{id}
+func (c *Client) GetAircraftById(ctx context.Context, id string) (*Aircraft, error) {
+	var out Aircraft
+	if err := c.do(ctx, "GET", "/aircraft/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAircraftChecks: tells when the checks of an aircraft are due
+//
+// GET /aircraft/{id}/checks
+func (c *Client) GetAircraftChecks(ctx context.Context, id string) (*AircraftChecks, error) {
+	var out AircraftChecks
+	if err := c.do(ctx, "GET", "/aircraft/"+url.PathEscape(id)+"/checks", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ScheduleCheck: schedules a check and charges its cost to the owner
+//
+// POST /aircraft/{id}/checks
+func (c *Client) ScheduleCheck(ctx context.Context, id string, body ScheduleCheckRequest) (*ScheduledCheck, error) {
+	var out ScheduledCheck
+	if err := c.do(ctx, "POST", "/aircraft/"+url.PathEscape(id)+"/checks", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirlineData: gets the airline of an aircraft
+//
+// GET /aircraft/{id}/get_airline
+func (c *Client) GetAirlineData(ctx context.Context, id string) (*Airline, error) {
+	var out Airline
+	if err := c.do(ctx, "GET", "/aircraft/"+url.PathEscape(id)+"/get_airline", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEngineData: lists the engines of an aircraft
+//
+// GET /aircraft/{id}/get_engines
+func (c *Client) GetEngineData(ctx context.Context, id string) ([]Engine, error) {
+	var out []Engine
+	err := c.do(ctx, "GET", "/aircraft/"+url.PathEscape(id)+"/get_engines", nil, nil, &out)
+	return out, err
+}
+
+// GetOwnerData: gets the owner of an aircraft
+//
+// GET /aircraft/{id}/get_owner
+func (c *Client) GetOwnerData(ctx context.Context, id string) (*User, error) {
+	var out User
+	if err := c.do(ctx, "GET", "/aircraft/"+url.PathEscape(id)+"/get_owner", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ListAircraftForSale: lists an aircraft for sale
+//
+// PUT /aircraft/{id}/list_for_sale
+func (c *Client) ListAircraftForSale(ctx context.Context, id string, body SaleListing) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/list_for_sale", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ServiceAPU: records an APU service
+//
+// POST /aircraft/{id}/service_apu
+func (c *Client) ServiceAPU(ctx context.Context, id string, body ServiceAPURequest) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "POST", "/aircraft/"+url.PathEscape(id)+"/service_apu", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UnlistAircraft: removes an aircraft from sale
+//
+// PUT /aircraft/{id}/unlist
+func (c *Client) UnlistAircraft(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/unlist", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAirframe: updates the airframe
+//
+// PUT /aircraft/{id}/update_airframe
+func (c *Client) UpdateAirframe(ctx context.Context, id string, body Airframe) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_airframe", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAPU: updates the APU
+//
+// PUT /aircraft/{id}/update_apu
+func (c *Client) UpdateAPU(ctx context.Context, id string, body APU) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_apu", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateCockpit: updates the cockpit
+//
+// PUT /aircraft/{id}/update_cockpit
+func (c *Client) UpdateCockpit(ctx context.Context, id string, body Cockpit) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_cockpit", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateEngines: toggles the installation of the given engines
+//
+// PUT /aircraft/{id}/update_engines
+func (c *Client) UpdateEngines(ctx context.Context, id string, body Aircraft) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_engines", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateExterior: updates the exterior
+//
+// PUT /aircraft/{id}/update_exterior
+func (c *Client) UpdateExterior(ctx context.Context, id string, body Exterior) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_exterior", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateGeneral: updates the general data
+//
+// PUT /aircraft/{id}/update_general
+func (c *Client) UpdateGeneral(ctx context.Context, id string, body AircraftGeneral) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_general", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateInterior: updates the interior
+//
+// PUT /aircraft/{id}/update_interior
+func (c *Client) UpdateInterior(ctx context.Context, id string, body Interior) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_interior", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateOwner: changes the owner, admins only
+//
+// PUT /aircraft/{id}/update_owner
+func (c *Client) UpdateOwner(ctx context.Context, id string, body Aircraft) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_owner", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdatePerformance: updates the performance
+//
+// PUT /aircraft/{id}/update_performance
+func (c *Client) UpdatePerformance(ctx context.Context, id string, body Performance) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_performance", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateTags: toggles the given tags
+//
+// PUT /aircraft/{id}/update_tags
+func (c *Client) UpdateTags(ctx context.Context, id string, body Aircraft) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/aircraft/"+url.PathEscape(id)+"/update_tags", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirlines: lists airlines, filtered by any other query parameter
+//
+// GET /airlines?limit&after&sort
+func (c *Client) GetAirlines(ctx context.Context, query url.Values) (*AirlinePage, error) {
+	var out AirlinePage
+	if err := c.do(ctx, "GET", "/airlines", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateAirline: creates an airline owned by the signed in user
+//
+// POST /airlines
+func (c *Client) CreateAirline(ctx context.Context, body AirlineCreate) (*Airline, error) {
+	var out Airline
+	if err := c.do(ctx, "POST", "/airlines", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteAirline: deletes an airline
+//
+// DELETE /airlines/{id}
+func (c *Client) DeleteAirline(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/airlines/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFleetData: lists the fleet of an airline
+//
+// GET /airlines/{id}/get_fleet
+func (c *Client) GetFleetData(ctx context.Context, id string) ([]Aircraft, error) {
+	var out []Aircraft
+	err := c.do(ctx, "GET", "/airlines/"+url.PathEscape(id)+"/get_fleet", nil, nil, &out)
+	return out, err
+}
+
+// GetAirlineOwnerData: gets the owner of an airline
+//
+// GET /airlines/{id}/get_owner
+func (c *Client) GetAirlineOwnerData(ctx context.Context, id string) (*User, error) {
+	var out User
+	if err := c.do(ctx, "GET", "/airlines/"+url.PathEscape(id)+"/get_owner", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirlineLedger: gets the balance and the ledger entries of an airline
+//
+// GET /airlines/{id}/ledger?from&to&format
+func (c *Client) GetAirlineLedger(ctx context.Context, id string, query url.Values) (*LedgerStatement, error) {
+	var out LedgerStatement
+	if err := c.do(ctx, "GET", "/airlines/"+url.PathEscape(id)+"/ledger", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdjustAirlineBalance: adjusts the balance of an airline, admins only
+//
+// POST /airlines/{id}/ledger
+func (c *Client) AdjustAirlineBalance(ctx context.Context, id string, body BalanceAdjustment) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "POST", "/airlines/"+url.PathEscape(id)+"/ledger", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetReviews: lists the visible reviews of an airline
+//
+// GET /airlines/{id}/reviews
+func (c *Client) GetReviews(ctx context.Context, id string) ([]Review, error) {
+	var out []Review
+	err := c.do(ctx, "GET", "/airlines/"+url.PathEscape(id)+"/reviews", nil, nil, &out)
+	return out, err
+}
+
+// CreateReview: reviews an airline
+//
+// POST /airlines/{id}/reviews
+func (c *Client) CreateReview(ctx context.Context, id string, body ReviewCreate) (*Review, error) {
+	var out Review
+	if err := c.do(ctx, "POST", "/airlines/"+url.PathEscape(id)+"/reviews", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteReview: deletes a review, by its author or an admin
+//
+// DELETE /airlines/{id}/reviews/{reviewId}
+func (c *Client) DeleteReview(ctx context.Context, id string, reviewID string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/airlines/"+url.PathEscape(id)+"/reviews/"+url.PathEscape(reviewID), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// HideReview: hides or shows a review, admins only
+//
+// PUT /airlines/{id}/reviews/{reviewId}/hide
+func (c *Client) HideReview(ctx context.Context, id string, reviewID string, body ReviewModeration) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/reviews/"+url.PathEscape(reviewID)+"/hide", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirlineRoutes: lists the routes of an airline with their airports and flights
+//
+// GET /airlines/{id}/routes
+func (c *Client) GetAirlineRoutes(ctx context.Context, id string) ([]RouteData, error) {
+	var out []RouteData
+	err := c.do(ctx, "GET", "/airlines/"+url.PathEscape(id)+"/routes", nil, nil, &out)
+	return out, err
+}
+
+// CreateRoute: adds a route to an airline
+//
+// POST /airlines/{id}/routes
+func (c *Client) CreateRoute(ctx context.Context, id string, body RouteCreate) (*Route, error) {
+	var out Route
+	if err := c.do(ctx, "POST", "/airlines/"+url.PathEscape(id)+"/routes", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateFleet: toggles the given aircraft in the fleet
+//
+// PUT /airlines/{id}/update_fleet
+func (c *Client) UpdateFleet(ctx context.Context, id string, body Airline) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/update_fleet", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAirlineGeneral: updates the general data
+//
+// PUT /airlines/{id}/update_general
+func (c *Client) UpdateAirlineGeneral(ctx context.Context, id string, body AirlineGeneral) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/update_general", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAirlineOwner: changes the owner, admins only
+//
+// PUT /airlines/{id}/update_owner
+func (c *Client) UpdateAirlineOwner(ctx context.Context, id string, body Airline) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/update_owner", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateReviews: toggles the given reviews
+//
+// PUT /airlines/{id}/update_review
+func (c *Client) UpdateReviews(ctx context.Context, id string, body Airline) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/update_review", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateRoutes: toggles the given routes
+//
+// PUT /airlines/{id}/update_routes
+func (c *Client) UpdateRoutes(ctx context.Context, id string, body Airline) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airlines/"+url.PathEscape(id)+"/update_routes", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirports: finds airports
+//
+// GET /airports?icao&iata&country&name
+func (c *Client) GetAirports(ctx context.Context, query url.Values) ([]Airport, error) {
+	var out []Airport
+	err := c.do(ctx, "GET", "/airports", query, nil, &out)
+	return out, err
+}
+
+// GetAirportByIATA: gets an airport by its IATA code
+//
+// GET /airports/iata/{iata}
+func (c *Client) GetAirportByIATA(ctx context.Context, iata string) (*Airport, error) {
+	var out Airport
+	if err := c.do(ctx, "GET", "/airports/iata/"+url.PathEscape(iata), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// ImportAirports: imports OurAirports csv files of the import directory, admins only
+//
+// POST /airports/import
+func (c *Client) ImportAirports(ctx context.Context, body ImportAirportsRequest) (*Result, error) {
+	var out Result
+	if err := c.do(ctx, "POST", "/airports/import", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirportByICAO: gets an airport by its ICAO code
+//
+// GET /airports/{icao}
+func (c *Client) GetAirportByICAO(ctx context.Context, icao string) (*Airport, error) {
+	var out Airport
+	if err := c.do(ctx, "GET", "/airports/"+url.PathEscape(icao), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAirportWeather: gets the current METAR and TAF of an airport
+//
+// GET /airports/{icao}/weather
+func (c *Client) GetAirportWeather(ctx context.Context, icao string) (*Weather, error) {
+	var out Weather
+	if err := c.do(ctx, "GET", "/airports/"+url.PathEscape(icao)+"/weather", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateAirportWeather: stores raw METAR and TAF reports, admins only
+//
+// PUT /airports/{icao}/weather
+func (c *Client) UpdateAirportWeather(ctx context.Context, icao string, body WeatherReports) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/airports/"+url.PathEscape(icao)+"/weather", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCheckPrograms: lists the check programs
+//
+// GET /check_programs
+func (c *Client) GetCheckPrograms(ctx context.Context) ([]CheckProgram, error) {
+	var out []CheckProgram
+	err := c.do(ctx, "GET", "/check_programs", nil, nil, &out)
+	return out, err
+}
+
+// UpdateCheckProgram: replaces the check program of a model, admins only
+//
+// PUT /check_programs/{model}
+func (c *Client) UpdateCheckProgram(ctx context.Context, model string, body CheckProgram) (*CheckProgram, error) {
+	var out CheckProgram
+	if err := c.do(ctx, "PUT", "/check_programs/"+url.PathEscape(model), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEngines: lists engines, filtered by any other query parameter
+//
+// GET /engines?limit&after&sort
+func (c *Client) GetEngines(ctx context.Context, query url.Values) (*EnginePage, error) {
+	var out EnginePage
+	if err := c.do(ctx, "GET", "/engines", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateEngine: creates an engine owned by the signed in user
+//
+// POST /engines
+func (c *Client) CreateEngine(ctx context.Context, body EngineCreate) (*Engine, error) {
+	var out Engine
+	if err := c.do(ctx, "POST", "/engines", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetDueEngines: lists engines due for an overhaul or a hot section inspection
+//
+// GET /engines/due?threshold
+func (c *Client) GetDueEngines(ctx context.Context, query url.Values) ([]Engine, error) {
+	var out []Engine
+	err := c.do(ctx, "GET", "/engines/due", query, nil, &out)
+	return out, err
+}
+
+// DeleteEngine: deletes an engine
+//
+// DELETE /engines/{id}
+func (c *Client) DeleteEngine(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/engines/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetEngineById: gets an engine
+//
+// GET /engines/{id}
+func (c *Client) GetEngineById(ctx context.Context, id string) (*Engine, error) {
+	var out Engine
+	if err := c.do(ctx, "GET", "/engines/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateEngine: updates an engine
+//
+// PUT /engines/{id}
+func (c *Client) UpdateEngine(ctx context.Context, id string, body Engine) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/engines/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// OverhaulEngine: records an overhaul or a hot section inspection
+//
+// POST /engines/{id}/overhaul
+func (c *Client) OverhaulEngine(ctx context.Context, id string, body OverhaulEngineRequest) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "POST", "/engines/"+url.PathEscape(id)+"/overhaul", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFlights: lists flights, filtered by any other query parameter
+//
+// GET /flights?limit&after&sort
+func (c *Client) GetFlights(ctx context.Context, query url.Values) (*FlightPage, error) {
+	var out FlightPage
+	if err := c.do(ctx, "GET", "/flights", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CreateFlight: schedules a flight
+//
+// POST /flights
+func (c *Client) CreateFlight(ctx context.Context, body FlightCreate) (*Flight, error) {
+	var out Flight
+	if err := c.do(ctx, "POST", "/flights", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetFlightById: gets a flight
+//
+// GET /flights/{id}
+func (c *Client) GetFlightById(ctx context.Context, id string) (*Flight, error) {
+	var out Flight
+	if err := c.do(ctx, "GET", "/flights/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateFlight: updates a scheduled flight
+//
+// PUT /flights/{id}
+func (c *Client) UpdateFlight(ctx context.Context, id string, body Flight) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/flights/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CancelFlight: cancels a scheduled flight
+//
+// PUT /flights/{id}/cancel
+func (c *Client) CancelFlight(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/flights/"+url.PathEscape(id)+"/cancel", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CompleteFlight: completes a flight and adds its time to the aircraft
+//
+// PUT /flights/{id}/complete
+func (c *Client) CompleteFlight(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/flights/"+url.PathEscape(id)+"/complete", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Healthz: tells the process is alive
+//
+// GET /healthz
+func (c *Client) Healthz(ctx context.Context) (*HealthReport, error) {
+	var out HealthReport
+	if err := c.do(ctx, "GET", "/healthz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// CheckIntegrity: reports broken references between collections, admins only
+//
+// GET /integrity
+func (c *Client) CheckIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	var out IntegrityReport
+	if err := c.do(ctx, "GET", "/integrity", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FixIntegrity: repairs broken references between collections, admins only
+//
+// POST /integrity/fix
+func (c *Client) FixIntegrity(ctx context.Context) (*IntegrityReport, error) {
+	var out IntegrityReport
+	if err := c.do(ctx, "POST", "/integrity/fix", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetMarketplace: lists aircraft for sale
+//
+// GET /marketplace?minPrice&maxPrice&manufacturer&model&condition&location
+func (c *Client) GetMarketplace(ctx context.Context, query url.Values) ([]Aircraft, error) {
+	var out []Aircraft
+	err := c.do(ctx, "GET", "/marketplace", query, nil, &out)
+	return out, err
+}
+
+// BuyAircraft: buys an aircraft for sale
+//
+// POST /marketplace/{id}/buy
+func (c *Client) BuyAircraft(ctx context.Context, id string, body PurchaseRequest) (*PurchaseReceipt, error) {
+	var out PurchaseReceipt
+	if err := c.do(ctx, "POST", "/marketplace/"+url.PathEscape(id)+"/buy", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Readyz: pings MongoDB and Redis, 503 with the same report when one does not answer
+//
+// GET /readyz
+func (c *Client) Readyz(ctx context.Context) (*HealthReport, error) {
+	var out HealthReport
+	if err := c.do(ctx, "GET", "/readyz", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Refresh: exchanges a refresh token for new tokens
+//
+// POST /refresh
+func (c *Client) Refresh(ctx context.Context, body RefreshRequest) (*JWTOutput, error) {
+	var out JWTOutput
+	if err := c.do(ctx, "POST", "/refresh", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// DeleteRoute: deletes a route
+//
+// DELETE /routes/{id}
+func (c *Client) DeleteRoute(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/routes/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetRouteById: gets a route with its airports and flights
+//
+// GET /routes/{id}
+func (c *Client) GetRouteById(ctx context.Context, id string) (*RouteData, error) {
+	var out RouteData
+	if err := c.do(ctx, "GET", "/routes/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Search: searches aircraft, airlines and airports at once
+//
+// GET /search?q&limit&types&fuzzy&manufacturer&condition&location&tag
+func (c *Client) Search(ctx context.Context, query url.Values) (*SearchResult, error) {
+	var out SearchResult
+	if err := c.do(ctx, "GET", "/search", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SignIn: starts a session and issues tokens
+//
+// POST /signin
+func (c *Client) SignIn(ctx context.Context, body User) (*JWTOutput, error) {
+	var out JWTOutput
+	if err := c.do(ctx, "POST", "/signin", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SignOut: ends the session and revokes refresh tokens
+//
+// POST /signout
+func (c *Client) SignOut(ctx context.Context, body SignOutRequest) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "POST", "/signout", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SignUp: creates a user
+//
+// POST /signup
+func (c *Client) SignUp(ctx context.Context, body UserCreate) (*User, error) {
+	var out User
+	if err := c.do(ctx, "POST", "/signup", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUsers: lists users, filtered by any other query parameter
+//
+// GET /users?limit&after&sort
+func (c *Client) GetUsers(ctx context.Context, query url.Values) (*UserPage, error) {
+	var out UserPage
+	if err := c.do(ctx, "GET", "/users", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserByAirline: lists the owners of an airline
+//
+// GET /users/airline_filter?airlines
+func (c *Client) GetUserByAirline(ctx context.Context, query url.Values) ([]User, error) {
+	var out []User
+	err := c.do(ctx, "GET", "/users/airline_filter", query, nil, &out)
+	return out, err
+}
+
+// DeleteUser: deletes the signed in user and their airlines
+//
+// DELETE /users/{id}
+func (c *Client) DeleteUser(ctx context.Context, id string) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "DELETE", "/users/"+url.PathEscape(id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUser: updates the signed in user, admin rights only by admins
+//
+// PUT /users/{id}
+func (c *Client) UpdateUser(ctx context.Context, id string, body User) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/users/"+url.PathEscape(id), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetUserAirlinesData: lists the airlines of a user
+//
+// GET /users/{id}/get_airlines
+func (c *Client) GetUserAirlinesData(ctx context.Context, id string) ([]Airline, error) {
+	var out []Airline
+	err := c.do(ctx, "GET", "/users/"+url.PathEscape(id)+"/get_airlines", nil, nil, &out)
+	return out, err
+}
+
+// GetUserLedger: gets the balance and the ledger entries of a user
+//
+// GET /users/{id}/ledger?from&to&format
+func (c *Client) GetUserLedger(ctx context.Context, id string, query url.Values) (*LedgerStatement, error) {
+	var out LedgerStatement
+	if err := c.do(ctx, "GET", "/users/"+url.PathEscape(id)+"/ledger", query, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// AdjustUserBalance: adjusts the balance of a user, admins only
+//
+// POST /users/{id}/ledger
+func (c *Client) AdjustUserBalance(ctx context.Context, id string, body BalanceAdjustment) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "POST", "/users/"+url.PathEscape(id)+"/ledger", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// UpdateUserAirlines: toggles the ownership of the given airlines
+//
+// PUT /users/{id}/update_airlines
+func (c *Client) UpdateUserAirlines(ctx context.Context, id string, body User) (*Message, error) {
+	var out Message
+	if err := c.do(ctx, "PUT", "/users/"+url.PathEscape(id)+"/update_airlines", nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// IngestWeather: ingests the weather drop directory, admins only
+//
+// POST /weather/ingest
+func (c *Client) IngestWeather(ctx context.Context) (*IngestedStations, error) {
+	var out IngestedStations
+	if err := c.do(ctx, "POST", "/weather/ingest", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,401 @@
+// Package config loads the settings of the server. A setting comes from, last one winning, the defaults
+// of the profile, the YAML file, the environment (a .env file included) and the command line flags
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v2"
+)
+
+// profiles
+const (
+	Dev  = "dev"
+	Test = "test"
+	Prod = "prod"
+)
+
+// Field tags: env is the environment variable and flag the command line flag of a setting,
+// required settings cannot be empty and secrets are never printed
+type Config struct {
+	Profile string `yaml:"profile"`
+	Addr    string `yaml:"addr" env:"ADDR" flag:"addr" usage:"address the server listens on" required:"true"`
+	// how long in-flight requests may take to finish after SIGTERM
+	ShutdownTimeout time.Duration `yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	Startup         Startup       `yaml:"startup"`
+	Mongo           Mongo         `yaml:"mongo"`
+	Redis           Redis         `yaml:"redis"`
+	Session         Session       `yaml:"session"`
+	Auth            Auth          `yaml:"auth"`
+	Cache           Cache         `yaml:"cache"`
+	Weather         Weather       `yaml:"weather"`
+	Airports        Airports      `yaml:"airports"`
+	Maintenance     Maintenance   `yaml:"maintenance"`
+}
+
+// how startup retries MongoDB and Redis before it gives up
+type Startup struct {
+	Attempts int `yaml:"attempts" env:"STARTUP_ATTEMPTS"`
+	// the first wait between attempts, it doubles up to MaxDelay
+	Delay    time.Duration `yaml:"delay" env:"STARTUP_DELAY"`
+	MaxDelay time.Duration `yaml:"maxDelay" env:"STARTUP_MAX_DELAY"`
+}
+
+type Mongo struct {
+	URI         string      `yaml:"uri" env:"CONNECTION_STRING" flag:"mongo-uri" usage:"MongoDB connection string" secret:"true" required:"true"`
+	Database    string      `yaml:"database" env:"DATABASE" flag:"mongo-database" usage:"MongoDB database" required:"true"`
+	Collections Collections `yaml:"collections"`
+}
+
+type Collections struct {
+	Users         string `yaml:"users" env:"USERS" required:"true"`
+	Aircraft      string `yaml:"aircraft" env:"AIRCRAFT" required:"true"`
+	Engines       string `yaml:"engines" env:"ENGINES" required:"true"`
+	Airlines      string `yaml:"airlines" env:"AIRLINES" required:"true"`
+	Flights       string `yaml:"flights" env:"FLIGHTS" required:"true"`
+	Reviews       string `yaml:"reviews" env:"REVIEWS" required:"true"`
+	Routes        string `yaml:"routes" env:"ROUTES" required:"true"`
+	Airports      string `yaml:"airports" env:"AIRPORTS" required:"true"`
+	Weather       string `yaml:"weather" env:"WEATHER" required:"true"`
+	Ledger        string `yaml:"ledger" env:"LEDGER" required:"true"`
+	Accounts      string `yaml:"accounts" env:"ACCOUNTS" required:"true"`
+	CheckPrograms string `yaml:"checkPrograms" env:"CHECK_PROGRAMS" required:"true"`
+}
+
+type Redis struct {
+	Addr     string `yaml:"addr" env:"REDIS_ADDR" flag:"redis-addr" usage:"Redis address" required:"true"`
+	Password string `yaml:"password" env:"REDIS_PASSWORD" secret:"true"`
+	DB       int    `yaml:"db" env:"REDIS_DB"`
+}
+
+type Session struct {
+	// signs the session cookies
+	Secret string `yaml:"secret" env:"SESSION_SECRET" secret:"true" required:"true"`
+}
+
+type Auth struct {
+	JWTSecret  string        `yaml:"jwtSecret" env:"JWT_SECRET" secret:"true" required:"true"`
+	AccessTTL  time.Duration `yaml:"accessTTL" env:"JWT_ACCESS_TTL"`
+	RefreshTTL time.Duration `yaml:"refreshTTL" env:"JWT_REFRESH_TTL"`
+	// what the system account pays new users
+	StartingBalance int `yaml:"startingBalance" env:"STARTING_BALANCE"`
+}
+
+type Cache struct {
+	// redis, lru or none
+	Backend string `yaml:"backend" env:"CACHE" flag:"cache" usage:"where handler reads are cached: redis, lru or none"`
+	// entries of the lru cache
+	Size int `yaml:"size" env:"CACHE_SIZE"`
+	// how long entries live unless their entity has a TTL of its own
+	TTL  time.Duration `yaml:"ttl" env:"CACHE_TTL"`
+	TTLs CacheTTLs     `yaml:"ttls"`
+}
+
+// time to live of the entries of each entity, 0 is the cache TTL
+type CacheTTLs struct {
+	Users    time.Duration `yaml:"users" env:"CACHE_TTL_USERS"`
+	Aircraft time.Duration `yaml:"aircraft" env:"CACHE_TTL_AIRCRAFT"`
+	Engines  time.Duration `yaml:"engines" env:"CACHE_TTL_ENGINES"`
+	Airlines time.Duration `yaml:"airlines" env:"CACHE_TTL_AIRLINES"`
+	Flights  time.Duration `yaml:"flights" env:"CACHE_TTL_FLIGHTS"`
+	Routes   time.Duration `yaml:"routes" env:"CACHE_TTL_ROUTES"`
+	Airports time.Duration `yaml:"airports" env:"CACHE_TTL_AIRPORTS"`
+	Weather  time.Duration `yaml:"weather" env:"CACHE_TTL_WEATHER"`
+	Search   time.Duration `yaml:"search" env:"CACHE_TTL_SEARCH"`
+}
+
+// EntityTTL is the time to live of the entries of an entity, e.g. aircraft
+func (c Cache) EntityTTL(entity string) time.Duration {
+	ttl := c.TTL
+	each(reflect.ValueOf(&c.TTLs).Elem(), "", func(field reflect.StructField, value reflect.Value, path string) {
+		if path == entity && value.Int() > 0 {
+			ttl = time.Duration(value.Int())
+		}
+	})
+	return ttl
+}
+
+type Weather struct {
+	// METAR and TAF files dropped here are ingested, nothing is watched without it
+	DropDir      string        `yaml:"dropDir" env:"WEATHER_DROP_DIR"`
+	DropInterval time.Duration `yaml:"dropInterval" env:"WEATHER_DROP_INTERVAL"`
+}
+
+type Airports struct {
+	// the OurAirports files an import reads are inside it
+	ImportDir string `yaml:"importDir" env:"AIRPORTS_IMPORT_DIR"`
+}
+
+type Maintenance struct {
+	ChecksSweepInterval time.Duration `yaml:"checksSweepInterval" env:"CHECKS_SWEEP_INTERVAL"`
+	// hours before an engine inspection that make the engine due
+	EngineDueThreshold float64 `yaml:"engineDueThreshold" env:"ENGINE_DUE_THRESHOLD"`
+	// hours between APU services
+	APUInterval float64 `yaml:"apuInterval" env:"APU_MAINTENANCE_INTERVAL"`
+	// minutes the APU runs at the gate per flight
+	APUGroundMinutes float64 `yaml:"apuGroundMinutes" env:"APU_GROUND_MINUTES"`
+}
+
+// Defaults are the settings of a profile before the file, the environment and the flags.
+// Only dev and test have secrets, prod ones must be configured
+func Defaults(profile string) *Config {
+	c := &Config{
+		Profile:         profile,
+		Addr:            ":3000",
+		ShutdownTimeout: 30 * time.Second,
+		Startup:         Startup{Attempts: 10, Delay: 500 * time.Millisecond, MaxDelay: 30 * time.Second},
+		Mongo: Mongo{Collections: Collections{
+			Users:         "users",
+			Aircraft:      "aircraft",
+			Engines:       "engines",
+			Airlines:      "airlines",
+			Flights:       "flights",
+			Reviews:       "reviews",
+			Routes:        "routes",
+			Airports:      "airports",
+			Weather:       "weather",
+			Ledger:        "ledger",
+			Accounts:      "accounts",
+			CheckPrograms: "check_programs"}},
+		Redis:       Redis{Addr: "localhost:6379"},
+		Auth:        Auth{AccessTTL: 10 * time.Minute, RefreshTTL: 30 * 24 * time.Hour},
+		Cache:       Cache{Backend: "redis", Size: 1000, TTL: 5 * time.Minute},
+		Weather:     Weather{DropInterval: time.Minute},
+		Airports:    Airports{ImportDir: "data"},
+		Maintenance: Maintenance{ChecksSweepInterval: time.Minute, EngineDueThreshold: 100, APUInterval: 500, APUGroundMinutes: 30},
+	}
+	switch profile {
+	case Dev:
+		c.Mongo.URI = "mongodb://localhost:27017"
+		c.Mongo.Database = "x-airlines"
+		c.Session.Secret = "dev-session-secret"
+		c.Auth.JWTSecret = "dev-jwt-secret"
+	case Test:
+		c.Addr = ":3001"
+		c.Mongo.URI = "mongodb://localhost:27017"
+		c.Mongo.Database = "x-airlines-test"
+		c.Redis.DB = 1
+		c.Session.Secret = "test-session-secret"
+		c.Auth.JWTSecret = "test-jwt-secret"
+		c.Cache.Backend = "none"
+	}
+	return c
+}
+
+// Load reads the settings, args are the command line arguments without the program name.
+// It returns the arguments after the flags, e.g. a subcommand, and leaves validation to Validate
+func Load(args []string) (*Config, []string, error) {
+	flags := flag.NewFlagSet("x-airlines", flag.ContinueOnError)
+	profile := flags.String("profile", "", "dev, test or prod, PROFILE by default")
+	file := flags.String("config", "", "YAML file of settings, CONFIG_FILE or config.<profile>.yaml if it exists by default")
+	// flags of the settings are strings until the profile and the file are read
+	each(reflect.ValueOf(&Config{}).Elem(), "", func(field reflect.StructField, value reflect.Value, path string) {
+		if name := field.Tag.Get("flag"); name != "" {
+			flags.String(name, "", field.Tag.Get("usage"))
+		}
+	})
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+	// a missing .env is fine, it never overrides the environment
+	godotenv.Load()
+
+	if *profile == "" {
+		*profile = os.Getenv("PROFILE")
+	}
+	// a missing profile is an error, not dev with its built-in secrets
+	if *profile == "" {
+		return nil, nil, errors.New("config: no profile, set -profile or PROFILE to dev, test or prod")
+	}
+	if *profile != Dev && *profile != Test && *profile != Prod {
+		return nil, nil, fmt.Errorf("config: unknown profile %q, use dev, test or prod", *profile)
+	}
+	c := Defaults(*profile)
+
+	if *file == "" {
+		*file = os.Getenv("CONFIG_FILE")
+	}
+	if *file == "" {
+		if _, err := os.Stat("config." + *profile + ".yaml"); err == nil {
+			*file = "config." + *profile + ".yaml"
+		}
+	}
+	if *file != "" {
+		data, err := ioutil.ReadFile(*file)
+		if err != nil {
+			return nil, nil, fmt.Errorf("config: %v", err)
+		}
+		if err = yaml.UnmarshalStrict(data, c); err != nil {
+			return nil, nil, fmt.Errorf("config: %s: %v", *file, err)
+		}
+		// the profile picks the file, not the other way round
+		c.Profile = *profile
+	}
+
+	given := map[string]string{}
+	flags.Visit(func(f *flag.Flag) {
+		given[f.Name] = f.Value.String()
+	})
+	var err error
+	each(reflect.ValueOf(c).Elem(), "", func(field reflect.StructField, value reflect.Value, path string) {
+		// empty variables do not clear a setting
+		if key := field.Tag.Get("env"); key != "" && os.Getenv(key) != "" && err == nil {
+			if e := set(value, os.Getenv(key)); e != nil {
+				err = fmt.Errorf("config: %s: %v", key, e)
+			}
+		}
+		if text, ok := given[field.Tag.Get("flag")]; ok && err == nil {
+			if e := set(value, text); e != nil {
+				err = fmt.Errorf("config: -%s: %v", field.Tag.Get("flag"), e)
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return c, flags.Args(), nil
+}
+
+// Validate reports every missing required setting and every invalid value at once
+func (c *Config) Validate() error {
+	var problems []string
+	each(reflect.ValueOf(c).Elem(), "", func(field reflect.StructField, value reflect.Value, path string) {
+		if field.Tag.Get("required") == "true" && value.IsZero() {
+			problem := path + " is required"
+			if key := field.Tag.Get("env"); key != "" {
+				problem += " (" + key + ")"
+			}
+			problems = append(problems, problem)
+		}
+	})
+	switch c.Cache.Backend {
+	case "redis", "lru", "none":
+	default:
+		problems = append(problems, "cache.backend must be redis, lru or none")
+	}
+	if c.Cache.Backend == "lru" && c.Cache.Size <= 0 {
+		problems = append(problems, "cache.size must be positive")
+	}
+	// the secrets of dev and test are in the source, other profiles cannot use them
+	if c.Profile != Dev && c.Profile != Test {
+		for _, profile := range []string{Dev, Test} {
+			builtIn := Defaults(profile)
+			if c.Session.Secret == builtIn.Session.Secret {
+				problems = append(problems, "session.secret is the built-in "+profile+" secret")
+			}
+			if c.Auth.JWTSecret == builtIn.Auth.JWTSecret {
+				problems = append(problems, "auth.jwtSecret is the built-in "+profile+" secret")
+			}
+		}
+	}
+	if c.Startup.Attempts <= 0 {
+		problems = append(problems, "startup.attempts must be positive")
+	}
+	for path, interval := range map[string]time.Duration{
+		"shutdownTimeout":                 c.ShutdownTimeout,
+		"startup.delay":                   c.Startup.Delay,
+		"startup.maxDelay":                c.Startup.MaxDelay,
+		"cache.ttl":                       c.Cache.TTL,
+		"weather.dropInterval":            c.Weather.DropInterval,
+		"maintenance.checksSweepInterval": c.Maintenance.ChecksSweepInterval} {
+		if interval <= 0 {
+			problems = append(problems, path+" must be positive")
+		}
+	}
+	each(reflect.ValueOf(&c.Cache.TTLs).Elem(), "cache.ttls.", func(field reflect.StructField, value reflect.Value, path string) {
+		if value.Int() < 0 {
+			problems = append(problems, path+" cannot be negative")
+		}
+	})
+	if c.Maintenance.APUInterval <= 0 {
+		problems = append(problems, "maintenance.apuInterval must be positive")
+	}
+	if c.Maintenance.APUGroundMinutes < 0 {
+		problems = append(problems, "maintenance.apuGroundMinutes cannot be negative")
+	}
+	if len(problems) > 0 {
+		return errors.New("config: " + strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+// Redacted is a copy of the settings with the secrets masked
+func (c Config) Redacted() Config {
+	each(reflect.ValueOf(&c).Elem(), "", func(field reflect.StructField, value reflect.Value, path string) {
+		if field.Tag.Get("secret") == "true" && !value.IsZero() {
+			value.SetString("******")
+		}
+	})
+	return c
+}
+
+// String is the YAML of the settings without their secrets
+func (c Config) String() string {
+	data, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+// calls visit with every setting and its YAML path, e.g. mongo.collections.users
+func each(v reflect.Value, prefix string, visit func(field reflect.StructField, value reflect.Value, path string)) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := prefix + strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.Struct {
+			each(v.Field(i), path+".", visit)
+			continue
+		}
+		visit(field, v.Field(i), path)
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// sets a setting from its text
+func set(value reflect.Value, text string) error {
+	if value.Type() == durationType {
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(text)
+	case reflect.Int:
+		n, err := strconv.Atoi(text)
+		if err != nil {
+			return err
+		}
+		value.SetInt(int64(n))
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	default:
+		return fmt.Errorf("unsupported setting type %s", value.Type())
+	}
+	return nil
+}
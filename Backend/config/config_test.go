@@ -0,0 +1,175 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// a settings file in a directory of its own, which is also the working directory without a .env
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "config.yaml")
+	if err = ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "addr: \":4000\"\nprofile: prod\ncache:\n  ttl: 2m\n  ttls:\n    aircraft: 1m\n")
+	tests := []struct {
+		name    string
+		env     map[string]string
+		args    []string
+		addr    string
+		profile string
+	}{
+		{"profile defaults", nil, []string{"-profile", "test"}, ":3001", Test},
+		{"file over defaults", nil, []string{"-profile", "dev", "-config", file}, ":4000", Dev},
+		{"environment over file", map[string]string{"ADDR": ":5000"}, []string{"-profile", "dev", "-config", file}, ":5000", Dev},
+		{"flags over environment", map[string]string{"ADDR": ":5000"}, []string{"-profile", "dev", "-config", file, "-addr", ":6000"}, ":6000", Dev},
+		{"empty variable keeps the setting", map[string]string{"ADDR": ""}, []string{"-profile", "dev", "-config", file}, ":4000", Dev},
+		{"profile and file from the environment", map[string]string{"PROFILE": "test", "CONFIG_FILE": file}, nil, ":4000", Test},
+		{"profile flag over environment", map[string]string{"PROFILE": "prod"}, []string{"-profile", "dev"}, ":3000", Dev},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"PROFILE", "CONFIG_FILE", "ADDR"} {
+				t.Setenv(key, test.env[key])
+			}
+			c, _, err := Load(test.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if c.Addr != test.addr {
+				t.Errorf("addr %q, want %q", c.Addr, test.addr)
+			}
+			// the file never changes the profile
+			if c.Profile != test.profile {
+				t.Errorf("profile %q, want %q", c.Profile, test.profile)
+			}
+		})
+	}
+}
+
+func TestLoadCacheTTLs(t *testing.T) {
+	file := writeFile(t, "cache:\n  ttl: 2m\n  ttls:\n    aircraft: 1m\n    users: 30s\n")
+	t.Setenv("CACHE_TTL_USERS", "10s")
+	c, _, err := Load([]string{"-profile", "dev", "-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		entity string
+		ttl    time.Duration
+	}{
+		{"aircraft", time.Minute},
+		{"users", 10 * time.Second},
+		// entities without a TTL of their own
+		{"airlines", 2 * time.Minute},
+		{"unknown", 2 * time.Minute},
+	}
+	for _, test := range tests {
+		if ttl := c.Cache.EntityTTL(test.entity); ttl != test.ttl {
+			t.Errorf("TTL of %s %s, want %s", test.entity, ttl, test.ttl)
+		}
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := writeFile(t, "unknownSetting: 1\n")
+	tests := []struct {
+		name     string
+		attempts string
+		args     []string
+		err      string
+	}{
+		{"no profile", "", nil, "no profile"},
+		{"unknown profile", "", []string{"-profile", "staging"}, "unknown profile"},
+		{"unknown setting in the file", "", []string{"-profile", "dev", "-config", file}, "unknownSetting"},
+		{"invalid variable", "many", []string{"-profile", "dev"}, "STARTUP_ATTEMPTS"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("PROFILE", "")
+			t.Setenv("CONFIG_FILE", "")
+			t.Setenv("STARTUP_ATTEMPTS", test.attempts)
+			_, _, err := Load(test.args)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error %v, want one about %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestLoadReturnsSubcommand(t *testing.T) {
+	t.Setenv("CONFIG_FILE", "")
+	_, args, err := Load([]string{"-profile", "dev", "integrity", "--fix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(args, " ") != "integrity --fix" {
+		t.Errorf("args %v, want the subcommand and its flags", args)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	prod := func(change func(c *Config)) *Config {
+		c := Defaults(Prod)
+		c.Mongo.URI = "mongodb://db:27017"
+		c.Mongo.Database = "x-airlines"
+		c.Session.Secret = "a session secret"
+		c.Auth.JWTSecret = "a jwt secret"
+		change(c)
+		return c
+	}
+	tests := []struct {
+		name   string
+		config *Config
+		err    string
+	}{
+		{"dev defaults", Defaults(Dev), ""},
+		{"test defaults", Defaults(Test), ""},
+		{"prod defaults have no secrets", Defaults(Prod), "session.secret is required"},
+		{"configured prod", prod(func(c *Config) {}), ""},
+		{"prod with the dev secret", prod(func(c *Config) { c.Auth.JWTSecret = Defaults(Dev).Auth.JWTSecret }), "auth.jwtSecret is the built-in dev secret"},
+		{"prod with the test secret", prod(func(c *Config) { c.Session.Secret = Defaults(Test).Session.Secret }), "session.secret is the built-in test secret"},
+		{"negative entity TTL", prod(func(c *Config) { c.Cache.TTLs.Aircraft = -time.Minute }), "cache.ttls.aircraft cannot be negative"},
+		{"no APU interval", prod(func(c *Config) { c.Maintenance.APUInterval = 0 }), "maintenance.apuInterval must be positive"},
+		{"unknown cache backend", prod(func(c *Config) { c.Cache.Backend = "memcached" }), "cache.backend must be redis, lru or none"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.err == "" {
+				if err != nil {
+					t.Errorf("error %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("error %v, want one about %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	c := Defaults(Dev)
+	text := c.String()
+	if strings.Contains(text, c.Auth.JWTSecret) || strings.Contains(text, c.Session.Secret) {
+		t.Errorf("secrets in\n%s", text)
+	}
+	if c.Auth.JWTSecret != "dev-jwt-secret" {
+		t.Error("redacting changed the settings")
+	}
+}
@@ -2,7 +2,7 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
+	"io"
 	"log"
 	"net/http"
 	"time"
@@ -11,271 +11,234 @@ import (
 	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
 	trackerdata "github.com/arttkachev/X-Airlines/Backend/api/models/trackerData"
 	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
 	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateAircraft(c *gin.Context) {
-	var aircraft aircraft.Aircraft
-	err := c.ShouldBindJSON(&aircraft)
+	repos := getRepositories(c)
+	var airplane aircraft.Aircraft
+	err := c.ShouldBindJSON(&airplane)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	err = validation.Create(&airplane)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraft.ID = primitive.NewObjectID()
-	aircraftService := services.GetAircraftService()
-	if aircraft.Engines == nil {
-		aircraft.Engines = make([]primitive.ObjectID, 0)
+	airplane.ID = primitive.NewObjectID()
+	// only admins create aircraft for someone else
+	if airplane.Owner == primitive.NilObjectID || !isAdmin(getCurrentUser(c)) {
+		airplane.Owner = getCurrentUser(c).ID
+	}
+	if airplane.Engines == nil {
+		airplane.Engines = make([]primitive.ObjectID, 0)
+	}
+	if airplane.General.History == nil {
+		airplane.General.History = make([]primitive.ObjectID, 0)
+	}
+	if airplane.Tags == nil {
+		airplane.Tags = make([]string, 0)
 	}
-	if aircraft.General.History == nil {
-		aircraft.General.History = make([]primitive.ObjectID, 0)
+	if airplane.TrackerData == nil {
+		airplane.TrackerData = &trackerdata.TrackerData{FlightHistory: make([]primitive.ObjectID, 0)}
 	}
-	if aircraft.Tags == nil {
-		aircraft.Tags = make([]string, 0)
+	// checks are only scheduled through the maintenance program
+	if airplane.Airframe != nil {
+		airplane.Airframe.Check = nil
+		// finished checks are pushed onto the log, it has to be an array
+		if airplane.Airframe.MaintenanceLog == nil {
+			airplane.Airframe.MaintenanceLog = make([]aircraft.MaintenanceEntry, 0)
+		}
 	}
-	if aircraft.TrackerData == nil {
-		aircraft.TrackerData = &trackerdata.TrackerData{FlightHistory: make([]primitive.ObjectID, 0)}
+	// so is the APU log with its services
+	if airplane.APU != nil && airplane.APU.MaintenanceLog == nil {
+		airplane.APU.MaintenanceLog = make([]aircraft.MaintenanceEntry, 0)
 	}
-	_, err = aircraftService.Collection.InsertOne(ctx, aircraft)
+	err = repos.Aircraft.Insert(ctx, airplane)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	// clear cache
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	c.JSON(http.StatusOK, aircraft)
+	invalidate("aircraft")
+	c.JSON(http.StatusOK, airplane)
 }
 
 func GetAircraft(c *gin.Context) {
-	aircraftService := services.GetAircraftService()
-	airplanes := make([]aircraft.Aircraft, 0)
-	val, err := aircraftService.RedisClient.Get("aircraft").Result()
-	if err == redis.Nil {
+	repos := getRepositories(c)
+	query, err := parseListQuery(c)
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
+	var page struct {
+		Items []aircraft.Aircraft `json:"items"`
+		repositories.Page
+	}
+	err = responseCache.Get(pageKey("aircraft", c), &page)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cur, err := aircraftService.Collection.Find(ctx, bson.M{})
-		defer cur.Close(ctx)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		page.Items, page.Page, err = repos.Aircraft.List(ctx, query)
+		if err == repositories.ErrInvalidCursor {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
-		for cur.Next(ctx) {
-			var airplane aircraft.Aircraft
-			err = cur.Decode(&airplane)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			airplanes = append(airplanes, airplane)
-		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airplanes)
-		aircraftService.RedisClient.Set("aircraft", string(data), 0)
+		cacheSet(pageKey("aircraft", c), "aircraft", page, cache.Tag("aircraft"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airplanes)
+		log.Printf("Request to the cache")
+	}
+	for i := range page.Items {
+		if page.Items[i].APU != nil {
+			maintenance.FillAPURemaining(page.Items[i].APU)
+		}
 	}
-	c.JSON(http.StatusOK, airplanes)
+	c.JSON(http.StatusOK, page)
 }
 
 func GetAircraftById(c *gin.Context) {
+	repos := getRepositories(c)
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	var aircraftService = services.GetAircraftService()
+	objectId := paramId(c, "id")
 	var airplane aircraft.Aircraft
-	val, err := aircraftService.RedisClient.Get("aircraft/" + id).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("aircraft", id), &airplane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&airplane)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		airplane, err = repos.Aircraft.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such aircraft"))
 			return
-		}
-		if airplane.ID == primitive.NilObjectID {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "No such aircraft"})
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
-		data, _ := json.Marshal(airplane)
-		aircraftService.RedisClient.Set("aircraft/"+id, string(data), 0)
+		cacheSet(idKey("aircraft", id), "aircraft", airplane, cache.Tag("aircraft", id))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airplane)
+		log.Printf("Request to the cache")
+	}
+	if airplane.APU != nil {
+		maintenance.FillAPURemaining(airplane.APU)
 	}
 	c.JSON(http.StatusOK, airplane)
 }
 
 func GetAircraftByType(c *gin.Context) {
+	repos := getRepositories(c)
 	airplaneQuery := c.Query("aircraft")
-	aircraftService := services.GetAircraftService()
 	foundAirplanes := make([]aircraft.Aircraft, 0)
-	val, err := aircraftService.RedisClient.Get("aircraft/" + airplaneQuery).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(cache.Key("aircraft", "name", airplaneQuery), &foundAirplanes)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cur, err := aircraftService.Collection.Find(ctx, bson.M{"general.name": airplaneQuery})
-		defer cur.Close(ctx)
+		foundAirplanes, err = repos.Aircraft.Find(ctx, bson.M{"general.name": airplaneQuery})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		for cur.Next(ctx) {
-			var airplane aircraft.Aircraft
-			err = cur.Decode(&airplane)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			foundAirplanes = append(foundAirplanes, airplane)
-		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(foundAirplanes)
-		aircraftService.RedisClient.Set("aircraft/"+airplaneQuery, string(data), 0)
+		cacheSet(cache.Key("aircraft", "name", airplaneQuery), "aircraft", foundAirplanes, cache.Tag("aircraft"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &foundAirplanes)
+		log.Printf("Request to the cache")
 	}
 	if len(foundAirplanes) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "Aircraft not found"})
+		c.Error(apierrors.NotFound("Aircraft not found"))
 		return
 	}
 	c.JSON(http.StatusOK, foundAirplanes)
 }
 
 func DeleteAircraft(c *gin.Context) {
-	aircraftService := services.GetAircraftService()
-	airlineService := services.GetAirlineService()
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
+	objectId := paramId(c, "id")
 	var airplane aircraft.Aircraft
-	aircraftVal, err := aircraftService.RedisClient.Get("aircraft/" + id).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("aircraft", id), &airplane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&airplane)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		airplane, err = repos.Aircraft.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such aircraft"))
+			return
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(aircraftVal), &airplane)
-	}
-	if len(airplane.General.History) > 0 {
-		currentAirlineId := airplane.General.History[len(airplane.General.History)-1].Hex()
-		currentAirlineObjectId, _ := primitive.ObjectIDFromHex(currentAirlineId)
-		var selfAircraft []primitive.ObjectID
-		selfAircraft = append(selfAircraft, objectId)
-		filter := bson.D{{"_id", currentAirlineObjectId}}
-		update := bson.D{{"$set", bson.D{
-			{"fleet", bson.D{
-				{"$cond", bson.D{
-					{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{selfAircraft, bson.A{}}}}}}}, "$fleet"}}}},
-					{"then", bson.D{{"$setDifference", bson.A{"$fleet", selfAircraft}}}},
-					{"else", bson.D{{"$concatArrays", bson.A{"$fleet", bson.D{{"$ifNull", bson.A{selfAircraft, bson.A{}}}}}}}}}}}}}}}
-
-		_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		log.Printf("Request to the cache")
+	}
+	// aircraft without an airline are only deleted
+	if airplane.General != nil && len(airplane.General.History) > 0 {
+		currentAirlineObjectId := airplane.General.History[len(airplane.General.History)-1]
+		currentAirlineId := currentAirlineObjectId.Hex()
+		err = repos.Airlines.Pull(ctx, currentAirlineObjectId, "fleet", objectId)
+		if err != nil && err != repositories.ErrNotFound {
+			c.Error(err)
 			return
 		}
-		log.Println("Remove airline data from Redis")
-		airlineService.RedisClient.Del("airlines")
-		airlineService.RedisClient.Del("airlines/" + currentAirlineId)
-		deleteResult, _ := aircraftService.Collection.DeleteOne(ctx, bson.M{"_id": objectId})
-		if deleteResult.DeletedCount == 0 {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Error on deleting an aircraft"})
-			return
-		}
-		log.Println("Remove aircraft data from Redis")
-		aircraftService.RedisClient.Del("aircraft")
-		aircraftService.RedisClient.Del("aircraft/" + id)
-		c.JSON(http.StatusOK, gin.H{
-			"message": "An aircraft has been deleted"})
+		invalidate("airlines", currentAirlineId)
 	}
+	err = repos.Aircraft.Delete(ctx, objectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "An aircraft has been deleted"})
 }
 
 func UpdateAirframe(c *gin.Context) {
 	var airframe aircraft.Airframe
 	err := c.ShouldBindJSON(&airframe)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"airframe.totalTime", bson.D{
-			{"$cond", bson.D{
-				{"if", airframe.TotalTime != nil},
-				{"then", airframe.TotalTime},
-				{"else", "$airframe.totalTime"}}}}},
-
-		{"airframe.totalLandings", bson.D{
-			{"$cond", bson.D{
-				{"if", airframe.TotalLandings != nil},
-				{"then", airframe.TotalLandings},
-				{"else", "$airframe.totalLandings"}}}}},
-
-		{"airframe.airframeNotes", bson.D{
-			{"$cond", bson.D{
-				{"if", airframe.AirframeNotes != ""},
-				{"then", airframe.AirframeNotes},
-				{"else", "$airframe.airframeNotes"}}}}}}}}
-
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"airframe.totalTime":     airframe.TotalTime,
+		"airframe.totalLandings": airframe.TotalLandings,
+		"airframe.airframeNotes": airframe.AirframeNotes}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft airframe has been updated"})
 }
@@ -284,38 +247,21 @@ func UpdateExterior(c *gin.Context) {
 	var exterior aircraft.Exterior
 	err := c.ShouldBindJSON(&exterior)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"exterior.yearPainted", bson.D{
-			{"$cond", bson.D{
-				{"if", exterior.YearPainted != nil},
-				{"then", exterior.YearPainted},
-				{"else", "$exterior.yearPainted"}}}}},
-
-		{"exterior.notes", bson.D{
-			{"$cond", bson.D{
-				{"if", exterior.Notes != ""},
-				{"then", exterior.Notes},
-				{"else", "$exterior.notes"}}}}}}}}
-
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"exterior.yearPainted": exterior.YearPainted,
+		"exterior.notes":       exterior.Notes}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft exterior has been updated"})
 }
@@ -324,38 +270,21 @@ func UpdateInterior(c *gin.Context) {
 	var interior aircraft.Interior
 	err := c.ShouldBindJSON(&interior)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"interior.yearInterior", bson.D{
-			{"$cond", bson.D{
-				{"if", interior.YearInterior != nil},
-				{"then", interior.YearInterior},
-				{"else", "$interior.yearInterior"}}}}},
-
-		{"interior.numberOfSeats", bson.D{
-			{"$cond", bson.D{
-				{"if", interior.NumberOfSeats != nil},
-				{"then", interior.NumberOfSeats},
-				{"else", "$interior.numberOfSeats"}}}}}}}}
-
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"interior.yearInterior":  interior.YearInterior,
+		"interior.numberOfSeats": interior.NumberOfSeats}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft interior has been updated"})
 }
@@ -364,132 +293,164 @@ func UpdateCockpit(c *gin.Context) {
 	var cockpit aircraft.Cockpit
 	err := c.ShouldBindJSON(&cockpit)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"cockpit.glassCockpit", bson.D{
-			{"$cond", bson.D{
-				{"if", cockpit.GlassCockpit != nil},
-				{"then", cockpit.GlassCockpit},
-				{"else", "$cockpit.glassCockpit"}}}}}}}}
-
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"cockpit.glassCockpit": cockpit.GlassCockpit}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft cockpit has been updated"})
 }
 
-func UpdateGeneral(c *gin.Context) {
-	var general aircraft.General
-	err := c.ShouldBindJSON(&general)
+func UpdateAPU(c *gin.Context) {
+	var apu aircraft.APU
+	err := c.ShouldBindJSON(&apu)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"general.name", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Name != ""},
-				{"then", general.Name},
-				{"else", "$general.name"}}}}},
-
-		{"general.icon", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Icon != ""},
-				{"then", general.Icon},
-				{"else", "$general.icon"}}}}},
-
-		{"general.year", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Year != nil},
-				{"then", general.Year},
-				{"else", "$general.year"}}}}},
-
-		{"general.manufacturer", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Manufacturer != ""},
-				{"then", general.Manufacturer},
-				{"else", "$general.manufacturer"}}}}},
-
-		{"general.model", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Model != ""},
-				{"then", general.Model},
-				{"else", "$general.model"}}}}},
-
-		{"general.registration", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Registration != ""},
-				{"then", general.Registration},
-				{"else", "$general.registration"}}}}},
-
-		{"general.condition", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Condition != ""},
-				{"then", general.Condition},
-				{"else", "$general.condition"}}}}},
-
-		{"general.description", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Description != ""},
-				{"then", general.Description},
-				{"else", "$general.description"}}}}},
-
-		{"general.location", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Location != ""},
-				{"then", general.Location},
-				{"else", "$general.location"}}}}},
-
-		{"general.isOperating", bson.D{
-			{"$cond", bson.D{
-				{"if", general.IsOperating != nil},
-				{"then", general.IsOperating},
-				{"else", "$general.isOperating"}}}}},
+	objectId := paramId(c, "id")
+	repos := getRepositories(c)
+	airplane, err := repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	fields := givenFields(bson.M{
+		"apu.totalTime":        apu.TotalTime,
+		"apu.sinceMaintenance": apu.SinceMaintenance,
+		"apu.notes":            apu.Notes})
+	// services are pushed onto the log, it has to be an array
+	if airplane.APU == nil || airplane.APU.MaintenanceLog == nil {
+		fields["apu.maintenanceLog"] = make([]aircraft.MaintenanceEntry, 0)
+	}
+	err = repos.Aircraft.Update(ctx, objectId, fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The aircraft APU has been updated"})
+}
 
-		{"general.history", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{general.History, bson.A{}}}}}}}, "$general.history"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$general.history", general.History}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$general.history", bson.D{{"$ifNull", bson.A{general.History, bson.A{}}}}}}}}}}}},
+type serviceAPURequest struct {
+	Notes string `json:"notes"`
+}
 
-		{"general.price", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Price != nil},
-				{"then", general.Price},
-				{"else", "$general.price"}}}}}}}}
+// records an APU service and resets its time since maintenance
+func ServiceAPU(c *gin.Context) {
+	repos := getRepositories(c)
+	var event serviceAPURequest
+	err := c.ShouldBindJSON(&event)
+	if err != nil && err != io.EOF {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	var airplane aircraft.Aircraft
+	airplane, err = repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if airplane.APU == nil {
+		c.Error(apierrors.Validation("The aircraft has no APU"))
+		return
+	}
+	entry := aircraft.MaintenanceEntry{
+		Type:  aircraft.APUService,
+		Date:  time.Now().UTC(),
+		Notes: event.Notes}
+	if airplane.APU.TotalTime != nil {
+		entry.TotalTime = *airplane.APU.TotalTime
+	}
+	change := repositories.Change{
+		Set:  bson.M{"apu.sinceMaintenance": 0},
+		Push: bson.M{"apu.maintenanceLog": entry}}
+	// aircraft stored without a log cannot be pushed onto
+	if airplane.APU.MaintenanceLog == nil {
+		change.Set["apu.maintenanceLog"] = []aircraft.MaintenanceEntry{entry}
+		change.Push = nil
+	}
+	err = repos.Aircraft.Apply(ctx, objectId, nil, change)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The aircraft APU service has been recorded"})
+}
 
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+func UpdateGeneral(c *gin.Context) {
+	var general aircraft.General
+	err := c.ShouldBindJSON(&general)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	repos := getRepositories(c)
+	airplane, err := repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	fields := givenFields(bson.M{
+		"general.name":         general.Name,
+		"general.icon":         general.Icon,
+		"general.year":         general.Year,
+		"general.manufacturer": general.Manufacturer,
+		"general.model":        general.Model,
+		"general.registration": general.Registration,
+		"general.condition":    general.Condition,
+		"general.description":  general.Description,
+		"general.location":     general.Location,
+		"general.isOperating":  general.IsOperating,
+		"general.price":        general.Price})
+	// an aircraft in a check stays out of service until the check is over
+	if airplane.Airframe != nil && airplane.Airframe.Check != nil && airplane.Airframe.Check.Started {
+		delete(fields, "general.isOperating")
+	}
+	err = repos.Aircraft.Update(ctx, objectId, fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = repos.Aircraft.Toggle(ctx, objectId, "general.history", general.History)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft general information has been updated"})
 }
@@ -498,208 +459,137 @@ func UpdatePerformance(c *gin.Context) {
 	var performance aircraft.Performance
 	err := c.ShouldBindJSON(&performance)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"performance.range", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.Range != nil},
-				{"then", performance.Range},
-				{"else", "$performance.range"}}}}},
-
-		{"performance.cruiseSpeed", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.CruiseSpeed != nil},
-				{"then", performance.CruiseSpeed},
-				{"else", "$performance.cruiseSpeed"}}}}},
-
-		{"performance.maxSpeed", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.MaxSpeed != nil},
-				{"then", performance.MaxSpeed},
-				{"else", "$performance.maxSpeed"}}}}},
-
-		{"performance.ceiling", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.Ceiling != nil},
-				{"then", performance.Ceiling},
-				{"else", "$performance.ceiling"}}}}},
-
-		{"performance.maxTakeoffWeight", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.MaxTakeoffWeight != nil},
-				{"then", performance.MaxTakeoffWeight},
-				{"else", "$performance.maxTakeoffWeight"}}}}},
-
-		{"performance.maxLandingWeight", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.MaxLandingWeight != nil},
-				{"then", performance.MaxLandingWeight},
-				{"else", "$performance.maxLandingWeight"}}}}},
-
-		{"performance.maxZeroFuelWeight", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.MaxZeroFuelWeight != nil},
-				{"then", performance.MaxZeroFuelWeight},
-				{"else", "$performance.maxZeroFuelWeight"}}}}},
-
-		{"performance.fuelCapacity", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.FuelCapacity != nil},
-				{"then", performance.FuelCapacity},
-				{"else", "$performance.fuelCapacity"}}}}},
-
-		{"performance.takeoffDistance", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.TakeoffDistance != nil},
-				{"then", performance.TakeoffDistance},
-				{"else", "$performance.takeoffDistance"}}}}},
-
-		{"performance.wingspan", bson.D{
-			{"$cond", bson.D{
-				{"if", performance.Wingspan != nil},
-				{"then", performance.Wingspan},
-				{"else", "$performance.wingspan"}}}}}}}}
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"performance.range":             performance.Range,
+		"performance.cruiseSpeed":       performance.CruiseSpeed,
+		"performance.maxSpeed":          performance.MaxSpeed,
+		"performance.ceiling":           performance.Ceiling,
+		"performance.maxTakeoffWeight":  performance.MaxTakeoffWeight,
+		"performance.maxLandingWeight":  performance.MaxLandingWeight,
+		"performance.maxZeroFuelWeight": performance.MaxZeroFuelWeight,
+		"performance.fuelCapacity":      performance.FuelCapacity,
+		"performance.takeoffDistance":   performance.TakeoffDistance,
+		"performance.wingspan":          performance.Wingspan}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft performance has been updated"})
 }
 
 func UpdateEngines(c *gin.Context) {
+	repos := getRepositories(c)
 	var airplane aircraft.Aircraft
-	aircraftService := services.GetAircraftService()
-	engineService := services.GetEngineService()
 	err := c.ShouldBindJSON(&airplane)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	aircraftObjectId, _ := primitive.ObjectIDFromHex(id)
+	aircraftObjectId := paramId(c, "id")
+	current, err := repos.Aircraft.FindById(ctx, aircraftObjectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	work := services.NewUnitOfWork("Update engines of aircraft " + id)
+	var engineIds []string
+	aircraftIds := []string{id}
 
 	// update current owning aircraft info
 	for _, x := range airplane.Engines {
 		var engine aircraft.Engine
 		engineId := x.Hex()
-		engineObjectId, _ := primitive.ObjectIDFromHex(engineId)
-		engineVal, err := engineService.RedisClient.Get("engines/" + engineId).Result()
-		if err == redis.Nil {
+		engineObjectId := x
+		err := responseCache.Get(idKey("engines", engineId), &engine)
+		if err == cache.ErrMiss {
 			log.Printf("Request to MongoDB")
-			err = engineService.Collection.FindOne(ctx, bson.M{"_id": engineObjectId}).Decode(&engine)
+			engine, err = repos.Engines.FindById(ctx, engineObjectId)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
+				c.Error(err)
 				return
 			}
-			// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-			data, _ := json.Marshal(engine)
-			engineService.RedisClient.Set("engines/"+engineId, string(data), 0)
+			cacheSet(idKey("engines", engineId), "engines", engine, cache.Tag("engines", engineId))
 
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(engineVal), &engine)
+			log.Printf("Request to the cache")
 		}
 		if engine.OwningAircraft != primitive.NilObjectID {
-			formerOwningAircraftObjectId, _ := primitive.ObjectIDFromHex(engine.OwningAircraft.Hex())
+			formerOwningAircraftObjectId := engine.OwningAircraft
 			var formerOwningAircraft aircraft.Aircraft
-			formerOwningAircraftVal, err := aircraftService.RedisClient.Get("aircraft/" + engine.OwningAircraft.Hex()).Result()
-			if err == redis.Nil {
+			err := responseCache.Get(idKey("aircraft", engine.OwningAircraft.Hex()), &formerOwningAircraft)
+			if err == cache.ErrMiss {
 				log.Printf("Request to MongoDB")
-				err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": formerOwningAircraftObjectId}).Decode(&formerOwningAircraft)
+				formerOwningAircraft, err = repos.Aircraft.FindById(ctx, formerOwningAircraftObjectId)
 				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"error": err.Error()})
+					c.Error(err)
 					return
 				}
-				data, _ := json.Marshal(formerOwningAircraft)
-				aircraftService.RedisClient.Set("aircraft/"+engine.OwningAircraft.Hex(), string(data), 0)
+				cacheSet(idKey("aircraft", engine.OwningAircraft.Hex()), "aircraft", formerOwningAircraft, cache.Tag("aircraft", engine.OwningAircraft.Hex()))
 			} else if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
+				c.Error(err)
 				return
 			} else {
-				log.Printf("Request to Redis")
-				json.Unmarshal([]byte(formerOwningAircraftVal), &formerOwningAircraft)
+				log.Printf("Request to the cache")
 			}
 
 			if aircraftObjectId != formerOwningAircraftObjectId {
-				var formerAircraft aircraft.Aircraft
-				formerAircraft.Engines = append(formerAircraft.Engines, x)
-				filter := bson.D{{"_id", formerOwningAircraftObjectId}}
-				update := bson.D{{"$set", bson.D{
-					{"engines", bson.D{
-						{"$cond", bson.D{
-							{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{formerAircraft.Engines, bson.A{}}}}}}}, formerOwningAircraft.Engines}}}},
-							{"then", bson.D{{"$setDifference", bson.A{formerOwningAircraft.Engines, formerAircraft.Engines}}}},
-							{"else", bson.D{{"$concatArrays", bson.A{formerOwningAircraft.Engines, bson.D{{"$ifNull", bson.A{formerAircraft.Engines, bson.A{}}}}}}}}}}}}}}}
-				_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+				// the engine only comes off an aircraft of someone else with their rights
+				formerOwner, err := aircraftOwner(repos, formerOwningAircraftObjectId)
 				if err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"error": err.Error()})
+					c.Error(err)
 					return
 				}
-				log.Println("Remove aircraft id data from Redis")
-				aircraftService.RedisClient.Del("aircraft/" + engine.OwningAircraft.Hex())
+				if !canModify(c, formerOwner) {
+					c.Error(apierrors.Forbidden("The engine " + engineId + " is on an aircraft that does not belong to you"))
+					return
+				}
+				work.Add("move engine "+engineId+" off aircraft "+engine.OwningAircraft.Hex(),
+					changeArray(repos.Aircraft.Pull, formerOwningAircraftObjectId, "engines", x),
+					restoreField(repos.Aircraft.Update, formerOwningAircraftObjectId, "engines", formerOwningAircraft.Engines))
+				aircraftIds = append(aircraftIds, engine.OwningAircraft.Hex())
 			}
 		}
-		filter := bson.D{{"_id", engineObjectId}}
-		update := bson.D{{"$set", bson.D{
-			{"owningAircraft", bson.D{
-				{"$cond", bson.D{
-					{"if", aircraftObjectId != engine.OwningAircraft},
-					{"then", aircraftObjectId},
-					{"else", primitive.NilObjectID}}}}}}}}
-		_, err = engineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
+		// an engine already on the aircraft comes off it like the aircraft engines toggle
+		owningAircraft := aircraftObjectId
+		if engine.OwningAircraft == aircraftObjectId {
+			owningAircraft = primitive.NilObjectID
 		}
-		log.Println("Remove engine id data from Redis")
-		engineService.RedisClient.Del("engines/" + engineId)
-	}
-	filter := bson.D{{"_id", aircraftObjectId}}
-	update := bson.D{{"$set", bson.D{
-		{"engines", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{airplane.Engines, bson.A{}}}}}}}, "$engines"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$engines", airplane.Engines}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$engines", bson.D{{"$ifNull", bson.A{airplane.Engines, bson.A{}}}}}}}}}}}}}}}
+		work.Add("set the owning aircraft of engine "+engineId,
+			func(ctx context.Context) error {
+				return repos.Engines.Update(ctx, engineObjectId, bson.M{"owningAircraft": owningAircraft})
+			},
+			restoreField(repos.Engines.Update, engineObjectId, "owningAircraft", engine.OwningAircraft))
+		engineIds = append(engineIds, engineId)
+	}
 	// update engines
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	work.Add("update the engines",
+		changeArray(repos.Aircraft.Toggle, aircraftObjectId, "engines", airplane.Engines),
+		restoreField(repos.Aircraft.Update, aircraftObjectId, "engines", current.Engines))
+	err = work.Run(ctx)
+	invalidate("engines", engineIds...)
+	invalidate("aircraft", aircraftIds...)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove engines data from Redis")
-	engineService.RedisClient.Del("engines")
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft engines have been updated"})
 }
@@ -708,32 +598,19 @@ func UpdateTags(c *gin.Context) {
 	var aircraft aircraft.Aircraft
 	err := c.ShouldBindJSON(&aircraft)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"tags", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{aircraft.Tags, bson.A{}}}}}}}, "$tags"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$tags", aircraft.Tags}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$tags", bson.D{{"$ifNull", bson.A{aircraft.Tags, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Toggle(ctx, objectId, "tags", aircraft.Tags)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The aircraft tags have been updated"})
 }
@@ -742,258 +619,191 @@ func UpdateOwner(c *gin.Context) {
 	var aircraft aircraft.Aircraft
 	err := c.ShouldBindJSON(&aircraft)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	aircraftService := services.GetAircraftService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"owner", bson.D{
-			{"$cond", bson.D{
-				{"if", aircraft.Owner != primitive.NilObjectID},
-				{"then", aircraft.Owner},
-				{"else", "$owner"}}}}}}}}
-	_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Aircraft.Update(ctx, objectId, givenFields(bson.M{
+		"owner": aircraft.Owner}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
-	aircraftService.RedisClient.Del("aircraft/" + id)
+	invalidate("aircraft", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The owner has been updated"})
 }
 
 func GetOwnerData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	aircraftId := c.Param("id")
 	var airplane aircraft.Aircraft
-	aircraftService := services.GetAircraftService()
-	val, err := aircraftService.RedisClient.Get("aircraft/" + aircraftId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("aircraft", aircraftId), &airplane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		aircraftObjectId, err := primitive.ObjectIDFromHex(aircraftId)
+		aircraftObjectId := paramId(c, "id")
+		airplane, err = repos.Aircraft.FindById(ctx, aircraftObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(&airplane)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airplane)
-		aircraftService.RedisClient.Set("aircraft/"+aircraftId, string(data), 0)
+		cacheSet(idKey("aircraft", aircraftId), "aircraft", airplane, cache.Tag("aircraft", aircraftId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airplane)
+		log.Printf("Request to the cache")
 	}
 
 	if airplane.Owner == primitive.NilObjectID {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "Aircraft does not have an owner"})
+		c.Error(apierrors.NotFound("Aircraft does not have an owner"))
 		return
 	}
 	userId := airplane.Owner.Hex()
-	userObjectId, err := primitive.ObjectIDFromHex(userId)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
-		return
-	}
+	userObjectId := airplane.Owner
 	var owner user.User
-	userService := services.GetUserService()
-	userVal, err := userService.RedisClient.Get("users/" + userId).Result()
-	if err == redis.Nil {
+	err = responseCache.Get(idKey("users", userId), &owner)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		err = userService.Collection.FindOne(ctx, bson.M{"_id": userObjectId}).Decode(&owner)
+		owner, err = repos.Users.FindById(ctx, userObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		//Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(owner)
-		userService.RedisClient.Set("users/"+userId, string(data), 0)
+		cacheSet(idKey("users", userId), "users", owner, cache.Tag("users", userId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(userVal), &owner)
+		log.Printf("Request to the cache")
 
 	}
 	if owner.ID == primitive.NilObjectID {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "No owner found"})
+		c.Error(apierrors.NotFound("No owner found"))
 		return
 	}
 	c.JSON(http.StatusOK, owner)
 }
 
 func GetEngineData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	aircraftId := c.Param("id")
+	// the whole view is cached too and goes away with the aircraft or any of its engines
+	var engines []aircraft.Engine
+	viewKey := cache.Key("aircraft", "engines", aircraftId)
+	if responseCache.Get(viewKey, &engines) == nil {
+		log.Printf("Request to the cache")
+		for i := range engines {
+			maintenance.FillRemaining(&engines[i])
+		}
+		c.JSON(http.StatusOK, engines)
+		return
+	}
 	var airplane aircraft.Aircraft
-	aircraftService := services.GetAircraftService()
-	val, err := aircraftService.RedisClient.Get("aircraft/" + aircraftId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("aircraft", aircraftId), &airplane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		aircraftObjectId, err := primitive.ObjectIDFromHex(aircraftId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(&airplane)
+		aircraftObjectId := paramId(c, "id")
+		airplane, err = repos.Aircraft.FindById(ctx, aircraftObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airplane)
-		aircraftService.RedisClient.Set("aircraft/"+aircraftId, string(data), 0)
+		cacheSet(idKey("aircraft", aircraftId), "aircraft", airplane, cache.Tag("aircraft", aircraftId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airplane)
+		log.Printf("Request to the cache")
 	}
-	engineService := services.GetEngineService()
-	var engines []aircraft.Engine
+	tags := []string{cache.Tag("aircraft", aircraftId)}
 	for _, x := range airplane.Engines {
 		engineId := x.Hex()
-		engineObjectId, err := primitive.ObjectIDFromHex(engineId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
+		tags = append(tags, cache.Tag("engines", engineId))
+		engineObjectId := x
 		var engine aircraft.Engine
-		engineVal, err := engineService.RedisClient.Get("engines/" + engineId).Result()
-		if err == redis.Nil {
+		err = responseCache.Get(idKey("engines", engineId), &engine)
+		if err == cache.ErrMiss {
 			log.Printf("Request to MongoDB")
-			err = engineService.Collection.FindOne(ctx, bson.M{"_id": engineObjectId}).Decode(&engine)
+			engine, err = repos.Engines.FindById(ctx, engineObjectId)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
+				c.Error(err)
 				return
 			}
-			// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-			data, _ := json.Marshal(engine)
-			aircraftService.RedisClient.Set("engines/"+engineId, string(data), 0)
+			cacheSet(idKey("engines", engineId), "engines", engine, cache.Tag("engines", engineId))
 			engines = append(engines, engine)
 
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(engineVal), &engine)
+			log.Printf("Request to the cache")
 			engines = append(engines, engine)
 
 		}
 	}
 	if len(engines) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "The aircraft does not have engines"})
+		c.Error(apierrors.NotFound("The aircraft does not have engines"))
 		return
 	}
+	cacheSet(viewKey, "aircraft", engines, tags...)
+	for i := range engines {
+		maintenance.FillRemaining(&engines[i])
+	}
 	c.JSON(http.StatusOK, engines)
 }
 
 func GetAirlineData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	aircraftId := c.Param("id")
 	var airplane aircraft.Aircraft
-	aircraftService := services.GetAircraftService()
-	val, err := aircraftService.RedisClient.Get("aircraft/" + aircraftId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("aircraft", aircraftId), &airplane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		aircraftObjectId, err := primitive.ObjectIDFromHex(aircraftId)
+		aircraftObjectId := paramId(c, "id")
+		airplane, err = repos.Aircraft.FindById(ctx, aircraftObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(&airplane)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airplane)
-		aircraftService.RedisClient.Set("aircraft/"+aircraftId, string(data), 0)
+		cacheSet(idKey("aircraft", aircraftId), "aircraft", airplane, cache.Tag("aircraft", aircraftId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airplane)
+		log.Printf("Request to the cache")
 	}
 	if len(airplane.General.History) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "The aircraft does not have an operator"})
+		c.Error(apierrors.NotFound("The aircraft does not have an operator"))
 		return
 	}
 
 	var airlane airline.Airline
-	airlineService := services.GetAirlineService()
 	airlineId := airplane.General.History[len(airplane.General.History)-1].Hex()
-	airlineObjectId, err := primitive.ObjectIDFromHex(airlineId)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
-		return
-	}
-	airlineVal, err := airlineService.RedisClient.Get("airlines/" + airlineId).Result()
-	if err == redis.Nil {
+	airlineObjectId := airplane.General.History[len(airplane.General.History)-1]
+	err = responseCache.Get(idKey("airlines", airlineId), &airlane)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
+		airlane, err = repos.Airlines.FindById(ctx, airlineObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		err = airlineService.Collection.FindOne(ctx, bson.M{"_id": airlineObjectId}).Decode(&airlane)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airlane)
-		aircraftService.RedisClient.Set("airlines/"+airlineId, string(data), 0)
+		cacheSet(idKey("airlines", airlineId), "airlines", airlane, cache.Tag("airlines", airlineId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(airlineVal), &airlane)
+		log.Printf("Request to the cache")
 	}
 	c.JSON(http.StatusOK, airlane)
 }
@@ -2,34 +2,44 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
 	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
 	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
 	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateAirline(c *gin.Context) {
+	repos := getRepositories(c)
 	var airlineData airline.Airline
 	err := c.ShouldBindJSON(&airlineData)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
+		return
+	}
+	err = validation.Create(&airlineData)
+	if err != nil {
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	airlineData.ID = primitive.NewObjectID()
-	airlineService := services.GetAirlineService()
+	// only admins create airlines for someone else
+	if airlineData.Owner == primitive.NilObjectID || !isAdmin(getCurrentUser(c)) {
+		airlineData.Owner = getCurrentUser(c).ID
+	}
 	if airlineData.Fleet == nil {
 		airlineData.Fleet = make([]primitive.ObjectID, 0)
 	}
@@ -39,227 +49,143 @@ func CreateAirline(c *gin.Context) {
 	if airlineData.Routes == nil {
 		airlineData.Routes = make([]primitive.ObjectID, 0)
 	}
-	_, err = airlineService.Collection.InsertOne(ctx, airlineData)
+	// the balance only changes through ledger postings
+	balance := 0
+	airlineData.Balance = &balance
+	err = repos.Airlines.Insert(ctx, airlineData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	// clear cache
-	log.Println("Remove airlines data from Redis")
-	airlineService.RedisClient.Del("airlines")
+	invalidate("airlines")
 	c.JSON(http.StatusOK, airlineData)
 }
 
 func DeleteAirline(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	var airline airline.Airline
-	airlineService := services.GetAirlineService()
-	airlineVal, err := airlineService.RedisClient.Get("airlines/" + id).Result()
-	if err == redis.Nil {
-		log.Printf("Request to MongoDB")
-		err = airlineService.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&airline)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
+	objectId := paramId(c, "id")
+	// undo puts back what is in the database, the cache may be stale
+	airline, err := repos.Airlines.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such airline"))
+		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
-	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(airlineVal), &airline)
-	}
-	userService := services.GetUserService()
-	ownerId := airline.Owner.Hex()
-	ownerObjectId, _ := primitive.ObjectIDFromHex(ownerId)
-	var selfAirline []primitive.ObjectID
-	selfAirline = append(selfAirline, objectId)
-	filter := bson.D{{"_id", ownerObjectId}}
-	update := bson.D{{"$set", bson.D{
-		{"airlines", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{selfAirline, bson.A{}}}}}}}, "$airlines"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$airlines", selfAirline}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$airlines", bson.D{{"$ifNull", bson.A{selfAirline, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = userService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+	}
+	work := services.NewUnitOfWork("Delete airline " + id)
+	owner, err := repos.Users.FindById(ctx, airline.Owner)
+	if err == nil {
+		work.Add("remove the airline from its owner "+owner.ID.Hex(),
+			changeArray(repos.Users.Pull, owner.ID, "airlines", objectId),
+			restoreField(repos.Users.Update, owner.ID, "airlines", owner.Airlines))
+	} else if err != repositories.ErrNotFound {
+		c.Error(err)
 		return
 	}
-	log.Println("Remove user data from Redis")
-	userService.RedisClient.Del("users")
-	userService.RedisClient.Del("users/" + ownerId)
-	aircraftService := services.GetAircraftService()
+	var aircraftIds []string
 	for _, x := range airline.Fleet {
-		var airplane aircraft.Aircraft
 		aircraftId := x.Hex()
-		aircraftObjectId, err := primitive.ObjectIDFromHex(aircraftId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		aircraftVal, err := aircraftService.RedisClient.Get("aircraft/" + aircraftId).Result()
-		if err == redis.Nil {
-			log.Printf("Request to MongoDB")
-			err = airlineService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(airplane)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
+		aircraftObjectId := x
+		airplane, err := repos.Aircraft.FindById(ctx, aircraftObjectId)
+		if err == repositories.ErrNotFound {
+			continue
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
-		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(aircraftVal), &airplane)
 		}
-		if len(airplane.General.History) > 0 {
-			var selfAirline []primitive.ObjectID
-			selfAirline = append(selfAirline, objectId)
-			filter := bson.D{{"_id", aircraftObjectId}}
-			update := bson.D{{"$set", bson.D{
-				{"general.history", bson.D{
-					{"$cond", bson.D{
-						{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{selfAirline, bson.A{}}}}}}}, "$general.history"}}}},
-						{"then", bson.D{{"$setDifference", bson.A{"$general.history", selfAirline}}}},
-						{"else", bson.D{{"$concatArrays", bson.A{"$general.history", bson.D{{"$ifNull", bson.A{selfAirline, bson.A{}}}}}}}}}}}}}}}
-
-			_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			log.Println("Remove aircraft id data from Redis")
-			aircraftService.RedisClient.Del("aircraft/" + aircraftId)
+		if airplane.General != nil && len(airplane.General.History) > 0 {
+			work.Add("remove the airline from the history of aircraft "+aircraftId,
+				changeArray(repos.Aircraft.Pull, aircraftObjectId, "general.history", objectId),
+				restoreField(repos.Aircraft.Update, aircraftObjectId, "general.history", airplane.General.History))
+			aircraftIds = append(aircraftIds, aircraftId)
 		}
 	}
-	deleteResult, _ := airlineService.Collection.DeleteOne(ctx, bson.M{"_id": objectId})
-	if deleteResult.DeletedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Error on deleting an airline"})
+	restored := airline
+	if addClosingTransfer(work, repos, ledger.Account{Type: ledger.Airline, ID: objectId}, airline.Balance) {
+		// undoing the closing transfer puts the balance back
+		restored.Balance = new(int)
+	}
+	work.Add("delete the airline",
+		func(ctx context.Context) error {
+			return repos.Airlines.Delete(ctx, objectId)
+		},
+		func(ctx context.Context) error {
+			return repos.Airlines.Insert(ctx, restored)
+		})
+	err = work.Run(ctx)
+	invalidate("users", airline.Owner.Hex())
+	invalidate("aircraft", aircraftIds...)
+	invalidate("airlines", id)
+	if err != nil {
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "An airline has been deleted"})
 }
 
 func GetAirlines(c *gin.Context) {
-	airlineService := services.GetAirlineService()
-	airlines := make([]airline.Airline, 0)
-	val, err := airlineService.RedisClient.Get("airlines").Result()
-	if err == redis.Nil {
+	repos := getRepositories(c)
+	query, err := parseListQuery(c)
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
+	var page struct {
+		Items []airline.Airline `json:"items"`
+		repositories.Page
+	}
+	err = responseCache.Get(pageKey("airlines", c), &page)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cur, err := airlineService.Collection.Find(ctx, bson.M{})
-		defer cur.Close(ctx)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		page.Items, page.Page, err = repos.Airlines.List(ctx, query)
+		if err == repositories.ErrInvalidCursor {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
-
-		for cur.Next(ctx) {
-			var airline airline.Airline
-			err = cur.Decode(&airline)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			airlines = append(airlines, airline)
-		}
-		// Redis value has to be a string, so, we need to Marshal data first and put it on a Redis server
-		data, _ := json.Marshal(airlines)
-		airlineService.RedisClient.Set("airlines", string(data), 0)
+		cacheSet(pageKey("airlines", c), "airlines", page, cache.Tag("airlines"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airlines)
+		log.Printf("Request to the cache")
 	}
-	c.JSON(http.StatusOK, airlines)
+	c.JSON(http.StatusOK, page)
 }
 
 func UpdateAirlineGeneral(c *gin.Context) {
 	var general airline.General
 	err := c.ShouldBindJSON(&general)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(c.Param("id"))
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"general.name", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Name != ""},
-				{"then", general.Name},
-				{"else", "$general.name"}}}}},
-
-		{"general.logo", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Logo != ""},
-				{"then", general.Logo},
-				{"else", "$general.logo"}}}}},
-
-		{"general.iata", bson.D{
-			{"$cond", bson.D{
-				{"if", general.IATA != ""},
-				{"then", general.IATA},
-				{"else", "$general.iata"}}}}},
-
-		{"general.icao", bson.D{
-			{"$cond", bson.D{
-				{"if", general.ICAO != ""},
-				{"then", general.ICAO},
-				{"else", "$general.icao"}}}}},
-
-		{"general.fleet", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Fleet != nil},
-				{"then", general.Fleet},
-				{"else", "$general.fleet"}}}}},
-
-		{"general.rating", bson.D{
-			{"$cond", bson.D{
-				{"if", general.Rating != nil},
-				{"then", general.Rating},
-				{"else", "$general.rating"}}}}}}}}
-
-	_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	// the rating is only written by updateAirlineRating from the approved reviews
+	err = getRepositories(c).Airlines.Update(ctx, objectId, givenFields(bson.M{
+		"general.name":  general.Name,
+		"general.logo":  general.Logo,
+		"general.iata":  general.IATA,
+		"general.icao":  general.ICAO,
+		"general.fleet": general.Fleet}))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
+	invalidate("airlines", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The airline general information has been updated"})
 }
@@ -268,32 +194,19 @@ func UpdateReviews(c *gin.Context) {
 	var airline airline.Airline
 	err := c.ShouldBindJSON(&airline)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(c.Param("id"))
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"reviews", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{airline.Reviews, bson.A{}}}}}}}, "$reviews"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$reviews", airline.Reviews}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$reviews", bson.D{{"$ifNull", bson.A{airline.Reviews, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Airlines.Toggle(ctx, objectId, "reviews", airline.Reviews)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
+	invalidate("airlines", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The airline reviews have been updated"})
 }
@@ -302,314 +215,298 @@ func UpdateRoutes(c *gin.Context) {
 	var airline airline.Airline
 	err := c.ShouldBindJSON(&airline)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(c.Param("id"))
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"reviews", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{airline.Routes, bson.A{}}}}}}}, "$routes"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$routes", airline.Routes}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$routes", bson.D{{"$ifNull", bson.A{airline.Routes, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	err = getRepositories(c).Airlines.Toggle(ctx, objectId, "routes", airline.Routes)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
+	invalidate("airlines", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The airline routes have been updated"})
 }
 
 func UpdateFleet(c *gin.Context) {
+	repos := getRepositories(c)
 	var airline airline.Airline
 	err := c.ShouldBindJSON(&airline)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"fleet", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{airline.Fleet, bson.A{}}}}}}}, "$fleet"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$fleet", airline.Fleet}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$fleet", bson.D{{"$ifNull", bson.A{airline.Fleet, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+	objectId := paramId(c, "id")
+	current, err := repos.Airlines.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such airline"))
+		return
+	} else if err != nil {
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
-	aircraftService := services.GetAircraftService()
+	inFleet := make(map[primitive.ObjectID]bool, len(current.Fleet))
+	for _, x := range current.Fleet {
+		inFleet[x] = true
+	}
+	// owners can drop any aircraft of the fleet but only bring in aircraft they own,
+	// whatever the toggle does with the list
 	for _, x := range airline.Fleet {
-		newAircraftObjectId, _ := primitive.ObjectIDFromHex(x.Hex())
-		var newAircraft aircraft.Aircraft
-		newAircraftVal, err := aircraftService.RedisClient.Get("aircraft/" + x.Hex()).Result()
-		if err == redis.Nil {
-			log.Printf("Request to MongoDB")
-			err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": newAircraftObjectId}).Decode(&newAircraft)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			data, _ := json.Marshal(newAircraft)
-			aircraftService.RedisClient.Set("aircraft/"+x.Hex(), string(data), 0)
-		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(newAircraftVal), &newAircraft)
+		if inFleet[x] {
+			continue
 		}
-		var airlineHistory []primitive.ObjectID
-		airlineHistory = append(airlineHistory, objectId)
-		filter := bson.D{{"_id", newAircraftObjectId}}
-		update := bson.D{{"$set", bson.D{
-			{"general.history", bson.D{
-				{"$cond", bson.D{
-					{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{airlineHistory, bson.A{}}}}}}}, "$general.history"}}}},
-					{"then", bson.D{{"$setDifference", bson.A{"$general.history", airlineHistory}}}},
-					{"else", bson.D{{"$concatArrays", bson.A{"$general.history", bson.D{{"$ifNull", bson.A{airlineHistory, bson.A{}}}}}}}}}}}}}}}
-		_, err = aircraftService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+		owner, err := aircraftOwner(repos, x)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
+			return
+		}
+		if !canModify(c, owner) {
+			c.Error(apierrors.Forbidden("Only the owner can add the aircraft " + x.Hex() + " to a fleet"))
+			return
+		}
+	}
+	work := services.NewUnitOfWork("Update fleet of airline " + id)
+	work.Add("update the fleet",
+		changeArray(repos.Airlines.Toggle, objectId, "fleet", airline.Fleet),
+		restoreField(repos.Airlines.Update, objectId, "fleet", current.Fleet))
+	var aircraftIds []string
+	for _, x := range airline.Fleet {
+		newAircraftObjectId := x
+		// undo puts back what is in the database, the cache may be stale
+		newAircraft, err := repos.Aircraft.FindById(ctx, newAircraftObjectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such aircraft " + x.Hex()))
 			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		var history []primitive.ObjectID
+		if newAircraft.General != nil {
+			history = newAircraft.General.History
 		}
-		log.Println("Remove aircraft id data from Redis")
-		aircraftService.RedisClient.Del("aircraft/" + x.Hex())
+		work.Add("update the history of aircraft "+x.Hex(),
+			changeArray(repos.Aircraft.Toggle, newAircraftObjectId, "general.history", []primitive.ObjectID{objectId}),
+			restoreField(repos.Aircraft.Update, newAircraftObjectId, "general.history", history))
+		aircraftIds = append(aircraftIds, x.Hex())
+	}
+	err = work.Run(ctx)
+	invalidate("airlines", id)
+	invalidate("aircraft", aircraftIds...)
+	if err != nil {
+		c.Error(err)
+		return
 	}
-	log.Println("Remove aircraft data from Redis")
-	aircraftService.RedisClient.Del("aircraft")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The airline fleet has been updated"})
 }
 
 func GetFleetData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	airlineId := c.Param("id")
+	// the whole view is cached too and goes away with the airline or any aircraft of its fleet
+	var aircraftArray []aircraft.Aircraft
+	viewKey := cache.Key("airlines", "fleet", airlineId)
+	if responseCache.Get(viewKey, &aircraftArray) == nil {
+		log.Printf("Request to the cache")
+		c.JSON(http.StatusOK, aircraftArray)
+		return
+	}
 	var airline airline.Airline
-	val, err := airlineService.RedisClient.Get("airlines/" + airlineId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("airlines", airlineId), &airline)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		airlineObjectId, err := primitive.ObjectIDFromHex(airlineId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		err = airlineService.Collection.FindOne(ctx, bson.M{"_id": airlineObjectId}).Decode(&airline)
+		airlineObjectId := paramId(c, "id")
+		airline, err = repos.Airlines.FindById(ctx, airlineObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airline)
-		airlineService.RedisClient.Set("airlines/"+airlineId, string(data), 0)
+		cacheSet(idKey("airlines", airlineId), "airlines", airline, cache.Tag("airlines", airlineId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airline)
+		log.Printf("Request to the cache")
 	}
-	var aircraftArray []aircraft.Aircraft
+	tags := []string{cache.Tag("airlines", airlineId)}
 	for _, x := range airline.Fleet {
 		aircraftId := x.Hex()
-		aircraftObjectId, err := primitive.ObjectIDFromHex(aircraftId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		aircraftService := services.GetAircraftService()
+		tags = append(tags, cache.Tag("aircraft", aircraftId))
+		aircraftObjectId := x
 		var aircraft aircraft.Aircraft
-		aircraftVal, err := aircraftService.RedisClient.Get("aircraft/" + aircraftId).Result()
-		if err == redis.Nil {
+		err = responseCache.Get(idKey("aircraft", aircraftId), &aircraft)
+		if err == cache.ErrMiss {
 			log.Printf("Request to MongoDB")
-			err = aircraftService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(&aircraft)
+			aircraft, err = repos.Aircraft.FindById(ctx, aircraftObjectId)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
+				c.Error(err)
 				return
 			}
-			//Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-			data, _ := json.Marshal(aircraft)
-			aircraftService.RedisClient.Set("aircraft/"+aircraftId, string(data), 0)
+			cacheSet(idKey("aircraft", aircraftId), "aircraft", aircraft, cache.Tag("aircraft", aircraftId))
 			aircraftArray = append(aircraftArray, aircraft)
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(aircraftVal), &aircraft)
+			log.Printf("Request to the cache")
 			aircraftArray = append(aircraftArray, aircraft)
 		}
 	}
 	if len(aircraftArray) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "The airline has no fleet"})
+		c.Error(apierrors.NotFound("The airline has no fleet"))
 		return
 	}
+	cacheSet(viewKey, "airlines", aircraftArray, tags...)
 	c.JSON(http.StatusOK, aircraftArray)
 }
 
 func UpdateAirlineOwner(c *gin.Context) {
-	var airline airline.Airline
-	err := c.ShouldBindJSON(&airline)
+	var update airline.Airline
+	err := c.ShouldBindJSON(&update)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
+		return
+	}
+	if update.Owner == primitive.NilObjectID {
+		c.Error(apierrors.Validation("The owner is required"))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"owner", bson.D{
-			{"$cond", bson.D{
-				{"if", airline.Owner != primitive.NilObjectID},
-				{"then", airline.Owner},
-				{"else", "$owner"}}}}}}}}
-
-	_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	repos := getRepositories(c)
+	airlineData, err := repos.Airlines.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such airline"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	_, err = repos.Users.FindById(ctx, update.Owner)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.Validation("No such user"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	work := services.NewUnitOfWork("Update the owner of airline " + id)
+	err = addOwnerChange(ctx, work, repos, airlineData, update.Owner)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
-	airlineService.RedisClient.Del("airlines/" + id)
-	userService := services.GetUserService()
-	userId := airline.Owner.Hex()
-	var newAirlines []primitive.ObjectID
-	newAirlines = append(newAirlines, objectId)
-	userObjectId, _ := primitive.ObjectIDFromHex(userId)
-	userFilter := bson.D{{"_id", userObjectId}}
-	userUpdate := bson.D{{"$set", bson.D{
-		{"airlines", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{newAirlines, bson.A{}}}}}}}, "$airlines"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$airlines", newAirlines}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$airlines", bson.D{{"$ifNull", bson.A{newAirlines, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = userService.Collection.UpdateOne(ctx, userFilter, mongo.Pipeline{userUpdate})
+	err = work.Run(ctx)
+	invalidate("airlines", id)
+	invalidate("users", airlineData.Owner.Hex(), update.Owner.Hex())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
-	log.Println("Remove user data from Redis")
-	userService.RedisClient.Del("users")
-	userService.RedisClient.Del("users/" + userId)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The owner has been updated"})
-	return
+}
+
+// adds the steps handing an airline over to owner, a nil owner leaves it without one.
+// The airline leaves the airlines of its former owner and joins those of the new one
+func addOwnerChange(ctx context.Context, work *services.UnitOfWork, repos repositories.Repositories, airlineData airline.Airline, owner primitive.ObjectID) error {
+	if airlineData.Owner == owner {
+		return nil
+	}
+	airlineId := airlineData.ID.Hex()
+	if airlineData.Owner != primitive.NilObjectID {
+		// undo puts back what is in the database
+		former, err := repos.Users.FindById(ctx, airlineData.Owner)
+		if err == nil {
+			work.Add("remove airline "+airlineId+" from its owner "+former.ID.Hex(),
+				changeArray(repos.Users.Pull, former.ID, "airlines", airlineData.ID),
+				restoreField(repos.Users.Update, former.ID, "airlines", former.Airlines))
+		} else if err != repositories.ErrNotFound {
+			return err
+		}
+	}
+	if owner != primitive.NilObjectID {
+		work.Add("add airline "+airlineId+" to the airlines of user "+owner.Hex(),
+			changeArray(repos.Users.AddToSet, owner, "airlines", airlineData.ID),
+			changeArray(repos.Users.Pull, owner, "airlines", airlineData.ID))
+	}
+	work.Add("set the owner of airline "+airlineId,
+		func(ctx context.Context) error {
+			return repos.Airlines.Apply(ctx, airlineData.ID, nil, ownership(owner))
+		},
+		func(ctx context.Context) error {
+			return repos.Airlines.Apply(ctx, airlineData.ID, nil, ownership(airlineData.Owner))
+		})
+	return nil
+}
+
+// the change making owner the owner of an airline, a nil owner removes the owner
+func ownership(owner primitive.ObjectID) repositories.Change {
+	if owner == primitive.NilObjectID {
+		return repositories.Change{Unset: []string{"owner"}}
+	}
+	return repositories.Change{Set: bson.M{"owner": owner}}
 }
 
 func GetAirlineOwnerData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	airlineService := services.GetAirlineService()
 	airlineId := c.Param("id")
 	var airline airline.Airline
-	val, err := airlineService.RedisClient.Get("airlines/" + airlineId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("airlines", airlineId), &airline)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		airlineObjectId, err := primitive.ObjectIDFromHex(airlineId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		err = airlineService.Collection.FindOne(ctx, bson.M{"_id": airlineObjectId}).Decode(&airline)
+		airlineObjectId := paramId(c, "id")
+		airline, err = repos.Airlines.FindById(ctx, airlineObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(airline)
-		airlineService.RedisClient.Set("airlines/"+airlineId, string(data), 0)
+		cacheSet(idKey("airlines", airlineId), "airlines", airline, cache.Tag("airlines", airlineId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &airline)
+		log.Printf("Request to the cache")
 	}
 
 	if airline.Owner == primitive.NilObjectID {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "Airline does not have an owner"})
+		c.Error(apierrors.NotFound("Airline does not have an owner"))
 		return
 	}
 	userId := airline.Owner.Hex()
-	userObjectId, err := primitive.ObjectIDFromHex(userId)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
-		return
-	}
+	userObjectId := airline.Owner
 	var owner user.User
-	userService := services.GetUserService()
-	userVal, err := userService.RedisClient.Get("users/" + userId).Result()
-	if err == redis.Nil {
+	err = responseCache.Get(idKey("users", userId), &owner)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		err = userService.Collection.FindOne(ctx, bson.M{"_id": userObjectId}).Decode(&owner)
+		owner, err = repos.Users.FindById(ctx, userObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		//Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(owner)
-		userService.RedisClient.Set("users/"+userId, string(data), 0)
+		cacheSet(idKey("users", userId), "users", owner, cache.Tag("users", userId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(userVal), &owner)
+		log.Printf("Request to the cache")
 	}
 	if owner.ID == primitive.NilObjectID {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "No owner found"})
+		c.Error(apierrors.NotFound("No owner found"))
 		return
 	}
 	c.JSON(http.StatusOK, owner)
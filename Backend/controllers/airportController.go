@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/ourairports"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// max number of airports returned by a search
+const airportSearchLimit = 50
+
+// returns nil when there is no such airport
+func findAirport(ctx context.Context, repos repositories.Repositories, id primitive.ObjectID) (*airport.Airport, error) {
+	found, err := repos.Airports.FindById(ctx, id)
+	if err == repositories.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &found, nil
+}
+
+// airport lookup by a code field with a cache in front of it
+func getAirportByCode(c *gin.Context, field string, code string) {
+	code = strings.ToUpper(code)
+	var airportData airport.Airport
+	key := cache.Key("airports", field, code)
+	err := responseCache.Get(key, &airportData)
+	if err == cache.ErrMiss {
+		log.Printf("Request to MongoDB")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		airportData, err = getRepositories(c).Airports.FindOne(ctx, bson.M{field: code})
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such airport"))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		cacheSet(key, "airports", airportData, cache.Tag("airports"))
+	} else if err != nil {
+		c.Error(err)
+		return
+	} else {
+		log.Printf("Request to the cache")
+	}
+	c.JSON(http.StatusOK, airportData)
+}
+
+func GetAirportByICAO(c *gin.Context) {
+	getAirportByCode(c, "icao", c.Param("icao"))
+}
+
+func GetAirportByIATA(c *gin.Context) {
+	getAirportByCode(c, "iata", c.Param("iata"))
+}
+
+// searches airports by name, codes and country
+func GetAirports(c *gin.Context) {
+	conditions := make([]repositories.Condition, 0)
+	for _, field := range []string{"icao", "iata", "country"} {
+		if value := c.Query(field); value != "" {
+			conditions = append(conditions, repositories.Condition{
+				Field: field, Operator: repositories.Eq, Values: []interface{}{strings.ToUpper(value)}})
+		}
+	}
+	if name := c.Query("name"); name != "" {
+		conditions = append(conditions, repositories.Condition{
+			Field: "name", Operator: repositories.Match, Values: []interface{}{regexp.QuoteMeta(name)}})
+	}
+	if len(conditions) == 0 {
+		c.Error(apierrors.Validation("Provide at least one of name, icao, iata or country"))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airports, _, err := getRepositories(c).Airports.List(ctx, repositories.Query{
+		Conditions: conditions,
+		Sort:       []repositories.Sort{{Field: "name"}},
+		Limit:      airportSearchLimit})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, airports)
+}
+
+// resolves a file name inside the airports import directory
+func importPath(name string) (string, error) {
+	dir := settings.Airports.ImportDir
+	if name == "" || filepath.Base(name) != name {
+		return "", errors.New("Import files must be plain file names inside the import directory")
+	}
+	return filepath.Join(dir, name), nil
+}
+
+type importAirportsRequest struct {
+	Airports string   `json:"airports"`
+	Runways  string   `json:"runways"`
+	Types    []string `json:"types"`
+}
+
+// imports OurAirports csv files placed in the import directory
+func ImportAirports(c *gin.Context) {
+	var request importAirportsRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	airportsPath, err := importPath(request.Airports)
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
+	runwaysPath := ""
+	if request.Runways != "" {
+		runwaysPath, err = importPath(request.Runways)
+		if err != nil {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	result, err := ourairports.Import(ctx, getRepositories(c).Airports, airportsPath, runwaysPath, request.Types)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("airports")
+	c.JSON(http.StatusOK, result)
+}
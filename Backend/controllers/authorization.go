@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/flight"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/auth"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the signed in user loaded by the auth middleware
+func getCurrentUser(c *gin.Context) user.User {
+	currentUser, _ := c.MustGet(auth.UserKey).(user.User)
+	return currentUser
+}
+
+func isAdmin(u user.User) bool {
+	return u.IsAdmin != nil && *u.IsAdmin
+}
+
+func forbid(c *gin.Context, message string) {
+	apierrors.Abort(c, apierrors.Forbidden(message))
+}
+
+// admins pass every ownership check, everyone else has to own the document
+func canModify(c *gin.Context, owner primitive.ObjectID) bool {
+	currentUser := getCurrentUser(c)
+	return isAdmin(currentUser) || (owner != primitive.NilObjectID && owner == currentUser.ID)
+}
+
+// loads the document of the id param with find, aborting with 404 when there is none
+func findByIdParam(c *gin.Context, name string, find func(ctx context.Context, id primitive.ObjectID) error) bool {
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := find(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		apierrors.Abort(c, apierrors.NotFound("No such "+name))
+		return false
+	} else if err != nil {
+		apierrors.Abort(c, err)
+		return false
+	}
+	return true
+}
+
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !isAdmin(getCurrentUser(c)) {
+			forbid(c, "Admin rights required")
+			return
+		}
+		c.Next()
+	}
+}
+
+// users can only change their own account unless they are admins
+func RequireSelf() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		objectId := paramId(c, "id")
+		if !canModify(c, objectId) {
+			forbid(c, "You can only access your own account")
+			return
+		}
+		c.Next()
+	}
+}
+
+func RequireAircraftOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repos := getRepositories(c)
+		var airplane aircraft.Aircraft
+		if !findByIdParam(c, "aircraft", func(ctx context.Context, id primitive.ObjectID) (err error) {
+			airplane, err = repos.Aircraft.FindById(ctx, id)
+			return err
+		}) {
+			return
+		}
+		if !canModify(c, airplane.Owner) {
+			forbid(c, "Only the owner can modify the aircraft")
+			return
+		}
+		c.Next()
+	}
+}
+
+func RequireAirlineOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repos := getRepositories(c)
+		var airlineData airline.Airline
+		if !findByIdParam(c, "airline", func(ctx context.Context, id primitive.ObjectID) (err error) {
+			airlineData, err = repos.Airlines.FindById(ctx, id)
+			return err
+		}) {
+			return
+		}
+		if !canModify(c, airlineData.Owner) {
+			forbid(c, "Only the owner can modify the airline")
+			return
+		}
+		c.Next()
+	}
+}
+
+// engines belong to the owner of the aircraft they are installed on
+func RequireEngineOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repos := getRepositories(c)
+		var engine aircraft.Engine
+		if !findByIdParam(c, "engine", func(ctx context.Context, id primitive.ObjectID) (err error) {
+			engine, err = repos.Engines.FindById(ctx, id)
+			return err
+		}) {
+			return
+		}
+		owner, err := aircraftOwner(repos, engine.OwningAircraft)
+		if err != nil {
+			apierrors.Abort(c, err)
+			return
+		}
+		if !canModify(c, owner) {
+			forbid(c, "Only the aircraft owner can modify the engine")
+			return
+		}
+		c.Next()
+	}
+}
+
+// routes belong to the owner of their airline
+func RequireRouteOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repos := getRepositories(c)
+		var route airline.Route
+		if !findByIdParam(c, "route", func(ctx context.Context, id primitive.ObjectID) (err error) {
+			route, err = repos.Routes.FindById(ctx, id)
+			return err
+		}) {
+			return
+		}
+		owner, err := airlineOwner(repos, route.Airline)
+		if err != nil {
+			apierrors.Abort(c, err)
+			return
+		}
+		if !canModify(c, owner) {
+			forbid(c, "Only the airline owner can modify the route")
+			return
+		}
+		c.Next()
+	}
+}
+
+// flights belong to the owner of their airline
+func RequireFlightOwner() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		repos := getRepositories(c)
+		var flightData flight.Flight
+		if !findByIdParam(c, "flight", func(ctx context.Context, id primitive.ObjectID) (err error) {
+			flightData, err = repos.Flights.FindById(ctx, id)
+			return err
+		}) {
+			return
+		}
+		owner, err := airlineOwner(repos, flightData.Airline)
+		if err != nil {
+			apierrors.Abort(c, err)
+			return
+		}
+		if !canModify(c, owner) {
+			forbid(c, "Only the airline owner can modify the flight")
+			return
+		}
+		c.Next()
+	}
+}
+
+// returns a nil id when the aircraft does not exist or has no owner
+func aircraftOwner(repos repositories.Repositories, aircraftObjectId primitive.ObjectID) (primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airplane, err := repos.Aircraft.FindById(ctx, aircraftObjectId)
+	if err == repositories.ErrNotFound {
+		return primitive.NilObjectID, nil
+	}
+	return airplane.Owner, err
+}
+
+// returns a nil id when the airline does not exist or has no owner
+func airlineOwner(repos repositories.Repositories, airlineObjectId primitive.ObjectID) (primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airlineData, err := repos.Airlines.FindById(ctx, airlineObjectId)
+	if err == repositories.ErrNotFound {
+		return primitive.NilObjectID, nil
+	}
+	return airlineData.Owner, err
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"log"
+
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+)
+
+// the cache of handler reads, injected at startup, nothing is cached until then
+var responseCache cache.Cache = cache.NewNoop()
+
+func UseCache(c cache.Cache) {
+	responseCache = c
+}
+
+func idKey(entity string, id string) string {
+	return cache.Key(entity, "id", id)
+}
+
+// caches value for the entity TTL, failures only cost a later miss
+func cacheSet(key string, entity string, value interface{}, tags ...string) {
+	if err := responseCache.Set(key, value, settings.Cache.EntityTTL(entity), tags...); err != nil {
+		log.Printf("Cache %s: %v", key, err)
+	}
+}
+
+// evicts the lists of an entity and every entry showing one of the documents
+func invalidate(entity string, ids ...string) {
+	log.Printf("Remove %s data from the cache", entity)
+	tags := []string{cache.Tag(entity)}
+	for _, x := range ids {
+		tags = append(tags, cache.Tag(entity, x))
+	}
+	if err := responseCache.Invalidate(tags...); err != nil {
+		log.Printf("Invalidate %s cache: %v", entity, err)
+	}
+}
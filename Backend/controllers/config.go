@@ -0,0 +1,12 @@
+package controllers
+
+import (
+	"github.com/arttkachev/X-Airlines/Backend/config"
+)
+
+// the settings handlers read, injected at startup
+var settings = config.Defaults(config.Dev)
+
+func UseConfig(c *config.Config) {
+	settings = c
+}
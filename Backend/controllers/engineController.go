@@ -2,194 +2,283 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
+	"io"
 	"log"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
-	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/gin-gonic/gin"
-	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func CreateEngine(c *gin.Context) {
+	repos := getRepositories(c)
 	var engine aircraft.Engine
 	err := c.ShouldBindJSON(&engine)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
+	err = validation.Create(&engine)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	if engine.OwningAircraft != primitive.NilObjectID {
+		owner, err := aircraftOwner(repos, engine.OwningAircraft)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !canModify(c, owner) {
+			c.Error(apierrors.Forbidden("Only the aircraft owner can install engines on it"))
+			return
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	engine.ID = primitive.NewObjectID()
-	engineService := services.GetEngineService()
-	collection := engineService.Collection
-	_, err = collection.InsertOne(ctx, engine)
+	if engine.MaintenanceLog == nil {
+		engine.MaintenanceLog = make([]aircraft.MaintenanceEntry, 0)
+	}
+	err = repos.Engines.Insert(ctx, engine)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	// clear cache
-	log.Println("Remove engine data from Redis")
-	engineService.RedisClient.Del("engines")
+	invalidate("engines")
 	c.JSON(http.StatusOK, engine)
 }
 
 func GetEngines(c *gin.Context) {
-	var engineService = services.GetEngineService()
-	engines := make([]aircraft.Engine, 0)
-	val, err := engineService.RedisClient.Get("engines").Result()
-	if err == redis.Nil {
+	repos := getRepositories(c)
+	query, err := parseListQuery(c)
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
+	var page struct {
+		Items []aircraft.Engine `json:"items"`
+		repositories.Page
+	}
+	err = responseCache.Get(pageKey("engines", c), &page)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cur, err := engineService.Collection.Find(ctx, bson.M{})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		page.Items, page.Page, err = repos.Engines.List(ctx, query)
+		if err == repositories.ErrInvalidCursor {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
-		defer cur.Close(ctx)
-		for cur.Next(ctx) {
-			var engine aircraft.Engine
-			decodeErr := cur.Decode(&engine)
-			if decodeErr != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": decodeErr.Error()})
-				return
-			}
-			engines = append(engines, engine)
-		}
-		data, _ := json.Marshal(engines)
-		engineService.RedisClient.Set("engines", string(data), 0)
+		cacheSet(pageKey("engines", c), "engines", page, cache.Tag("engines"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &engines)
+		log.Printf("Request to the cache")
 	}
-	c.JSON(http.StatusOK, engines)
+	for i := range page.Items {
+		maintenance.FillRemaining(&page.Items[i])
+	}
+	c.JSON(http.StatusOK, page)
 }
 
 func GetEngineById(c *gin.Context) {
+	repos := getRepositories(c)
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	var engineService = services.GetEngineService()
+	objectId := paramId(c, "id")
 	var engine aircraft.Engine
-	val, err := engineService.RedisClient.Get("engines/" + id).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("engines", id), &engine)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		err = engineService.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&engine)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		engine, err = repos.Engines.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such engine"))
 			return
-		}
-		if engine.ID == primitive.NilObjectID {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "No such engine"})
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
 
-		data, _ := json.Marshal(engine)
-		engineService.RedisClient.Set("engines/"+id, string(data), 0)
+		cacheSet(idKey("engines", id), "engines", engine, cache.Tag("engines", id))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &engine)
+		log.Printf("Request to the cache")
 	}
+	maintenance.FillRemaining(&engine)
 	c.JSON(http.StatusOK, engine)
 }
 
 func UpdateEngine(c *gin.Context) {
+	repos := getRepositories(c)
 	var engine aircraft.Engine
 	err := c.ShouldBindJSON(&engine)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
+	if engine.OwningAircraft != primitive.NilObjectID {
+		owner, err := aircraftOwner(repos, engine.OwningAircraft)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !canModify(c, owner) {
+			c.Error(apierrors.Forbidden("Only the aircraft owner can install engines on it"))
+			return
+		}
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	engineService := services.GetEngineService()
-	collection := engineService.Collection
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"owningAircraft", bson.D{
-			{"$cond", bson.D{
-				{"if", engine.OwningAircraft != primitive.NilObjectID},
-				{"then", engine.OwningAircraft},
-				{"else", "$owningAircraft"}}}}},
-		{"model", bson.D{
-			{"$cond", bson.D{
-				{"if", engine.Model != ""},
-				{"then", engine.Model},
-				{"else", "$model"}}}}},
-
-		{"totalTime", bson.D{
-			{"$cond", bson.D{
-				{"if", engine.TotalTime != nil},
-				{"then", engine.TotalTime},
-				{"else", "$totalTime"}}}}},
-
-		{"tbo", bson.D{
-			{"$cond", bson.D{
-				{"if", engine.TBO != nil},
-				{"then", engine.TBO},
-				{"else", "$tbo"}}}}},
-
-		{"hst", bson.D{
-			{"$cond", bson.D{
-				{"if", engine.HST != nil},
-				{"then", engine.HST},
-				{"else", "$hst"}}}}}}}}
-	_, err = collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+	objectId := paramId(c, "id")
+	fields := givenFields(bson.M{
+		"owningAircraft":  engine.OwningAircraft,
+		"model":           engine.Model,
+		"totalTime":       engine.TotalTime,
+		"tbo":             engine.TBO,
+		"hst":             engine.HST,
+		"sinceOverhaul":   engine.SinceOverhaul,
+		"sinceHotSection": engine.SinceHotSection})
+	err = repos.Engines.Update(ctx, objectId, fields)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	}
 	// clear cache
-	log.Println("Remove engine data from Redis")
-	engineService.RedisClient.Del("engines")
-	engineService.RedisClient.Del("engines/" + id)
+	invalidate("engines", id)
+	if engine.OwningAircraft != primitive.NilObjectID {
+		invalidate("aircraft", engine.OwningAircraft.Hex())
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The engine has been updated"})
 }
 
 func DeleteEngine(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	engineService := services.GetEngineService()
-	collection := engineService.Collection
-	deleteResult, _ := collection.DeleteOne(ctx, bson.M{"_id": objectId})
-	if deleteResult.DeletedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Error on deleting an engine"})
+	objectId := paramId(c, "id")
+	err := repos.Engines.Delete(ctx, objectId)
+	if err != nil {
+		c.Error(err)
 		return
 	}
 	// clear cache
-	log.Println("Remove engine data from Redis")
-	engineService.RedisClient.Del("engines")
-	engineService.RedisClient.Del("engines/" + id)
+	invalidate("engines", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "An engine has been deleted"})
 }
+
+// lists engines whose overhaul or hot section inspection is due within threshold hours
+func GetDueEngines(c *gin.Context) {
+	repos := getRepositories(c)
+	threshold := settings.Maintenance.EngineDueThreshold
+	if value := c.Query("threshold"); value != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			c.Error(apierrors.Validation("threshold must be a number"))
+			return
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	tracked, _, err := repos.Engines.List(ctx, repositories.Query{Conditions: []repositories.Condition{{
+		Operator: repositories.Or,
+		Values: []interface{}{
+			repositories.Condition{Field: "tbo", Operator: repositories.Exists, Values: []interface{}{true}},
+			repositories.Condition{Field: "hst", Operator: repositories.Exists, Values: []interface{}{true}}}}}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	engines := make([]aircraft.Engine, 0)
+	for _, engine := range tracked {
+		if maintenance.Due(engine, threshold) {
+			maintenance.FillRemaining(&engine)
+			engines = append(engines, engine)
+		}
+	}
+	c.JSON(http.StatusOK, engines)
+}
+
+type overhaulEngineRequest struct {
+	Type  string `json:"type"`
+	Notes string `json:"notes"`
+}
+
+// records an overhaul or a hot section inspection, an overhaul resets both counters
+func OverhaulEngine(c *gin.Context) {
+	repos := getRepositories(c)
+	var event overhaulEngineRequest
+	err := c.ShouldBindJSON(&event)
+	if err != nil && err != io.EOF {
+		c.Error(validation.Error(err))
+		return
+	}
+	if event.Type == "" {
+		event.Type = aircraft.Overhaul
+	}
+	if event.Type != aircraft.Overhaul && event.Type != aircraft.HotSection {
+		c.Error(apierrors.Validation("type must be overhaul or hotSection"))
+		return
+	}
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var engine aircraft.Engine
+	engine, err = repos.Engines.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such engine"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	entry := aircraft.MaintenanceEntry{
+		Type:  event.Type,
+		Date:  time.Now().UTC(),
+		Notes: event.Notes}
+	if engine.TotalTime != nil {
+		entry.TotalTime = *engine.TotalTime
+	}
+	reset := bson.M{"sinceHotSection": 0}
+	if event.Type == aircraft.Overhaul {
+		reset["sinceOverhaul"] = 0
+	}
+	err = repos.Engines.Apply(ctx, objectId, nil, repositories.Change{
+		Set:  reset,
+		Push: bson.M{"maintenanceLog": entry}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	// clear cache
+	invalidate("engines", id)
+	if engine.OwningAircraft != primitive.NilObjectID {
+		invalidate("aircraft", engine.OwningAircraft.Hex())
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The engine maintenance has been recorded"})
+}
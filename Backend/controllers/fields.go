@@ -0,0 +1,33 @@
+package controllers
+
+import (
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the fields a request body gives, fields left out keep their stored value:
+// nil pointers, maps and slices, empty strings and nil ids
+func givenFields(values bson.M) bson.M {
+	fields := bson.M{}
+	for field, value := range values {
+		switch v := reflect.ValueOf(value); v.Kind() {
+		case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+			if v.IsNil() {
+				continue
+			}
+		case reflect.String:
+			if v.Len() == 0 {
+				continue
+			}
+		case reflect.Invalid:
+			continue
+		}
+		if value == primitive.NilObjectID {
+			continue
+		}
+		fields[field] = value
+	}
+	return fields
+}
@@ -0,0 +1,510 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/flight"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/services/geo"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// checks that every document a flight refers to exists and that the aircraft flies for the airline,
+// then fills in the distance and times of the flight
+func validateFlight(ctx context.Context, repos repositories.Repositories, flightData *flight.Flight) error {
+	if flightData.Airline == primitive.NilObjectID || flightData.Aircraft == primitive.NilObjectID ||
+		flightData.Departure == primitive.NilObjectID || flightData.Arrival == primitive.NilObjectID {
+		return apierrors.Validation("Airline, aircraft, departure and arrival are required")
+	}
+	if flightData.Departure == flightData.Arrival {
+		return apierrors.Validation("Departure and arrival must be different airports")
+	}
+	airlineData, err := repos.Airlines.FindById(ctx, flightData.Airline)
+	if err == repositories.ErrNotFound {
+		return apierrors.Validation("No such airline")
+	} else if err != nil {
+		return err
+	}
+	inFleet := false
+	for _, x := range airlineData.Fleet {
+		if x == flightData.Aircraft {
+			inFleet = true
+			break
+		}
+	}
+	if !inFleet {
+		return apierrors.Validation("The aircraft is not in the airline fleet")
+	}
+	airplane, err := repos.Aircraft.FindById(ctx, flightData.Aircraft)
+	if err == repositories.ErrNotFound {
+		return apierrors.Validation("No such aircraft")
+	} else if err != nil {
+		return err
+	}
+	if airplane.Airframe != nil && airplane.Airframe.Check != nil && airplane.Airframe.Check.Started {
+		return apierrors.Conflict("The aircraft is in a " + airplane.Airframe.Check.Type + " check")
+	}
+	// aircraft with an engine past TBO are grounded until the overhaul
+	for _, x := range airplane.Engines {
+		engine, err := repos.Engines.FindById(ctx, x)
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if maintenance.PastTBO(engine) {
+			return apierrors.Conflict(fmt.Sprintf("The aircraft engine %s is past TBO", engine.ID.Hex()))
+		}
+	}
+	departure, err := findAirport(ctx, repos, flightData.Departure)
+	if err != nil {
+		return err
+	}
+	if departure == nil {
+		return apierrors.Validation("No such departure airport")
+	}
+	arrival, err := findAirport(ctx, repos, flightData.Arrival)
+	if err != nil {
+		return err
+	}
+	if arrival == nil {
+		return apierrors.Validation("No such arrival airport")
+	}
+	if flightData.Route != primitive.NilObjectID {
+		route, err := repos.Routes.FindById(ctx, flightData.Route)
+		if err == repositories.ErrNotFound {
+			return apierrors.Validation("No such route")
+		} else if err != nil {
+			return err
+		}
+		if route.Airline != flightData.Airline {
+			return apierrors.Validation("The route does not belong to the airline")
+		}
+		if route.From != flightData.Departure || route.To != flightData.Arrival {
+			return apierrors.Validation("Departure and arrival do not match the route")
+		}
+	}
+	if airplane.Performance == nil || airplane.Performance.CruiseSpeed == nil || *airplane.Performance.CruiseSpeed <= 0 {
+		return apierrors.Validation("The aircraft has no cruise speed")
+	}
+	distance := geo.DistanceNM(departure.Latitude, departure.Longitude, arrival.Latitude, arrival.Longitude)
+	if airplane.Performance.Range != nil && distance > float64(*airplane.Performance.Range) {
+		return apierrors.Validation(fmt.Sprintf("The route distance of %.0f nm exceeds the aircraft range of %.0f nm",
+			distance, *airplane.Performance.Range))
+	}
+	cruiseSpeed := float64(*airplane.Performance.CruiseSpeed)
+	flightData.Distance = math.Round(distance*10) / 10
+	flightData.FlightTime = uint16(geo.AirTimeMinutes(distance, cruiseSpeed))
+	flightData.BlockTime = uint16(geo.BlockTimeMinutes(distance, cruiseSpeed))
+	return nil
+}
+
+func CreateFlight(c *gin.Context) {
+	repos := getRepositories(c)
+	var flightData flight.Flight
+	err := c.ShouldBindJSON(&flightData)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	err = validation.Create(&flightData)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	owner, err := airlineOwner(repos, flightData.Airline)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if !canModify(c, owner) {
+		c.Error(apierrors.Forbidden("Only the airline owner can schedule its flights"))
+		return
+	}
+	err = validateFlight(ctx, repos, &flightData)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	flightData.ID = primitive.NewObjectID()
+	flightData.Status = flight.Scheduled
+	if flightData.DepartureTime == nil {
+		flightData.DepartureTime = make(map[string]string)
+	}
+	if flightData.ArrivalTime == nil {
+		flightData.ArrivalTime = make(map[string]string)
+	}
+	work := services.NewUnitOfWork("Create flight " + flightData.ID.Hex())
+	work.Add("insert the flight",
+		func(ctx context.Context) error {
+			return repos.Flights.Insert(ctx, flightData)
+		},
+		func(ctx context.Context) error {
+			return repos.Flights.Delete(ctx, flightData.ID)
+		})
+	// add the flight to the aircraft flight history
+	work.Add("add the flight to the flight history of aircraft "+flightData.Aircraft.Hex(),
+		changeArray(repos.Aircraft.AddToSet, flightData.Aircraft, "trackerData.flightHistory", flightData.ID),
+		changeArray(repos.Aircraft.Pull, flightData.Aircraft, "trackerData.flightHistory", flightData.ID))
+	if flightData.Route != primitive.NilObjectID {
+		work.Add("add the flight to route "+flightData.Route.Hex(),
+			changeArray(repos.Routes.AddToSet, flightData.Route, "flights", flightData.ID),
+			changeArray(repos.Routes.Pull, flightData.Route, "flights", flightData.ID))
+	}
+	err = work.Run(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if flightData.Route != primitive.NilObjectID {
+		invalidate("routes", flightData.Route.Hex())
+	}
+	// clear cache
+	invalidate("flights")
+	invalidate("aircraft", flightData.Aircraft.Hex())
+	c.JSON(http.StatusOK, flightData)
+}
+
+func GetFlights(c *gin.Context) {
+	repos := getRepositories(c)
+	query, err := parseListQuery(c)
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
+	var page struct {
+		Items []flight.Flight `json:"items"`
+		repositories.Page
+	}
+	err = responseCache.Get(pageKey("flights", c), &page)
+	if err == cache.ErrMiss {
+		log.Printf("Request to MongoDB")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		page.Items, page.Page, err = repos.Flights.List(ctx, query)
+		if err == repositories.ErrInvalidCursor {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		cacheSet(pageKey("flights", c), "flights", page, cache.Tag("flights"))
+	} else if err != nil {
+		c.Error(err)
+		return
+	} else {
+		log.Printf("Request to the cache")
+	}
+	c.JSON(http.StatusOK, page)
+}
+
+func GetFlightById(c *gin.Context) {
+	repos := getRepositories(c)
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	var flightData flight.Flight
+	err := responseCache.Get(idKey("flights", id), &flightData)
+	if err == cache.ErrMiss {
+		log.Printf("Request to MongoDB")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		flightData, err = repos.Flights.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such flight"))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		cacheSet(idKey("flights", id), "flights", flightData, cache.Tag("flights", id))
+	} else if err != nil {
+		c.Error(err)
+		return
+	} else {
+		log.Printf("Request to the cache")
+	}
+	c.JSON(http.StatusOK, flightData)
+}
+
+func UpdateFlight(c *gin.Context) {
+	repos := getRepositories(c)
+	var update flight.Flight
+	err := c.ShouldBindJSON(&update)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	var current flight.Flight
+	current, err = repos.Flights.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such flight"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if current.Status == flight.Cancelled || current.Status == flight.Completed {
+		c.Error(apierrors.Conflict("The flight has been " + strings.ToLower(current.Status)))
+		return
+	}
+	// validate the flight as it is going to look after the update
+	merged := current
+	if update.Airline != primitive.NilObjectID {
+		owner, err := airlineOwner(repos, update.Airline)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		if !canModify(c, owner) {
+			c.Error(apierrors.Forbidden("Only the airline owner can schedule its flights"))
+			return
+		}
+		merged.Airline = update.Airline
+	}
+	if update.Aircraft != primitive.NilObjectID {
+		merged.Aircraft = update.Aircraft
+	}
+	if update.Departure != primitive.NilObjectID {
+		merged.Departure = update.Departure
+	}
+	if update.Arrival != primitive.NilObjectID {
+		merged.Arrival = update.Arrival
+	}
+	if update.Route != primitive.NilObjectID {
+		merged.Route = update.Route
+	}
+	err = validateFlight(ctx, repos, &merged)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	fields := givenFields(bson.M{
+		"flightNumber":        update.FlightNumber,
+		"callsign":            update.Callsign,
+		"route":               merged.Route,
+		"averageArrivalDelay": update.AverageArrivalDelay,
+		"departureTime":       update.DepartureTime,
+		"arrivalTime":         update.ArrivalTime})
+	fields["departure"] = merged.Departure
+	fields["arrival"] = merged.Arrival
+	fields["airline"] = merged.Airline
+	fields["aircraft"] = merged.Aircraft
+	fields["distance"] = merged.Distance
+	fields["flightTime"] = merged.FlightTime
+	fields["blockTime"] = merged.BlockTime
+	previous, err := previousFields(current, fields)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	work := services.NewUnitOfWork("Update flight " + id)
+	work.Add("update the flight",
+		func(ctx context.Context) error {
+			return repos.Flights.Update(ctx, objectId, fields)
+		},
+		func(ctx context.Context) error {
+			return repos.Flights.Apply(ctx, objectId, nil, previous)
+		})
+	// move the flight to the flight history of the newly assigned aircraft
+	if merged.Aircraft != current.Aircraft {
+		work.Add("remove the flight from the flight history of aircraft "+current.Aircraft.Hex(),
+			changeArray(repos.Aircraft.Pull, current.Aircraft, "trackerData.flightHistory", objectId),
+			changeArray(repos.Aircraft.AddToSet, current.Aircraft, "trackerData.flightHistory", objectId))
+		work.Add("add the flight to the flight history of aircraft "+merged.Aircraft.Hex(),
+			changeArray(repos.Aircraft.AddToSet, merged.Aircraft, "trackerData.flightHistory", objectId),
+			changeArray(repos.Aircraft.Pull, merged.Aircraft, "trackerData.flightHistory", objectId))
+	}
+	// move the flight to the newly assigned route
+	if merged.Route != current.Route {
+		if current.Route != primitive.NilObjectID {
+			work.Add("remove the flight from route "+current.Route.Hex(),
+				changeArray(repos.Routes.Pull, current.Route, "flights", objectId),
+				changeArray(repos.Routes.AddToSet, current.Route, "flights", objectId))
+		}
+		work.Add("add the flight to route "+merged.Route.Hex(),
+			changeArray(repos.Routes.AddToSet, merged.Route, "flights", objectId),
+			changeArray(repos.Routes.Pull, merged.Route, "flights", objectId))
+	}
+	err = work.Run(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if merged.Aircraft != current.Aircraft {
+		invalidate("aircraft", current.Aircraft.Hex(), merged.Aircraft.Hex())
+	}
+	if merged.Route != current.Route {
+		if current.Route != primitive.NilObjectID {
+			invalidate("routes", current.Route.Hex())
+		}
+		invalidate("routes", merged.Route.Hex())
+	}
+	invalidate("flights", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The flight has been updated"})
+}
+
+// the change putting the given fields of a flight back to how they were, fields it did not have are removed
+func previousFields(flightData flight.Flight, fields bson.M) (repositories.Change, error) {
+	data, err := bson.Marshal(flightData)
+	if err != nil {
+		return repositories.Change{}, err
+	}
+	var document bson.M
+	if err = bson.Unmarshal(data, &document); err != nil {
+		return repositories.Change{}, err
+	}
+	change := repositories.Change{Set: bson.M{}}
+	for field := range fields {
+		if value, ok := document[field]; ok {
+			change.Set[field] = value
+		} else {
+			change.Unset = append(change.Unset, field)
+		}
+	}
+	return change, nil
+}
+
+func CancelFlight(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	flightData, err := repos.Flights.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such flight"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if flightData.Status == flight.Cancelled {
+		c.Error(apierrors.Conflict("The flight has already been cancelled"))
+		return
+	}
+	if flightData.Status == flight.Completed {
+		c.Error(apierrors.Conflict("The flight has been completed"))
+		return
+	}
+	work := services.NewUnitOfWork("Cancel flight " + id)
+	work.Add("cancel the flight",
+		func(ctx context.Context) error {
+			return repos.Flights.Update(ctx, objectId, bson.M{"status": flight.Cancelled})
+		},
+		restoreField(repos.Flights.Update, objectId, "status", flightData.Status))
+	// a cancelled flight is no longer part of the aircraft flight history
+	work.Add("remove the flight from the flight history of aircraft "+flightData.Aircraft.Hex(),
+		changeArray(repos.Aircraft.Pull, flightData.Aircraft, "trackerData.flightHistory", objectId),
+		changeArray(repos.Aircraft.AddToSet, flightData.Aircraft, "trackerData.flightHistory", objectId))
+	err = work.Run(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", flightData.Aircraft.Hex())
+	invalidate("flights", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The flight has been cancelled"})
+}
+
+// completes a scheduled flight and accrues its flight time and landing on the airframe and the engines,
+// the APU accrues the gate and taxi time
+func CompleteFlight(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	flightData, err := repos.Flights.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such flight"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if flightData.Status != flight.Scheduled {
+		c.Error(apierrors.Conflict("Only scheduled flights can be completed"))
+		return
+	}
+	var airplane aircraft.Aircraft
+	airplane, err = repos.Aircraft.FindById(ctx, flightData.Aircraft)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	completedAt := time.Now().UTC()
+	hours := float64(flightData.FlightTime) / 60
+	work := services.NewUnitOfWork("Complete flight " + id)
+	work.Add("complete the flight",
+		func(ctx context.Context) error {
+			err := repos.Flights.Apply(ctx, objectId,
+				[]repositories.Condition{{Field: "status", Operator: repositories.Eq, Values: []interface{}{flight.Scheduled}}},
+				repositories.Change{Set: bson.M{
+					"status":      flight.Completed,
+					"completedAt": completedAt}})
+			if err == repositories.ErrNotFound {
+				return apierrors.Conflict("The flight is no longer scheduled")
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			return repos.Flights.Apply(ctx, objectId, nil, repositories.Change{
+				Set:   bson.M{"status": flight.Scheduled},
+				Unset: []string{"completedAt"}})
+		})
+	do, undo := applyChange(repos.Aircraft.Apply, airplane.ID, maintenance.AccrueAirframe(hours))
+	work.Add("accrue the flight on the airframe", do, undo)
+	if airplane.APU != nil {
+		do, undo = applyChange(repos.Aircraft.Apply, airplane.ID,
+			maintenance.AccrueAPU(*airplane.APU, maintenance.APUHours(flightData.FlightTime, flightData.BlockTime)))
+		work.Add("accrue the gate and taxi time on the APU", do, undo)
+	}
+	for _, x := range airplane.Engines {
+		engine, err := repos.Engines.FindById(ctx, x)
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		do, undo = applyChange(repos.Engines.Apply, x, maintenance.AccrueEngineHours(engine, hours))
+		work.Add("accrue the flight on engine "+x.Hex(), do, undo)
+	}
+	err = work.Run(ctx)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	engineIds := make([]string, len(airplane.Engines))
+	for i, x := range airplane.Engines {
+		engineIds[i] = x.Hex()
+	}
+	invalidate("engines", engineIds...)
+	invalidate("aircraft", airplane.ID.Hex())
+	invalidate("flights", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The flight has been completed"})
+}
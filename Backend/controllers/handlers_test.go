@@ -0,0 +1,936 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/auth"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	if err := validation.Register(); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// the routes under test served from repos to the signed in currentUser
+func newTestRouter(repos repositories.Repositories, currentUser user.User) *gin.Engine {
+	router := gin.New()
+	router.Use(apierrors.Handler(), BindObjectIds(), Repositories(repos), func(c *gin.Context) {
+		c.Set(auth.UserKey, currentUser)
+	})
+	router.POST("/aircraft", CreateAircraft)
+	router.PUT("/users/:id", RequireSelf(), UpdateUser)
+	router.DELETE("/users/:id", RequireSelf(), DeleteUser)
+	router.PUT("/users/:id/update_airlines", RequireSelf(), UpdateUserAirlines)
+	router.PUT("/aircraft/:id/update_airframe", RequireAircraftOwner(), UpdateAirframe)
+	router.PUT("/aircraft/:id/update_general", RequireAircraftOwner(), UpdateGeneral)
+	router.PUT("/aircraft/:id/update_tags", RequireAircraftOwner(), UpdateTags)
+	router.PUT("/aircraft/:id/update_engines", RequireAircraftOwner(), UpdateEngines)
+	router.POST("/aircraft/:id/service_apu", RequireAircraftOwner(), ServiceAPU)
+	router.GET("/aircraft/:id/get_airline", GetAirlineData)
+	router.DELETE("/airlines/:id", RequireAirlineOwner(), DeleteAirline)
+	router.PUT("/airlines/:id/update_fleet", RequireAirlineOwner(), UpdateFleet)
+	router.PUT("/airlines/:id/update_owner", RequireAdmin(), UpdateAirlineOwner)
+	router.GET("/engines/due", GetDueEngines)
+	router.GET("/airports", GetAirports)
+	router.POST("/flights", CreateFlight)
+	router.POST("/marketplace/:id/buy", BuyAircraft)
+	return router
+}
+
+func perform(router *gin.Engine, method string, path string, body interface{}) *httptest.ResponseRecorder {
+	var data []byte
+	if body != nil {
+		data, _ = json.Marshal(body)
+	}
+	request := httptest.NewRequest(method, path, bytes.NewReader(data))
+	request.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func float64Ptr(x float64) *float64 {
+	return &x
+}
+
+func uint16Ptr(x uint16) *uint16 {
+	return &x
+}
+
+type fixture struct {
+	repos    repositories.Repositories
+	owner    user.User
+	other    user.User
+	airplane aircraft.Aircraft
+	airline  airline.Airline
+}
+
+// an owner with one airline and one aircraft that is not in its fleet yet, and another user
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	ctx := context.Background()
+	f := fixture{repos: repositories.NewMemoryRepositories()}
+	f.owner = user.User{ID: primitive.NewObjectID(), Name: "owner", Airlines: []primitive.ObjectID{}}
+	f.other = user.User{ID: primitive.NewObjectID(), Name: "other", Airlines: []primitive.ObjectID{}}
+	f.airline = airline.Airline{
+		ID:      primitive.NewObjectID(),
+		General: &airline.General{Name: "Example Air"},
+		Fleet:   []primitive.ObjectID{},
+		Reviews: []primitive.ObjectID{},
+		Routes:  []primitive.ObjectID{},
+		Owner:   f.owner.ID}
+	f.owner.Airlines = append(f.owner.Airlines, f.airline.ID)
+	f.airplane = aircraft.Aircraft{
+		ID:       primitive.NewObjectID(),
+		General:  &aircraft.General{Name: "Example", Model: "A320", History: []primitive.ObjectID{}},
+		Airframe: &aircraft.Airframe{TotalTime: float64Ptr(1200)},
+		APU:      &aircraft.APU{TotalTime: float64Ptr(300), SinceMaintenance: float64Ptr(40)},
+		Engines:  []primitive.ObjectID{},
+		Owner:    f.owner.ID,
+		Tags:     []string{}}
+	for _, err := range []error{
+		f.repos.Users.Insert(ctx, f.owner),
+		f.repos.Users.Insert(ctx, f.other),
+		f.repos.Airlines.Insert(ctx, f.airline),
+		f.repos.Aircraft.Insert(ctx, f.airplane)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func (f fixture) aircraft(t *testing.T) aircraft.Aircraft {
+	t.Helper()
+	airplane, err := f.repos.Aircraft.FindById(context.Background(), f.airplane.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return airplane
+}
+
+func TestUpdateAirframe(t *testing.T) {
+	f := newFixture(t)
+	path := "/aircraft/" + f.airplane.ID.Hex() + "/update_airframe"
+	tests := []struct {
+		name      string
+		as        user.User
+		status    int
+		totalTime float64
+	}{
+		{"someone else", f.other, http.StatusForbidden, 1200},
+		{"owner", f.owner, http.StatusOK, 1500},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := perform(newTestRouter(f.repos, test.as), http.MethodPut, path, gin.H{"totalTime": 1500})
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			airplane := f.aircraft(t)
+			if *airplane.Airframe.TotalTime != test.totalTime {
+				t.Errorf("total time %v, want %v", *airplane.Airframe.TotalTime, test.totalTime)
+			}
+			// fields missing from the request keep their values
+			if airplane.General.Name != "Example" {
+				t.Errorf("name %q, want it untouched", airplane.General.Name)
+			}
+		})
+	}
+}
+
+func TestUpdateGeneralKeepsAircraftInCheckOutOfService(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	err := f.repos.Aircraft.Update(ctx, f.airplane.ID, bson.M{
+		"airframe.check":      aircraft.ScheduledCheck{Type: "A", Started: true},
+		"general.isOperating": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(f.repos, f.owner)
+	recorder := perform(router, http.MethodPut, "/aircraft/"+f.airplane.ID.Hex()+"/update_general",
+		gin.H{"name": "Renamed", "isOperating": true})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	airplane := f.aircraft(t)
+	if airplane.General.Name != "Renamed" {
+		t.Errorf("name %q, want Renamed", airplane.General.Name)
+	}
+	if airplane.General.IsOperating == nil || *airplane.General.IsOperating {
+		t.Errorf("an aircraft in a started check went back into service")
+	}
+}
+
+func TestUpdateTagsToggles(t *testing.T) {
+	f := newFixture(t)
+	router := newTestRouter(f.repos, f.owner)
+	path := "/aircraft/" + f.airplane.ID.Hex() + "/update_tags"
+	steps := []struct {
+		tags []string
+		want []string
+	}{
+		{[]string{"cargo", "vip"}, []string{"cargo", "vip"}},
+		{[]string{"charter"}, []string{"cargo", "vip", "charter"}},
+		// the first tag is there, so the tags are removed
+		{[]string{"cargo", "charter"}, []string{"vip"}},
+	}
+	for i, step := range steps {
+		recorder := perform(router, http.MethodPut, path, gin.H{"tags": step.tags})
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("step %d: status %d: %s", i, recorder.Code, recorder.Body)
+		}
+		got := f.aircraft(t).Tags
+		if len(got) != len(step.want) {
+			t.Fatalf("step %d: tags %v, want %v", i, got, step.want)
+		}
+		for j := range got {
+			if got[j] != step.want[j] {
+				t.Fatalf("step %d: tags %v, want %v", i, got, step.want)
+			}
+		}
+	}
+}
+
+func TestServiceAPU(t *testing.T) {
+	f := newFixture(t)
+	router := newTestRouter(f.repos, f.owner)
+	recorder := perform(router, http.MethodPost, "/aircraft/"+f.airplane.ID.Hex()+"/service_apu", gin.H{})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	apu := f.aircraft(t).APU
+	if apu.SinceMaintenance == nil || *apu.SinceMaintenance != 0 {
+		t.Errorf("since maintenance %v, want 0", apu.SinceMaintenance)
+	}
+	if len(apu.MaintenanceLog) != 1 {
+		t.Errorf("maintenance log %v, want one service", apu.MaintenanceLog)
+	}
+}
+
+func TestUpdateFleetAddsAndRemoves(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	path := "/airlines/" + f.airline.ID.Hex() + "/update_fleet"
+	body := gin.H{"fleet": []primitive.ObjectID{f.airplane.ID}}
+
+	recorder := perform(newTestRouter(f.repos, f.other), http.MethodPut, path, body)
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("someone else: status %d, want 403", recorder.Code)
+	}
+
+	router := newTestRouter(f.repos, f.owner)
+	for i, want := range []int{1, 0} {
+		recorder = perform(router, http.MethodPut, path, body)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("pass %d: status %d: %s", i, recorder.Code, recorder.Body)
+		}
+		airlineData, err := f.repos.Airlines.FindById(ctx, f.airline.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(airlineData.Fleet) != want {
+			t.Errorf("pass %d: fleet %v, want %d aircraft", i, airlineData.Fleet, want)
+		}
+		if history := f.aircraft(t).General.History; len(history) != want {
+			t.Errorf("pass %d: history %v, want %d airlines", i, history, want)
+		}
+	}
+}
+
+func TestDeleteAirlineCleansReferences(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	router := newTestRouter(f.repos, f.owner)
+	recorder := perform(router, http.MethodPut, "/airlines/"+f.airline.ID.Hex()+"/update_fleet",
+		gin.H{"fleet": []primitive.ObjectID{f.airplane.ID}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("update fleet: status %d: %s", recorder.Code, recorder.Body)
+	}
+	recorder = perform(router, http.MethodDelete, "/airlines/"+f.airline.ID.Hex(), nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	if _, err := f.repos.Airlines.FindById(ctx, f.airline.ID); err != repositories.ErrNotFound {
+		t.Errorf("the airline is still there: %v", err)
+	}
+	owner, err := f.repos.Users.FindById(ctx, f.owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owner.Airlines) != 0 {
+		t.Errorf("owner airlines %v, want none", owner.Airlines)
+	}
+	if history := f.aircraft(t).General.History; len(history) != 0 {
+		t.Errorf("aircraft history %v, want none", history)
+	}
+}
+
+func TestGetAirlineDataOnCacheMiss(t *testing.T) {
+	f := newFixture(t)
+	router := newTestRouter(f.repos, f.owner)
+	path := "/aircraft/" + f.airplane.ID.Hex() + "/get_airline"
+	// the test cache never holds anything so both lookups go to the repositories
+	if recorder := perform(router, http.MethodGet, path, nil); recorder.Code != http.StatusNotFound {
+		t.Errorf("without an operator: status %d, want %d: %s", recorder.Code, http.StatusNotFound, recorder.Body)
+	}
+	recorder := perform(router, http.MethodPut, "/airlines/"+f.airline.ID.Hex()+"/update_fleet",
+		gin.H{"fleet": []primitive.ObjectID{f.airplane.ID}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("update fleet: status %d: %s", recorder.Code, recorder.Body)
+	}
+	recorder = perform(router, http.MethodGet, path, nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	var got airline.Airline
+	if err := json.Unmarshal(recorder.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != f.airline.ID {
+		t.Errorf("airline %s, want %s", got.ID.Hex(), f.airline.ID.Hex())
+	}
+}
+
+func TestUpdateUserNameTaken(t *testing.T) {
+	f := newFixture(t)
+	router := newTestRouter(f.repos, f.owner)
+	tests := []struct {
+		name   string
+		body   gin.H
+		status int
+	}{
+		{"taken", gin.H{"name": "other"}, http.StatusConflict},
+		{"free", gin.H{"name": "renamed"}, http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := perform(router, http.MethodPut, "/users/"+f.owner.ID.Hex(), test.body)
+			if recorder.Code != test.status {
+				t.Errorf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+		})
+	}
+	// someone else's account
+	recorder := perform(router, http.MethodPut, "/users/"+f.other.ID.Hex(), gin.H{"email": "x@example.com"})
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("status %d, want 403", recorder.Code)
+	}
+}
+
+func TestGetDueEngines(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	engines := []aircraft.Engine{
+		// 100 hours to the overhaul
+		{ID: primitive.NewObjectID(), Model: "CFM56", TBO: uint16Ptr(3000), SinceOverhaul: float64Ptr(2900)},
+		// overdue hot section
+		{ID: primitive.NewObjectID(), Model: "CFM56", HST: uint16Ptr(1500), TotalTime: float64Ptr(1600)},
+		// far from both
+		{ID: primitive.NewObjectID(), Model: "CFM56", TBO: uint16Ptr(3000), HST: uint16Ptr(1500), SinceOverhaul: float64Ptr(10)},
+		// not tracked
+		{ID: primitive.NewObjectID(), Model: "PW127", TotalTime: float64Ptr(90000)},
+	}
+	for _, x := range engines {
+		if err := f.repos.Engines.Insert(ctx, x); err != nil {
+			t.Fatal(err)
+		}
+	}
+	router := newTestRouter(f.repos, f.owner)
+	tests := []struct {
+		threshold string
+		want      int
+	}{
+		{"0", 1},
+		{"150", 2},
+		{"5000", 3},
+	}
+	for _, test := range tests {
+		t.Run(test.threshold, func(t *testing.T) {
+			recorder := perform(router, http.MethodGet, "/engines/due?threshold="+test.threshold, nil)
+			if recorder.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+			}
+			var due []aircraft.Engine
+			if err := json.Unmarshal(recorder.Body.Bytes(), &due); err != nil {
+				t.Fatal(err)
+			}
+			if len(due) != test.want {
+				t.Errorf("%d engines due, want %d", len(due), test.want)
+			}
+			for _, x := range due {
+				if x.TimeToOverhaul == nil && x.TimeToHotSection == nil {
+					t.Errorf("engine %s has no remaining hours", x.ID.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestGetAirports(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	airports := []airport.Airport{
+		{ICAO: "EGLL", IATA: "LHR", Name: "London Heathrow Airport", Country: "GB"},
+		{ICAO: "EGKK", IATA: "LGW", Name: "London Gatwick Airport", Country: "GB"},
+		{ICAO: "LFPG", IATA: "CDG", Name: "Charles de Gaulle International Airport", Country: "FR"},
+	}
+	upserts := make([]repositories.Upsert, len(airports))
+	for i, x := range airports {
+		upserts[i] = repositories.Upsert{
+			Filter: bson.M{"icao": x.ICAO},
+			Change: repositories.Change{Set: bson.M{"iata": x.IATA, "name": x.Name, "country": x.Country}}}
+	}
+	if _, _, err := f.repos.Airports.UpsertMany(ctx, upserts); err != nil {
+		t.Fatal(err)
+	}
+	router := newTestRouter(f.repos, f.owner)
+	tests := []struct {
+		query  string
+		status int
+		want   []string
+	}{
+		{"icao=egll", http.StatusOK, []string{"EGLL"}},
+		{"country=gb", http.StatusOK, []string{"EGKK", "EGLL"}},
+		{"name=london", http.StatusOK, []string{"EGKK", "EGLL"}},
+		{"name=gaulle&country=FR", http.StatusOK, []string{"LFPG"}},
+		// regular expression characters are matched literally
+		{"name=.*", http.StatusOK, []string{}},
+		{"", http.StatusBadRequest, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			recorder := perform(router, http.MethodGet, "/airports?"+test.query, nil)
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			if test.want == nil {
+				return
+			}
+			var found []airport.Airport
+			if err := json.Unmarshal(recorder.Body.Bytes(), &found); err != nil {
+				t.Fatal(err)
+			}
+			if len(found) != len(test.want) {
+				t.Fatalf("found %d airports, want %v", len(found), test.want)
+			}
+			// sorted by name
+			for i, x := range found {
+				if x.ICAO != test.want[i] {
+					t.Errorf("airport %d is %s, want %s", i, x.ICAO, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuyAircraft(t *testing.T) {
+	tests := []struct {
+		name          string
+		balance       int
+		status        int
+		owner         string
+		buyerBalance  int
+		sellerBalance int
+		fleet         int
+		buyerFleet    int
+		entries       int
+	}{
+		// the aircraft changes hands before the buyer is debited, so this undoes it
+		{"insufficient funds", 100, http.StatusPaymentRequired, "owner", 100, 0, 1, 0, 0},
+		{"bought", 1000, http.StatusOK, "other", 500, 500, 0, 1, 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			// without an airline in the request the aircraft joins the first airline of the buyer
+			buyerAirline := airline.Airline{
+				ID:      primitive.NewObjectID(),
+				General: &airline.General{Name: "Other Air"},
+				Fleet:   []primitive.ObjectID{},
+				Reviews: []primitive.ObjectID{},
+				Routes:  []primitive.ObjectID{},
+				Owner:   f.other.ID}
+			f.other.Airlines = append(f.other.Airlines, buyerAirline.ID)
+			for _, err := range []error{
+				f.repos.Airlines.Insert(ctx, buyerAirline),
+				f.repos.Aircraft.Update(ctx, f.airplane.ID, bson.M{"general.forSale": true, "general.price": 500}),
+				f.repos.Airlines.AddToSet(ctx, f.airline.ID, "fleet", f.airplane.ID),
+				f.repos.Users.Update(ctx, f.owner.ID, bson.M{"balance": 0}),
+				f.repos.Users.Update(ctx, f.other.ID, bson.M{"balance": test.balance, "airlines": f.other.Airlines})} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			recorder := perform(newTestRouter(f.repos, f.other), http.MethodPost, "/marketplace/"+f.airplane.ID.Hex()+"/buy", nil)
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			owners := map[string]primitive.ObjectID{"owner": f.owner.ID, "other": f.other.ID}
+			airplane := f.aircraft(t)
+			if airplane.Owner != owners[test.owner] {
+				t.Errorf("owner %s, want the %s", airplane.Owner.Hex(), test.owner)
+			}
+			balances := []struct {
+				name string
+				id   primitive.ObjectID
+				want int
+			}{
+				{"buyer", f.other.ID, test.buyerBalance},
+				{"seller", f.owner.ID, test.sellerBalance},
+			}
+			for _, x := range balances {
+				u, err := f.repos.Users.FindById(ctx, x.id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if u.Balance == nil || *u.Balance != x.want {
+					t.Errorf("%s balance %v, want %d", x.name, u.Balance, x.want)
+				}
+			}
+			fleets := []struct {
+				name string
+				id   primitive.ObjectID
+				want int
+			}{
+				{"seller", f.airline.ID, test.fleet},
+				{"buyer", buyerAirline.ID, test.buyerFleet},
+			}
+			for _, x := range fleets {
+				a, err := f.repos.Airlines.FindById(ctx, x.id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if len(a.Fleet) != x.want {
+					t.Errorf("%s fleet %v, want %d aircraft", x.name, a.Fleet, x.want)
+				}
+			}
+			// the legs of the purchase balance out
+			entries, _, err := f.repos.Ledger.List(ctx, repositories.Query{Conditions: []repositories.Condition{
+				{Field: "reference", Operator: repositories.Eq, Values: []interface{}{f.airplane.ID}}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != test.entries {
+				t.Errorf("%d ledger entries, want %d", len(entries), test.entries)
+			}
+			sum := 0
+			for _, x := range entries {
+				if x.Type != ledger.AircraftPurchase {
+					t.Errorf("entry type %q, want %q", x.Type, ledger.AircraftPurchase)
+				}
+				sum += x.Amount
+			}
+			if sum != 0 {
+				t.Errorf("ledger entries sum up to %d, want 0", sum)
+			}
+		})
+	}
+}
+
+// an aircraft of the other user in the fixture
+func (f fixture) otherAircraft(t *testing.T) aircraft.Aircraft {
+	t.Helper()
+	airplane := aircraft.Aircraft{
+		ID:      primitive.NewObjectID(),
+		General: &aircraft.General{Name: "Other", Model: "A320", History: []primitive.ObjectID{}},
+		Engines: []primitive.ObjectID{},
+		Owner:   f.other.ID,
+		Tags:    []string{}}
+	if err := f.repos.Aircraft.Insert(context.Background(), airplane); err != nil {
+		t.Fatal(err)
+	}
+	return airplane
+}
+
+func TestUpdateEnginesTakesOnlyOwnEngines(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	engine := aircraft.Engine{ID: primitive.NewObjectID(), Model: "CFM56", OwningAircraft: f.airplane.ID}
+	for _, err := range []error{
+		f.repos.Engines.Insert(ctx, engine),
+		f.repos.Aircraft.AddToSet(ctx, f.airplane.ID, "engines", engine.ID)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	theirs := f.otherAircraft(t)
+	recorder := perform(newTestRouter(f.repos, f.other), http.MethodPut, "/aircraft/"+theirs.ID.Hex()+"/update_engines",
+		gin.H{"engines": []primitive.ObjectID{engine.ID}})
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403: %s", recorder.Code, recorder.Body)
+	}
+	if engines := f.aircraft(t).Engines; len(engines) != 1 || engines[0] != engine.ID {
+		t.Errorf("engines %v, want the engine still on the aircraft of its owner", engines)
+	}
+	if got, err := f.repos.Engines.FindById(ctx, engine.ID); err != nil || got.OwningAircraft != f.airplane.ID {
+		t.Errorf("owning aircraft %s (%v), want %s", got.OwningAircraft.Hex(), err, f.airplane.ID.Hex())
+	}
+	if got, err := f.repos.Aircraft.FindById(ctx, theirs.ID); err != nil || len(got.Engines) != 0 {
+		t.Errorf("engines %v (%v), want none on the other aircraft", got.Engines, err)
+	}
+}
+
+// an aircraft already in the fleet does not make the others a removal
+func TestUpdateFleetChecksEveryNewAircraft(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	router := newTestRouter(f.repos, f.owner)
+	path := "/airlines/" + f.airline.ID.Hex() + "/update_fleet"
+	recorder := perform(router, http.MethodPut, path, gin.H{"fleet": []primitive.ObjectID{f.airplane.ID}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	theirs := f.otherAircraft(t)
+	recorder = perform(router, http.MethodPut, path, gin.H{"fleet": []primitive.ObjectID{f.airplane.ID, theirs.ID}})
+	if recorder.Code != http.StatusForbidden {
+		t.Fatalf("status %d, want 403: %s", recorder.Code, recorder.Body)
+	}
+	airlineData, err := f.repos.Airlines.FindById(ctx, f.airline.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(airlineData.Fleet) != 1 || airlineData.Fleet[0] != f.airplane.ID {
+		t.Errorf("fleet %v, want only %s", airlineData.Fleet, f.airplane.ID.Hex())
+	}
+	if got, err := f.repos.Aircraft.FindById(ctx, theirs.ID); err != nil || len(got.General.History) != 0 {
+		t.Errorf("history %v (%v), want the other aircraft untouched", got.General.History, err)
+	}
+}
+
+// the logs of a created aircraft take the entries of its maintenance
+func TestCreatedAircraftLogsMaintenance(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	router := newTestRouter(f.repos, f.owner)
+	recorder := perform(router, http.MethodPost, "/aircraft", gin.H{
+		"general":  gin.H{"name": "New", "model": "A320", "registration": "N320XA"},
+		"airframe": gin.H{"totalTime": 100},
+		"apu":      gin.H{"totalTime": 50}})
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	var created aircraft.Aircraft
+	if err := json.Unmarshal(recorder.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	err := f.repos.Aircraft.Update(ctx, created.ID, bson.M{
+		"airframe.check":      aircraft.ScheduledCheck{Type: "A", Start: now.Add(-2 * time.Hour), End: now.Add(-time.Hour), Started: true},
+		"general.isOperating": false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	changed, err := maintenance.SweepChecks(ctx, f.repos.Aircraft, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changed) != 1 {
+		t.Fatalf("changed %v, want the created aircraft", changed)
+	}
+	released, err := f.repos.Aircraft.FindById(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(released.Airframe.MaintenanceLog) != 1 || released.Airframe.Check != nil {
+		t.Errorf("airframe %+v, want the finished check in the log", released.Airframe)
+	}
+	recorder = perform(router, http.MethodPost, "/aircraft/"+created.ID.Hex()+"/service_apu", nil)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", recorder.Code, recorder.Body)
+	}
+	serviced, err := f.repos.Aircraft.FindById(ctx, created.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(serviced.APU.MaintenanceLog) != 1 {
+		t.Errorf("APU log %v, want the service", serviced.APU.MaintenanceLog)
+	}
+}
+
+// routes that cannot take flights
+type failingRoutes struct {
+	repositories.RouteRepository
+}
+
+func (failingRoutes) AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return errors.New("no route updates")
+}
+
+func TestCreateFlightIsUndoneOnFailure(t *testing.T) {
+	tests := []struct {
+		name    string
+		broken  bool
+		status  int
+		flights int
+	}{
+		{"scheduled", false, http.StatusOK, 1},
+		// the flight and the aircraft history are written before the route update fails
+		{"route fails", true, http.StatusInternalServerError, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			upserts := []repositories.Upsert{
+				{Filter: bson.M{"icao": "EGLL"}, Change: repositories.Change{Set: bson.M{"latitude": 51.47, "longitude": -0.45}}},
+				{Filter: bson.M{"icao": "LFPG"}, Change: repositories.Change{Set: bson.M{"latitude": 49.01, "longitude": 2.55}}},
+			}
+			if _, _, err := f.repos.Airports.UpsertMany(ctx, upserts); err != nil {
+				t.Fatal(err)
+			}
+			departure, err := f.repos.Airports.FindOne(ctx, bson.M{"icao": "EGLL"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			arrival, err := f.repos.Airports.FindOne(ctx, bson.M{"icao": "LFPG"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			route := airline.Route{ID: primitive.NewObjectID(), From: departure.ID, To: arrival.ID, Airline: f.airline.ID}
+			for _, err := range []error{
+				f.repos.Routes.Insert(ctx, route),
+				f.repos.Aircraft.Update(ctx, f.airplane.ID, bson.M{"performance.cruiseSpeed": 450}),
+				f.repos.Airlines.AddToSet(ctx, f.airline.ID, "fleet", f.airplane.ID)} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.broken {
+				f.repos.Routes = failingRoutes{f.repos.Routes}
+			}
+			recorder := perform(newTestRouter(f.repos, f.owner), http.MethodPost, "/flights", gin.H{
+				"airline":   f.airline.ID,
+				"aircraft":  f.airplane.ID,
+				"departure": departure.ID,
+				"arrival":   arrival.ID,
+				"route":     route.ID})
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			flights, _, err := f.repos.Flights.List(ctx, repositories.Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(flights) != test.flights {
+				t.Errorf("%d flights, want %d", len(flights), test.flights)
+			}
+			var history []primitive.ObjectID
+			if tracker := f.aircraft(t).TrackerData; tracker != nil {
+				history = tracker.FlightHistory
+			}
+			if len(history) != test.flights {
+				t.Errorf("flight history %v, want %d flights", history, test.flights)
+			}
+		})
+	}
+}
+
+// users that cannot take airlines
+type failingUsers struct {
+	repositories.UserRepository
+}
+
+func (failingUsers) AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return errors.New("no user updates")
+}
+
+// the owner of the fixture airline and the airlines of both users
+func (f fixture) ownership(t *testing.T) (primitive.ObjectID, []primitive.ObjectID, []primitive.ObjectID) {
+	t.Helper()
+	ctx := context.Background()
+	airlineData, err := f.repos.Airlines.FindById(ctx, f.airline.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner, err := f.repos.Users.FindById(ctx, f.owner.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := f.repos.Users.FindById(ctx, f.other.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return airlineData.Owner, owner.Airlines, other.Airlines
+}
+
+func TestUpdateAirlineOwner(t *testing.T) {
+	tests := []struct {
+		name   string
+		broken bool
+		status int
+		owner  string
+	}{
+		{"moved", false, http.StatusOK, "other"},
+		// the airline leaves its owner before the new owner fails to take it
+		{"new owner fails", true, http.StatusInternalServerError, "owner"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			if test.broken {
+				f.repos.Users = failingUsers{f.repos.Users}
+			}
+			admin := user.User{ID: primitive.NewObjectID(), IsAdmin: new(bool)}
+			*admin.IsAdmin = true
+			recorder := perform(newTestRouter(f.repos, admin), http.MethodPut, "/airlines/"+f.airline.ID.Hex()+"/update_owner",
+				gin.H{"owner": f.other.ID})
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			owners := map[string]primitive.ObjectID{"owner": f.owner.ID, "other": f.other.ID}
+			want := map[string][]primitive.ObjectID{"owner": {}, "other": {}}
+			want[test.owner] = []primitive.ObjectID{f.airline.ID}
+			owner, ownerAirlines, otherAirlines := f.ownership(t)
+			if owner != owners[test.owner] {
+				t.Errorf("airline owner %s, want the %s", owner.Hex(), test.owner)
+			}
+			if len(ownerAirlines) != len(want["owner"]) || len(otherAirlines) != len(want["other"]) {
+				t.Errorf("airlines of the owner %v and the other user %v, want %v and %v",
+					ownerAirlines, otherAirlines, want["owner"], want["other"])
+			}
+		})
+	}
+}
+
+func TestUpdateUserAirlines(t *testing.T) {
+	f := newFixture(t)
+	admin := user.User{ID: primitive.NewObjectID(), IsAdmin: new(bool)}
+	*admin.IsAdmin = true
+	body := gin.H{"airlines": []primitive.ObjectID{f.airline.ID}}
+	steps := []struct {
+		name          string
+		as            user.User
+		user          primitive.ObjectID
+		owner         primitive.ObjectID
+		ownerAirlines int
+		otherAirlines int
+	}{
+		{"given away", f.owner, f.owner.ID, primitive.NilObjectID, 0, 0},
+		{"assigned by an admin", admin, f.other.ID, f.other.ID, 0, 1},
+		{"moved by an admin", admin, f.owner.ID, f.owner.ID, 1, 0},
+	}
+	for _, step := range steps {
+		recorder := perform(newTestRouter(f.repos, step.as), http.MethodPut, "/users/"+step.user.Hex()+"/update_airlines", body)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", step.name, recorder.Code, recorder.Body)
+		}
+		owner, ownerAirlines, otherAirlines := f.ownership(t)
+		if owner != step.owner {
+			t.Errorf("%s: airline owner %s, want %s", step.name, owner.Hex(), step.owner.Hex())
+		}
+		if len(ownerAirlines) != step.ownerAirlines || len(otherAirlines) != step.otherAirlines {
+			t.Errorf("%s: airlines of the owner %v and the other user %v, want %d and %d",
+				step.name, ownerAirlines, otherAirlines, step.ownerAirlines, step.otherAirlines)
+		}
+	}
+}
+
+// users that cannot be deleted
+type undeletableUsers struct {
+	repositories.UserRepository
+}
+
+func (undeletableUsers) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return errors.New("no user deletes")
+}
+
+func TestDeleteClosesBalances(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   func(f fixture) string
+		broken bool
+		status int
+		// what the system account takes over
+		closed int
+	}{
+		{"airline", func(f fixture) string { return "/airlines/" + f.airline.ID.Hex() }, false, http.StatusOK, 300},
+		{"user and airline", func(f fixture) string { return "/users/" + f.owner.ID.Hex() }, false, http.StatusOK, 500},
+		// the airline is deleted and its balance closed before the user delete fails
+		{"user delete fails", func(f fixture) string { return "/users/" + f.owner.ID.Hex() }, true, http.StatusInternalServerError, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := newFixture(t)
+			ctx := context.Background()
+			for _, err := range []error{
+				f.repos.Users.Update(ctx, f.owner.ID, bson.M{"balance": 200}),
+				f.repos.Airlines.Update(ctx, f.airline.ID, bson.M{"balance": 300})} {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if test.broken {
+				f.repos.Users = undeletableUsers{f.repos.Users}
+			}
+			recorder := perform(newTestRouter(f.repos, f.owner), http.MethodDelete, test.path(f), nil)
+			if recorder.Code != test.status {
+				t.Fatalf("status %d, want %d: %s", recorder.Code, test.status, recorder.Body)
+			}
+			closed, err := f.repos.Ledger.Balance(ctx, ledger.Account{Type: ledger.System})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if closed != test.closed {
+				t.Errorf("system account took over %d, want %d", closed, test.closed)
+			}
+			entries, _, err := f.repos.Ledger.List(ctx, repositories.Query{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			sum := 0
+			for _, x := range entries {
+				if x.Type != ledger.Closing {
+					t.Errorf("entry type %q, want %q", x.Type, ledger.Closing)
+				}
+				sum += x.Amount
+			}
+			if sum != 0 {
+				t.Errorf("ledger entries sum up to %d, want 0", sum)
+			}
+			if !test.broken {
+				return
+			}
+			// undone, both accounts keep their balance
+			owner, err := f.repos.Users.FindById(ctx, f.owner.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			airlineData, err := f.repos.Airlines.FindById(ctx, f.airline.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if owner.Balance == nil || *owner.Balance != 200 {
+				t.Errorf("user balance %v, want 200", owner.Balance)
+			}
+			if airlineData.Balance == nil || *airlineData.Balance != 300 {
+				t.Errorf("airline balance %v, want 300", airlineData.Balance)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/services/health"
+	"github.com/gin-gonic/gin"
+)
+
+// the dependencies readiness pings, injected at startup
+var healthChecks []health.Check
+
+func UseHealthChecks(checks ...health.Check) {
+	healthChecks = checks
+}
+
+// liveness: the process serves requests, dependencies are not pinged
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, health.Report{Status: health.OK})
+}
+
+// readiness: every dependency answers with its latency, 503 when one does not
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 2*time.Second)
+	defer cancel()
+	report := health.Run(ctx, healthChecks)
+	status := http.StatusOK
+	if report.Status != health.OK {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
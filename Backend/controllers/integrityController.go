@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/services/integrity"
+	"github.com/gin-gonic/gin"
+)
+
+// reports dangling and asymmetric references between users, aircraft, engines and airlines
+func CheckIntegrity(c *gin.Context) {
+	runIntegrityCheck(c, false)
+}
+
+// repairs the references CheckIntegrity reports
+func FixIntegrity(c *gin.Context) {
+	runIntegrityCheck(c, true)
+}
+
+func runIntegrityCheck(c *gin.Context, fix bool) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report, err := integrity.Check(ctx, repos, fix)
+	if fix {
+		for entity, ids := range report.Documents() {
+			invalidate(entity, ids...)
+		}
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
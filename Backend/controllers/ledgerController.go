@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// parses a RFC 3339 timestamp or a date, a date as the upper bound includes the whole day
+func parseLedgerTime(value string, upper bool) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse("2006-01-02", value)
+	if err != nil {
+		return t, errors.New("Dates must be YYYY-MM-DD or RFC 3339 timestamps")
+	}
+	if upper {
+		t = t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t, nil
+}
+
+// the balance of an account and its entries
+type ledgerStatement struct {
+	Balance int            `json:"balance"`
+	Entries []ledger.Entry `json:"entries"`
+}
+
+type balanceAdjustment struct {
+	Amount      int    `json:"amount" binding:"required"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+// adds the transfer of the remaining balance of an account about to be deleted to the system account,
+// false when there is nothing to close
+func addClosingTransfer(work *services.UnitOfWork, repos repositories.Repositories, account ledger.Account, balance *int) bool {
+	if balance == nil || *balance == 0 {
+		return false
+	}
+	services.GetLedgerService().AddTransfer(work, repos, ledger.Transfer{
+		From:   account,
+		To:     ledger.Account{Type: ledger.System},
+		Amount: *balance,
+		Type:   ledger.Closing})
+	return true
+}
+
+// writes the entries of an account filtered by the from and to query params as JSON or CSV
+func getLedger(ctx context.Context, c *gin.Context, account ledger.Account) {
+	conditions := []repositories.Condition{
+		{Field: "accountType", Operator: repositories.Eq, Values: []interface{}{account.Type}},
+		// entries of the system account have no account id
+		{Field: "account", Operator: repositories.Exists, Values: []interface{}{false}}}
+	if account.ID != primitive.NilObjectID {
+		conditions[1] = repositories.Condition{Field: "account", Operator: repositories.Eq, Values: []interface{}{account.ID}}
+	}
+	for query, operator := range map[string]string{"from": repositories.Gte, "to": repositories.Lte} {
+		if value := c.Query(query); value != "" {
+			t, err := parseLedgerTime(value, query == "to")
+			if err != nil {
+				c.Error(apierrors.Validation(err.Error()))
+				return
+			}
+			conditions = append(conditions, repositories.Condition{Field: "createdAt", Operator: operator, Values: []interface{}{t}})
+		}
+	}
+	entries, _, err := getRepositories(c).Ledger.List(ctx, repositories.Query{
+		Conditions: conditions,
+		Sort:       []repositories.Sort{{Field: "createdAt"}, {Field: "_id"}}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if c.Query("format") == "csv" {
+		c.Header("Content-Type", "text/csv")
+		c.Header("Content-Disposition", "attachment; filename=ledger-"+account.ID.Hex()+".csv")
+		c.Status(http.StatusOK)
+		w := csv.NewWriter(c.Writer)
+		w.Write([]string{"createdAt", "type", "amount", "balance", "transaction", "reference", "description"})
+		for _, x := range entries {
+			reference := ""
+			if x.Reference != primitive.NilObjectID {
+				reference = x.Reference.Hex()
+			}
+			w.Write([]string{
+				x.CreatedAt.Format(time.RFC3339),
+				x.Type,
+				strconv.Itoa(x.Amount),
+				strconv.Itoa(x.Balance),
+				x.Transaction.Hex(),
+				reference,
+				x.Description})
+		}
+		w.Flush()
+		return
+	}
+	balance, err := getRepositories(c).Ledger.Balance(ctx, account)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ledgerStatement{Balance: balance, Entries: entries})
+}
+
+// posts an admin adjustment between the system and an account
+func adjustBalance(ctx context.Context, c *gin.Context, account ledger.Account) {
+	var adjustment balanceAdjustment
+	err := c.ShouldBindJSON(&adjustment)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	currentUser := getCurrentUser(c)
+	err = services.GetLedgerService().Transfer(ctx, getRepositories(c), ledger.Transfer{
+		From:        ledger.Account{Type: ledger.System},
+		To:          account,
+		Amount:      adjustment.Amount,
+		Type:        ledger.Adjustment,
+		Reference:   currentUser.ID,
+		Description: adjustment.Description})
+	if err == services.ErrNoAccount {
+		c.Error(apierrors.NotFound(err.Error()))
+		return
+	} else if err == services.ErrInsufficientFunds {
+		c.Error(apierrors.InsufficientFunds(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if account.Type == ledger.User {
+		invalidate("users", account.ID.Hex())
+	} else {
+		invalidate("airlines", account.ID.Hex())
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The balance has been adjusted"})
+}
+
+func GetUserLedger(c *gin.Context) {
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	getLedger(ctx, c, ledger.Account{Type: ledger.User, ID: objectId})
+}
+
+func AdjustUserBalance(c *gin.Context) {
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	adjustBalance(ctx, c, ledger.Account{Type: ledger.User, ID: objectId})
+}
+
+func GetAirlineLedger(c *gin.Context) {
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	getLedger(ctx, c, ledger.Account{Type: ledger.Airline, ID: objectId})
+}
+
+func AdjustAirlineBalance(c *gin.Context) {
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	adjustBalance(ctx, c, ledger.Account{Type: ledger.Airline, ID: objectId})
+}
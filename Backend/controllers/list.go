@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+// a (dotted) field with an optional operator, e.g. performance.range[gte]
+var filterPattern = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9_]*(?:\.[A-Za-z][A-Za-z0-9_]*)*)(?:\[(eq|ne|gt|gte|lt|lte|in)\])?$`)
+
+// reads a list query: limit, after (the next cursor of the previous page), sort (fields separated by commas,
+// descending with a leading "-") and every other parameter as a field filter like general.manufacturer=Boeing
+// or performance.range[gte]=3000, hidden fields can be neither filtered nor sorted by
+func parseListQuery(c *gin.Context, hidden ...string) (repositories.Query, error) {
+	query := repositories.Query{Limit: defaultPageSize, After: c.Query("after")}
+	if limit := c.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 || value > maxPageSize {
+			return query, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+		query.Limit = value
+	}
+	isHidden := func(field string) bool {
+		for _, x := range hidden {
+			if field == x || strings.HasPrefix(field, x+".") {
+				return true
+			}
+		}
+		return false
+	}
+	if sort := c.Query("sort"); sort != "" {
+		for _, x := range strings.Split(sort, ",") {
+			descending := strings.HasPrefix(x, "-")
+			field := strings.TrimPrefix(x, "-")
+			match := filterPattern.FindStringSubmatch(field)
+			if match == nil || match[2] != "" || isHidden(field) {
+				return query, errors.New("Cannot sort by " + field)
+			}
+			query.Sort = append(query.Sort, repositories.Sort{Field: documentField(field), Descending: descending})
+		}
+	}
+	for key, values := range c.Request.URL.Query() {
+		if key == "limit" || key == "after" || key == "sort" {
+			continue
+		}
+		match := filterPattern.FindStringSubmatch(key)
+		if match == nil || isHidden(match[1]) {
+			return query, errors.New("Cannot filter by " + key)
+		}
+		operator := match[2]
+		if operator == "" {
+			operator = repositories.Eq
+		}
+		for _, x := range values {
+			condition := repositories.Condition{Field: documentField(match[1]), Operator: operator}
+			switch operator {
+			case repositories.Eq, repositories.Ne:
+				condition.Values = filterValues(x)
+			case repositories.In:
+				for _, y := range strings.Split(x, ",") {
+					condition.Values = append(condition.Values, filterValues(y)...)
+				}
+			default:
+				candidates := filterValues(x)
+				condition.Values = candidates[len(candidates)-1:]
+			}
+			query.Conditions = append(query.Conditions, condition)
+		}
+	}
+	return query, nil
+}
+
+// ids are called id in responses and _id in documents
+func documentField(field string) string {
+	if field == "id" {
+		return "_id"
+	}
+	return field
+}
+
+// a query value as a string and, when it reads as one, as an id, a number, a bool or a time,
+// the typed value comes last and is the one range operators compare with
+func filterValues(raw string) []interface{} {
+	values := []interface{}{raw}
+	if id, err := primitive.ObjectIDFromHex(raw); err == nil {
+		return append(values, id)
+	}
+	if number, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return append(values, number)
+	}
+	if number, err := strconv.ParseFloat(raw, 64); err == nil {
+		return append(values, number)
+	}
+	if value, err := strconv.ParseBool(raw); err == nil {
+		return append(values, value)
+	}
+	if date, err := time.Parse(time.RFC3339, raw); err == nil {
+		return append(values, date)
+	}
+	return values
+}
+
+// the cache key of a page, the query is part of it
+func pageKey(entity string, c *gin.Context) string {
+	return cache.Key(entity, "list", c.Request.URL.Query().Encode())
+}
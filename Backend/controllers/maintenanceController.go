@@ -0,0 +1,246 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errInCheck = errors.New("The aircraft already has a check scheduled")
+
+// removes cached aircraft touched by the checks sweeper
+func ClearAircraftCache(aircraftIds []primitive.ObjectID) {
+	ids := make([]string, len(aircraftIds))
+	for i, x := range aircraftIds {
+		ids[i] = x.Hex()
+	}
+	invalidate("aircraft", ids...)
+}
+
+// returns nil when there is no program for the aircraft model
+func findCheckProgram(ctx context.Context, repos repositories.Repositories, airplane aircraft.Aircraft) (*aircraft.CheckProgram, error) {
+	if airplane.General == nil || airplane.General.Model == "" {
+		return nil, nil
+	}
+	program, err := repos.CheckPrograms.FindByModel(ctx, airplane.General.Model)
+	if err == repositories.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &program, nil
+}
+
+func GetCheckPrograms(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	programs, _, err := getRepositories(c).CheckPrograms.List(ctx, repositories.Query{Sort: []repositories.Sort{{Field: "model"}}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, programs)
+}
+
+// creates or replaces the check program of an aircraft model
+func UpdateCheckProgram(c *gin.Context) {
+	var program aircraft.CheckProgram
+	err := c.ShouldBindJSON(&program)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	program.Model = c.Param("model")
+	seen := make(map[string]bool)
+	for _, x := range program.Checks {
+		if !maintenance.IsCheck(x.Type) {
+			c.Error(apierrors.Validation("Checks must be A, B, C or D"))
+			return
+		}
+		if seen[x.Type] {
+			c.Error(apierrors.Validation("The program has the " + x.Type + " check twice"))
+			return
+		}
+		seen[x.Type] = true
+		if x.Hours == nil && x.Cycles == nil && x.Months == nil {
+			c.Error(apierrors.Validation("The " + x.Type + " check needs hours, cycles or months"))
+			return
+		}
+	}
+	if program.Checks == nil {
+		program.Checks = make([]aircraft.CheckInterval, 0)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	program, err = getRepositories(c).CheckPrograms.Upsert(ctx, program.Model, repositories.Change{
+		Set:         bson.M{"checks": program.Checks},
+		SetOnInsert: bson.M{"_id": primitive.NewObjectID()}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, program)
+}
+
+type aircraftChecks struct {
+	Checks  []maintenance.CheckStatus `json:"checks"`
+	Current *aircraft.ScheduledCheck  `json:"current"`
+}
+
+type scheduleCheckRequest struct {
+	Type  string     `json:"type"`
+	Start *time.Time `json:"start"`
+}
+
+// tells when the checks of an aircraft are due and which check it is in
+func GetAircraftChecks(c *gin.Context) {
+	repos := getRepositories(c)
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airplane, err := repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	program, err := findCheckProgram(ctx, repos, airplane)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if program == nil {
+		c.Error(apierrors.NotFound("No check program for the aircraft model"))
+		return
+	}
+	var current *aircraft.ScheduledCheck
+	if airplane.Airframe != nil {
+		current = airplane.Airframe.Check
+	}
+	c.JSON(http.StatusOK, aircraftChecks{
+		Checks:  maintenance.CheckStatuses(*program, airplane, time.Now().UTC()),
+		Current: current})
+}
+
+// schedules a check of the aircraft and charges its cost to the owner,
+// the aircraft is out of service from start until the check duration is over
+func ScheduleCheck(c *gin.Context) {
+	repos := getRepositories(c)
+	var request scheduleCheckRequest
+	err := c.ShouldBindJSON(&request)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var airplane aircraft.Aircraft
+	airplane, err = repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if airplane.Owner == primitive.NilObjectID {
+		c.Error(apierrors.Validation("The aircraft has no owner to charge"))
+		return
+	}
+	if airplane.Airframe != nil && airplane.Airframe.Check != nil {
+		c.Error(apierrors.Conflict(errInCheck.Error()))
+		return
+	}
+	program, err := findCheckProgram(ctx, repos, airplane)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	var interval *aircraft.CheckInterval
+	if program != nil {
+		for i, x := range program.Checks {
+			if x.Type == request.Type {
+				interval = &program.Checks[i]
+			}
+		}
+	}
+	if interval == nil {
+		c.Error(apierrors.Validation("The aircraft model has no " + request.Type + " check"))
+		return
+	}
+	now := time.Now().UTC()
+	start := now
+	if request.Start != nil && request.Start.After(now) {
+		start = request.Start.UTC()
+	}
+	check := aircraft.ScheduledCheck{
+		Type:    interval.Type,
+		Start:   start,
+		End:     start.Add(time.Duration(interval.Duration * float64(time.Hour))),
+		Cost:    interval.Cost,
+		Started: !start.After(now)}
+	set := bson.M{"airframe.check": check}
+	if check.Started {
+		set["general.isOperating"] = false
+	}
+	// undo takes the check off and puts the aircraft back in the service it was in
+	restore := repositories.Change{Unset: []string{"airframe.check"}}
+	if check.Started {
+		if airplane.General != nil && airplane.General.IsOperating != nil {
+			restore.Set = bson.M{"general.isOperating": *airplane.General.IsOperating}
+		} else {
+			restore.Unset = append(restore.Unset, "general.isOperating")
+		}
+	}
+	work := services.NewUnitOfWork("Schedule a check of aircraft " + objectId.Hex())
+	work.Add("schedule the "+check.Type+" check",
+		func(ctx context.Context) error {
+			err := repos.Aircraft.Apply(ctx, objectId,
+				[]repositories.Condition{{Field: "airframe.check", Operator: repositories.Exists, Values: []interface{}{false}}},
+				repositories.Change{Set: set})
+			if err == repositories.ErrNotFound {
+				return errInCheck
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			return repos.Aircraft.Apply(ctx, objectId, nil, restore)
+		})
+	services.GetLedgerService().AddTransfer(work, repos, ledger.Transfer{
+		From:        ledger.Account{Type: ledger.User, ID: airplane.Owner},
+		To:          ledger.Account{Type: ledger.System},
+		Amount:      check.Cost,
+		Type:        ledger.Maintenance,
+		Reference:   objectId,
+		Description: check.Type + " check"})
+	err = work.Run(ctx)
+	if err == errInCheck {
+		c.Error(apierrors.Conflict(err.Error()))
+		return
+	} else if err == services.ErrInsufficientFunds {
+		c.Error(apierrors.InsufficientFunds(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	ClearAircraftCache([]primitive.ObjectID{objectId})
+	invalidate("users", airplane.Owner.Hex())
+	c.JSON(http.StatusOK, check)
+}
@@ -0,0 +1,287 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errNotForSale = errors.New("The aircraft is not for sale")
+
+// the price of an aircraft in balance units
+func aircraftPrice(a aircraft.Aircraft) int {
+	if a.General == nil || a.General.Price == nil {
+		return 0
+	}
+	return int(math.Round(float64(*a.General.Price)))
+}
+
+// lists aircraft for sale filtered by price, manufacturer, model, condition and location
+func GetMarketplace(c *gin.Context) {
+	conditions := []repositories.Condition{{Field: "general.forSale", Operator: repositories.Eq, Values: []interface{}{true}}}
+	for query, operator := range map[string]string{"minPrice": repositories.Gte, "maxPrice": repositories.Lte} {
+		if value := c.Query(query); value != "" {
+			bound, err := strconv.ParseFloat(value, 32)
+			if err != nil {
+				c.Error(apierrors.Validation(query + " must be a number"))
+				return
+			}
+			conditions = append(conditions, repositories.Condition{Field: "general.price", Operator: operator, Values: []interface{}{bound}})
+		}
+	}
+	for query, field := range map[string]string{
+		"manufacturer": "general.manufacturer",
+		"model":        "general.model",
+		"condition":    "general.condition",
+		"location":     "general.location"} {
+		if value := c.Query(query); value != "" {
+			conditions = append(conditions, repositories.Condition{Field: field, Operator: repositories.Match, Values: []interface{}{"^" + regexp.QuoteMeta(value) + "$"}})
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	listings, _, err := getRepositories(c).Aircraft.List(ctx, repositories.Query{
+		Conditions: conditions,
+		Sort:       []repositories.Sort{{Field: "general.price"}}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, listings)
+}
+
+type saleListing struct {
+	Price *float32 `json:"price"`
+}
+
+func ListAircraftForSale(c *gin.Context) {
+	repos := getRepositories(c)
+	var listing saleListing
+	err := c.ShouldBindJSON(&listing)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	var airplane aircraft.Aircraft
+	airplane, err = repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if listing.Price == nil {
+		if airplane.General == nil || airplane.General.Price == nil {
+			c.Error(apierrors.Validation("The aircraft has no price"))
+			return
+		}
+		listing.Price = airplane.General.Price
+	}
+	if *listing.Price < 0 {
+		c.Error(apierrors.Validation("The price cannot be negative"))
+		return
+	}
+	err = repos.Aircraft.Update(ctx, objectId, bson.M{
+		"general.forSale": true,
+		"general.price":   listing.Price})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The aircraft has been listed for sale"})
+}
+
+func UnlistAircraft(c *gin.Context) {
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	err := getRepositories(c).Aircraft.Apply(ctx, objectId, nil, repositories.Change{Unset: []string{"general.forSale"}})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The aircraft has been removed from sale"})
+}
+
+// the airline the aircraft joins, the first airline of the buyer by default.
+// Buyers without airlines get the aircraft outside any fleet
+type purchaseRequest struct {
+	Airline primitive.ObjectID `json:"airline"`
+}
+
+type purchaseReceipt struct {
+	Message string `json:"message"`
+	Price   int    `json:"price"`
+}
+
+// buys a listed aircraft, the buyer is debited, the seller is credited and the aircraft
+// moves from the seller airlines to the buyer airline in one unit of work
+func BuyAircraft(c *gin.Context) {
+	repos := getRepositories(c)
+	var purchase purchaseRequest
+	err := c.ShouldBindJSON(&purchase)
+	if err != nil && err != io.EOF {
+		c.Error(validation.Error(err))
+		return
+	}
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	buyer := getCurrentUser(c)
+	var airplane aircraft.Aircraft
+	airplane, err = repos.Aircraft.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such aircraft"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if airplane.General == nil || airplane.General.ForSale == nil || !*airplane.General.ForSale {
+		c.Error(apierrors.Conflict(errNotForSale.Error()))
+		return
+	}
+	if airplane.Owner == buyer.ID {
+		c.Error(apierrors.Validation("You cannot buy your own aircraft"))
+		return
+	}
+	if purchase.Airline == primitive.NilObjectID && len(buyer.Airlines) > 0 {
+		purchase.Airline = buyer.Airlines[0]
+	}
+	if purchase.Airline != primitive.NilObjectID {
+		var buyerAirline airline.Airline
+		buyerAirline, err = repos.Airlines.FindById(ctx, purchase.Airline)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.Validation("No such airline"))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		if buyerAirline.Owner != buyer.ID {
+			c.Error(apierrors.Forbidden("The airline does not belong to you"))
+			return
+		}
+	}
+	price := aircraftPrice(airplane)
+	// the aircraft leaves every fleet it was part of
+	fleets, err := repos.Airlines.Find(ctx, bson.M{"fleet": objectId})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	sellerAirlines := make([]primitive.ObjectID, 0, len(fleets))
+	for _, x := range fleets {
+		sellerAirlines = append(sellerAirlines, x.ID)
+	}
+	// undo gives the aircraft back to the seller, listed as it was
+	restore := repositories.Change{Set: bson.M{"general.forSale": true}}
+	if airplane.Owner != primitive.NilObjectID {
+		restore.Set["owner"] = airplane.Owner
+	} else {
+		restore.Unset = []string{"owner"}
+	}
+	work := services.NewUnitOfWork("Buy aircraft " + id)
+	work.Add("transfer the aircraft to "+buyer.ID.Hex(),
+		func(ctx context.Context) error {
+			// the listing may have changed since it was read
+			err := repos.Aircraft.Apply(ctx, objectId, []repositories.Condition{
+				{Field: "owner", Operator: repositories.Eq, Values: []interface{}{airplane.Owner}},
+				{Field: "general.forSale", Operator: repositories.Eq, Values: []interface{}{true}},
+				{Field: "general.price", Operator: repositories.Eq, Values: []interface{}{airplane.General.Price}}},
+				repositories.Change{
+					Set:   bson.M{"owner": buyer.ID},
+					Unset: []string{"general.forSale"}})
+			if err == repositories.ErrNotFound {
+				return errNotForSale
+			}
+			return err
+		},
+		func(ctx context.Context) error {
+			return repos.Aircraft.Apply(ctx, objectId, nil, restore)
+		})
+	seller := ledger.Account{Type: ledger.System}
+	if airplane.Owner != primitive.NilObjectID {
+		seller = ledger.Account{Type: ledger.User, ID: airplane.Owner}
+	}
+	services.GetLedgerService().AddTransfer(work, repos, ledger.Transfer{
+		From:      ledger.Account{Type: ledger.User, ID: buyer.ID},
+		To:        seller,
+		Amount:    price,
+		Type:      ledger.AircraftPurchase,
+		Reference: objectId})
+	for _, x := range sellerAirlines {
+		work.Add("remove the aircraft from the fleet of airline "+x.Hex(),
+			changeArray(repos.Airlines.Pull, x, "fleet", objectId),
+			changeArray(repos.Airlines.AddToSet, x, "fleet", objectId))
+	}
+	if purchase.Airline != primitive.NilObjectID {
+		work.Add("add the aircraft to the fleet of airline "+purchase.Airline.Hex(),
+			changeArray(repos.Airlines.AddToSet, purchase.Airline, "fleet", objectId),
+			changeArray(repos.Airlines.Pull, purchase.Airline, "fleet", objectId))
+	}
+	work.Add("update the history of the aircraft",
+		func(ctx context.Context) error {
+			for _, x := range sellerAirlines {
+				if err := repos.Aircraft.Pull(ctx, objectId, "general.history", x); err != nil {
+					return err
+				}
+			}
+			if purchase.Airline != primitive.NilObjectID {
+				return repos.Aircraft.AddToSet(ctx, objectId, "general.history", purchase.Airline)
+			}
+			return nil
+		},
+		restoreField(repos.Aircraft.Update, objectId, "general.history", airplane.General.History))
+	err = work.Run(ctx)
+	if err == errNotForSale {
+		c.Error(apierrors.Conflict(err.Error()))
+		return
+	} else if err == services.ErrInsufficientFunds {
+		c.Error(apierrors.InsufficientFunds(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("aircraft", id)
+	invalidate("users", buyer.ID.Hex())
+	if airplane.Owner != primitive.NilObjectID {
+		invalidate("users", airplane.Owner.Hex())
+	}
+	airlineIds := []string{purchase.Airline.Hex()}
+	for _, x := range sellerAirlines {
+		airlineIds = append(airlineIds, x.Hex())
+	}
+	invalidate("airlines", airlineIds...)
+	c.JSON(http.StatusOK, purchaseReceipt{
+		Message: "The aircraft has been bought",
+		Price:   price})
+}
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/flight"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+	"github.com/arttkachev/X-Airlines/Backend/openapi"
+	"github.com/arttkachev/X-Airlines/Backend/services/auth"
+	"github.com/arttkachev/X-Airlines/Backend/services/health"
+	"github.com/arttkachev/X-Airlines/Backend/services/integrity"
+	"github.com/arttkachev/X-Airlines/Backend/services/ourairports"
+)
+
+// the parameters parseListQuery reads, every other parameter filters by a field
+var listQuery = []openapi.Param{
+	{Name: "limit", Type: "integer", Description: "page size, 1 to 200, 50 by default"},
+	{Name: "after", Description: "the next cursor of the previous page"},
+	{Name: "sort", Description: "fields separated by commas, descending with a leading -"},
+}
+
+var ledgerQuery = []openapi.Param{
+	{Name: "from", Description: "YYYY-MM-DD or an RFC 3339 timestamp"},
+	{Name: "to", Description: "YYYY-MM-DD or an RFC 3339 timestamp, a date includes the whole day"},
+	{Name: "format", Description: "csv for a CSV file instead of JSON"},
+}
+
+// OpenAPI describes the handlers in the OpenAPI document, keyed by handler name
+var OpenAPI = map[string]openapi.Doc{
+	// users
+	"GetUsers": {Summary: "Lists users, filtered by any other query parameter", Query: listQuery,
+		Response: openapi.PageOf(user.User{})},
+	"GetUserByAirline": {Summary: "Lists the owners of an airline",
+		Query:    []openapi.Param{{Name: "airlines", Description: "airline id", Required: true}},
+		Response: []user.User{}},
+	"GetUserAirlinesData": {Summary: "Lists the airlines of a user", Response: []airline.Airline{}},
+	"UpdateUser":          {Summary: "Updates the signed in user, admin rights only by admins", Request: user.User{}, Response: openapi.Message{}},
+	"UpdateUserAirlines":  {Summary: "Toggles the ownership of the given airlines", Request: user.User{}, Response: openapi.Message{}},
+	"DeleteUser":          {Summary: "Deletes the signed in user and their airlines", Response: openapi.Message{}},
+
+	// aircraft
+	"GetAircraft": {Summary: "Lists aircraft, filtered by any other query parameter", Query: listQuery,
+		Response: openapi.PageOf(aircraft.Aircraft{})},
+	"GetAircraftById": {Summary: "Gets an aircraft", Response: aircraft.Aircraft{}},
+	"GetAircraftByType": {Summary: "Lists aircraft by name",
+		Query:    []openapi.Param{{Name: "aircraft", Description: "aircraft name", Required: true}},
+		Response: []aircraft.Aircraft{}},
+	"CreateAircraft":    {Summary: "Creates an aircraft owned by the signed in user", Create: true, Request: aircraft.Aircraft{}, Response: aircraft.Aircraft{}},
+	"DeleteAircraft":    {Summary: "Deletes an aircraft", Response: openapi.Message{}},
+	"UpdateAirframe":    {Summary: "Updates the airframe", Request: aircraft.Airframe{}, Response: openapi.Message{}},
+	"UpdateExterior":    {Summary: "Updates the exterior", Request: aircraft.Exterior{}, Response: openapi.Message{}},
+	"UpdateInterior":    {Summary: "Updates the interior", Request: aircraft.Interior{}, Response: openapi.Message{}},
+	"UpdateEngines":     {Summary: "Toggles the installation of the given engines", Request: aircraft.Aircraft{}, Response: openapi.Message{}},
+	"UpdateCockpit":     {Summary: "Updates the cockpit", Request: aircraft.Cockpit{}, Response: openapi.Message{}},
+	"UpdateGeneral":     {Summary: "Updates the general data", Request: aircraft.General{}, Response: openapi.Message{}},
+	"UpdatePerformance": {Summary: "Updates the performance", Request: aircraft.Performance{}, Response: openapi.Message{}},
+	"UpdateAPU":         {Summary: "Updates the APU", Request: aircraft.APU{}, Response: openapi.Message{}},
+	"ServiceAPU":        {Summary: "Records an APU service", Request: serviceAPURequest{}, Response: openapi.Message{}},
+	"UpdateTags":        {Summary: "Toggles the given tags", Request: aircraft.Aircraft{}, Response: openapi.Message{}},
+	"UpdateOwner":       {Summary: "Changes the owner, admins only", Request: aircraft.Aircraft{}, Response: openapi.Message{}},
+	"GetOwnerData":      {Summary: "Gets the owner of an aircraft", Response: user.User{}},
+	"GetEngineData":     {Summary: "Lists the engines of an aircraft", Response: []aircraft.Engine{}},
+	"GetAirlineData":    {Summary: "Gets the airline of an aircraft", Response: airline.Airline{}},
+
+	// engines
+	"GetEngines": {Summary: "Lists engines, filtered by any other query parameter", Query: listQuery,
+		Response: openapi.PageOf(aircraft.Engine{})},
+	"GetDueEngines": {Summary: "Lists engines due for an overhaul or a hot section inspection",
+		Query:    []openapi.Param{{Name: "threshold", Type: "number", Description: "hours before the inspection is due"}},
+		Response: []aircraft.Engine{}},
+	"GetEngineById":  {Summary: "Gets an engine", Response: aircraft.Engine{}},
+	"CreateEngine":   {Summary: "Creates an engine owned by the signed in user", Create: true, Request: aircraft.Engine{}, Response: aircraft.Engine{}},
+	"UpdateEngine":   {Summary: "Updates an engine", Request: aircraft.Engine{}, Response: openapi.Message{}},
+	"DeleteEngine":   {Summary: "Deletes an engine", Response: openapi.Message{}},
+	"OverhaulEngine": {Summary: "Records an overhaul or a hot section inspection", Request: overhaulEngineRequest{}, Response: openapi.Message{}},
+
+	// airlines
+	"GetAirlines": {Summary: "Lists airlines, filtered by any other query parameter", Query: listQuery,
+		Response: openapi.PageOf(airline.Airline{})},
+	"CreateAirline":        {Summary: "Creates an airline owned by the signed in user", Create: true, Request: airline.Airline{}, Response: airline.Airline{}},
+	"DeleteAirline":        {Summary: "Deletes an airline", Response: openapi.Message{}},
+	"UpdateAirlineGeneral": {Summary: "Updates the general data", Request: airline.General{}, Response: openapi.Message{}},
+	"UpdateReviews":        {Summary: "Toggles the given reviews", Request: airline.Airline{}, Response: openapi.Message{}},
+	"UpdateRoutes":         {Summary: "Toggles the given routes", Request: airline.Airline{}, Response: openapi.Message{}},
+	"UpdateFleet":          {Summary: "Toggles the given aircraft in the fleet", Request: airline.Airline{}, Response: openapi.Message{}},
+	"UpdateAirlineOwner":   {Summary: "Changes the owner, admins only", Request: airline.Airline{}, Response: openapi.Message{}},
+	"GetFleetData":         {Summary: "Lists the fleet of an airline", Response: []aircraft.Aircraft{}},
+	"GetAirlineOwnerData":  {Summary: "Gets the owner of an airline", Response: user.User{}},
+
+	// reviews
+	"GetReviews":   {Summary: "Lists the visible reviews of an airline", Response: []airline.Review{}},
+	"CreateReview": {Summary: "Reviews an airline", Create: true, Request: airline.Review{}, Response: airline.Review{}},
+	"HideReview":   {Summary: "Hides or shows a review, admins only", Request: reviewModeration{}, Response: openapi.Message{}},
+	"DeleteReview": {Summary: "Deletes a review, by its author or an admin", Response: openapi.Message{}},
+
+	// routes
+	"CreateRoute":      {Summary: "Adds a route to an airline", Create: true, Request: airline.Route{}, Response: airline.Route{}},
+	"GetAirlineRoutes": {Summary: "Lists the routes of an airline with their airports and flights", Response: []RouteData{}},
+	"GetRouteById":     {Summary: "Gets a route with its airports and flights", Response: RouteData{}},
+	"DeleteRoute":      {Summary: "Deletes a route", Response: openapi.Message{}},
+
+	// airports
+	"GetAirports": {Summary: "Finds airports",
+		Query: []openapi.Param{
+			{Name: "icao"},
+			{Name: "iata"},
+			{Name: "country", Description: "ISO 3166-1 alpha-2 code"},
+			{Name: "name", Description: "part of the name"}},
+		Response: []airport.Airport{}},
+	"GetAirportByICAO":     {Summary: "Gets an airport by its ICAO code", Response: airport.Airport{}},
+	"GetAirportByIATA":     {Summary: "Gets an airport by its IATA code", Response: airport.Airport{}},
+	"ImportAirports":       {Summary: "Imports OurAirports csv files of the import directory, admins only", Request: importAirportsRequest{}, Response: ourairports.Result{}},
+	"GetAirportWeather":    {Summary: "Gets the current METAR and TAF of an airport", Response: weatherModel.Weather{}},
+	"UpdateAirportWeather": {Summary: "Stores raw METAR and TAF reports, admins only", Request: weatherReports{}, Response: openapi.Message{}},
+	"IngestWeather":        {Summary: "Ingests the weather drop directory, admins only", Response: ingestedStations{}},
+
+	// flights
+	"GetFlights": {Summary: "Lists flights, filtered by any other query parameter", Query: listQuery,
+		Response: openapi.PageOf(flight.Flight{})},
+	"GetFlightById":  {Summary: "Gets a flight", Response: flight.Flight{}},
+	"CreateFlight":   {Summary: "Schedules a flight", Create: true, Request: flight.Flight{}, Response: flight.Flight{}},
+	"UpdateFlight":   {Summary: "Updates a scheduled flight", Request: flight.Flight{}, Response: openapi.Message{}},
+	"CancelFlight":   {Summary: "Cancels a scheduled flight", Response: openapi.Message{}},
+	"CompleteFlight": {Summary: "Completes a flight and adds its time to the aircraft", Response: openapi.Message{}},
+
+	// marketplace
+	"GetMarketplace": {Summary: "Lists aircraft for sale",
+		Query: []openapi.Param{
+			{Name: "minPrice", Type: "number"},
+			{Name: "maxPrice", Type: "number"},
+			{Name: "manufacturer"},
+			{Name: "model"},
+			{Name: "condition"},
+			{Name: "location"}},
+		Response: []aircraft.Aircraft{}},
+	"ListAircraftForSale": {Summary: "Lists an aircraft for sale", Request: saleListing{}, Response: openapi.Message{}},
+	"UnlistAircraft":      {Summary: "Removes an aircraft from sale", Response: openapi.Message{}},
+	"BuyAircraft":         {Summary: "Buys an aircraft for sale", Request: purchaseRequest{}, Response: purchaseReceipt{}},
+
+	// ledger
+	"GetUserLedger":        {Summary: "Gets the balance and the ledger entries of a user", Query: ledgerQuery, Response: ledgerStatement{}},
+	"AdjustUserBalance":    {Summary: "Adjusts the balance of a user, admins only", Request: balanceAdjustment{}, Response: openapi.Message{}},
+	"GetAirlineLedger":     {Summary: "Gets the balance and the ledger entries of an airline", Query: ledgerQuery, Response: ledgerStatement{}},
+	"AdjustAirlineBalance": {Summary: "Adjusts the balance of an airline, admins only", Request: balanceAdjustment{}, Response: openapi.Message{}},
+
+	// maintenance
+	"GetCheckPrograms":   {Summary: "Lists the check programs", Response: []aircraft.CheckProgram{}},
+	"UpdateCheckProgram": {Summary: "Replaces the check program of a model, admins only", Request: aircraft.CheckProgram{}, Response: aircraft.CheckProgram{}},
+	"GetAircraftChecks":  {Summary: "Tells when the checks of an aircraft are due", Response: aircraftChecks{}},
+	"ScheduleCheck":      {Summary: "Schedules a check and charges its cost to the owner", Request: scheduleCheckRequest{}, Response: aircraft.ScheduledCheck{}},
+
+	// search
+	"Search": {Summary: "Searches aircraft, airlines and airports at once",
+		Query: []openapi.Param{
+			{Name: "q", Required: true},
+			{Name: "limit", Type: "integer", Description: "results of each type, up to 50"},
+			{Name: "types", Description: "aircraft, airlines and airports separated by commas"},
+			{Name: "fuzzy", Type: "boolean", Description: "false matches no typos"},
+			{Name: "manufacturer"},
+			{Name: "condition"},
+			{Name: "location"},
+			{Name: "tag"}},
+		Response: searchResult{}},
+
+	// integrity
+	"CheckIntegrity": {Summary: "Reports broken references between collections, admins only", Response: integrity.Report{}},
+	"FixIntegrity":   {Summary: "Repairs broken references between collections, admins only", Response: integrity.Report{}},
+
+	// authentication
+	"SignUp":  {Summary: "Creates a user", Public: true, Create: true, Request: user.User{}, Response: user.User{}},
+	"SignIn":  {Summary: "Starts a session and issues tokens", Public: true, Request: user.User{}, Response: auth.JWTOutput{}},
+	"SignOut": {Summary: "Ends the session and revokes refresh tokens", Public: true, Request: auth.SignOutRequest{}, Response: openapi.Message{}},
+	"Refresh": {Summary: "Exchanges a refresh token for new tokens", Public: true, Request: auth.RefreshRequest{}, Response: auth.JWTOutput{}},
+	"Welcome": {Summary: "Greets", Public: true},
+	"Healthz": {Summary: "Tells the process is alive", Public: true, Response: health.Report{}},
+	"Readyz":  {Summary: "Pings MongoDB and Redis, 503 with the same report when one does not answer", Public: true, Response: health.Report{}},
+}
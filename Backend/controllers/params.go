@@ -0,0 +1,42 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// path params holding ids: id and the ones ending in Id like reviewId
+func isIdParam(name string) bool {
+	return name == "id" || strings.HasSuffix(name, "Id")
+}
+
+func paramKey(name string) string {
+	return "param:" + name
+}
+
+// BindObjectIds reads the id path params of the route, a malformed id fails the request
+// with a validation error before any other handler runs
+func BindObjectIds() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, x := range c.Params {
+			if !isIdParam(x.Key) {
+				continue
+			}
+			objectId, err := apierrors.ObjectID(x.Key, x.Value)
+			if err != nil {
+				apierrors.Abort(c, err)
+				return
+			}
+			c.Set(paramKey(x.Key), objectId)
+		}
+		c.Next()
+	}
+}
+
+// the id path param read by BindObjectIds
+func paramId(c *gin.Context, name string) primitive.ObjectID {
+	return c.MustGet(paramKey(name)).(primitive.ObjectID)
+}
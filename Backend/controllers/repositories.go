@@ -0,0 +1,22 @@
+package controllers
+
+import (
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// context key of the repositories of a request
+const repositoriesKey = "repositories"
+
+// makes the repositories handlers read and write through available to every request of the router
+func Repositories(r repositories.Repositories) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(repositoriesKey, r)
+		c.Next()
+	}
+}
+
+// the repositories set by the Repositories middleware
+func getRepositories(c *gin.Context) repositories.Repositories {
+	return c.MustGet(repositoriesKey).(repositories.Repositories)
+}
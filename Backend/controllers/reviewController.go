@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// a review loses half of its weight in the airline rating every ratingHalfLife
+const ratingHalfLife = 180 * 24 * time.Hour
+
+// recomputes airline general rating as a recency weighted average of its visible reviews
+func updateAirlineRating(ctx context.Context, repos repositories.Repositories, airlineObjectId primitive.ObjectID) error {
+	reviews, err := repos.Reviews.Find(ctx, bson.M{"airline": airlineObjectId, "hidden": false})
+	if err != nil {
+		return err
+	}
+	var sum, weights float64
+	now := time.Now()
+	for _, review := range reviews {
+		if review.Rating == nil {
+			continue
+		}
+		weight := math.Pow(0.5, float64(now.Sub(review.CreatedAt))/float64(ratingHalfLife))
+		sum += weight * float64(*review.Rating)
+		weights += weight
+	}
+	// no rating without visible reviews
+	var rating *uint8
+	if weights > 0 {
+		value := uint8(math.Round(sum / weights))
+		rating = &value
+	}
+	err = repos.Airlines.Update(ctx, airlineObjectId, bson.M{"general.rating": rating})
+	if err != nil {
+		return err
+	}
+	invalidate("airlines", airlineObjectId.Hex())
+	return nil
+}
+
+func CreateReview(c *gin.Context) {
+	repos := getRepositories(c)
+	var review airline.Review
+	err := c.ShouldBindJSON(&review)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	err = validation.Create(&review)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	author := getCurrentUser(c)
+	airlineObjectId := paramId(c, "id")
+	_, err = repos.Airlines.FindById(ctx, airlineObjectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such airline"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	// one review per user and airline
+	existing, err := repos.Reviews.Find(ctx, bson.M{"airline": airlineObjectId, "author": author.ID})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if len(existing) > 0 {
+		c.Error(apierrors.Conflict("The user has already reviewed this airline"))
+		return
+	}
+	review.ID = primitive.NewObjectID()
+	review.Airline = airlineObjectId
+	review.Author = author.ID
+	review.User = author.Name
+	review.Hidden = false
+	review.CreatedAt = time.Now()
+	err = repos.Reviews.Insert(ctx, review)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = repos.Airlines.AddToSet(ctx, airlineObjectId, "reviews", review.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = updateAirlineRating(ctx, repos, airlineObjectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, review)
+}
+
+func GetReviews(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airlineObjectId := paramId(c, "id")
+	filter := bson.M{"airline": airlineObjectId, "hidden": false}
+	// admins moderate hidden reviews too
+	if isAdmin(getCurrentUser(c)) {
+		delete(filter, "hidden")
+	}
+	reviews, err := repos.Reviews.Find(ctx, filter)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, reviews)
+}
+
+type reviewModeration struct {
+	Hidden *bool `json:"hidden" binding:"required"`
+}
+
+func HideReview(c *gin.Context) {
+	repos := getRepositories(c)
+	var moderation reviewModeration
+	err := c.ShouldBindJSON(&moderation)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airlineObjectId := paramId(c, "id")
+	reviewObjectId := paramId(c, "reviewId")
+	review, err := repos.Reviews.FindById(ctx, reviewObjectId)
+	if err == repositories.ErrNotFound || (err == nil && review.Airline != airlineObjectId) {
+		c.Error(apierrors.NotFound("No such review"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	err = repos.Reviews.Update(ctx, reviewObjectId, bson.M{"hidden": *moderation.Hidden})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = updateAirlineRating(ctx, repos, airlineObjectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The review visibility has been updated"})
+}
+
+func DeleteReview(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	currentUser := getCurrentUser(c)
+	airlineObjectId := paramId(c, "id")
+	reviewObjectId := paramId(c, "reviewId")
+	review, err := repos.Reviews.FindById(ctx, reviewObjectId)
+	if err == repositories.ErrNotFound || (err == nil && review.Airline != airlineObjectId) {
+		c.Error(apierrors.NotFound("No such review"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if review.Author != currentUser.ID && !isAdmin(currentUser) {
+		c.Error(apierrors.Forbidden("Only the author or an admin can delete a review"))
+		return
+	}
+	err = repos.Reviews.Delete(ctx, reviewObjectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = repos.Airlines.Pull(ctx, airlineObjectId, "reviews", reviewObjectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = updateAirlineRating(ctx, repos, airlineObjectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "A review has been deleted"})
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/flight"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/geo"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// route with its airports and flights resolved
+type RouteData struct {
+	airline.Route
+	From       *airport.Airport `json:"from,omitempty"`
+	To         *airport.Airport `json:"to,omitempty"`
+	FlightData []flight.Flight  `json:"flightData"`
+}
+
+func CreateRoute(c *gin.Context) {
+	repos := getRepositories(c)
+	var route airline.Route
+	err := c.ShouldBindJSON(&route)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	err = validation.Create(&route)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	airlineObjectId := paramId(c, "id")
+	_, err = repos.Airlines.FindById(ctx, airlineObjectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such airline"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	from, err := findAirport(ctx, repos, route.From)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if from == nil {
+		c.Error(apierrors.Validation("No such departure airport"))
+		return
+	}
+	to, err := findAirport(ctx, repos, route.To)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	if to == nil {
+		c.Error(apierrors.Validation("No such arrival airport"))
+		return
+	}
+	distance := geo.DistanceNM(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	route.Distance = math.Round(distance*10) / 10
+	route.ID = primitive.NewObjectID()
+	route.Airline = airlineObjectId
+	route.Flights = make([]primitive.ObjectID, 0)
+	err = repos.Routes.Insert(ctx, route)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	err = repos.Airlines.AddToSet(ctx, airlineObjectId, "routes", route.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("airlines", id)
+	c.JSON(http.StatusOK, route)
+}
+
+func GetAirlineRoutes(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	airlineObjectId := paramId(c, "id")
+	airlineRoutes, err := repos.Routes.Find(ctx, bson.M{"airline": airlineObjectId})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	routes := make([]RouteData, 0)
+	for _, route := range airlineRoutes {
+		routeData, err := resolveRoute(ctx, repos, route)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		routes = append(routes, routeData)
+	}
+	c.JSON(http.StatusOK, routes)
+}
+
+func GetRouteById(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	var route airline.Route
+	err := responseCache.Get(idKey("routes", id), &route)
+	if err == cache.ErrMiss {
+		log.Printf("Request to MongoDB")
+		route, err = repos.Routes.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such route"))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		cacheSet(idKey("routes", id), "routes", route, cache.Tag("routes", id))
+	} else if err != nil {
+		c.Error(err)
+		return
+	} else {
+		log.Printf("Request to the cache")
+	}
+	routeData, err := resolveRoute(ctx, repos, route)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, routeData)
+}
+
+func DeleteRoute(c *gin.Context) {
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	id := c.Param("id")
+	objectId := paramId(c, "id")
+	route, err := repos.Routes.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such route"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	// flights of the route go away with it
+	if len(route.Flights) > 0 {
+		flights, err := getRouteFlights(ctx, repos, route)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		flightIds := make([]string, len(flights))
+		aircraftIds := make([]string, len(flights))
+		for i, x := range flights {
+			flightIds[i] = x.ID.Hex()
+			aircraftIds[i] = x.Aircraft.Hex()
+			err = repos.Aircraft.Pull(ctx, x.Aircraft, "trackerData.flightHistory", x.ID)
+			if err != nil && err != repositories.ErrNotFound {
+				c.Error(err)
+				return
+			}
+			err = repos.Flights.Delete(ctx, x.ID)
+			if err != nil && err != repositories.ErrNotFound {
+				c.Error(err)
+				return
+			}
+		}
+		invalidate("flights", flightIds...)
+		invalidate("aircraft", aircraftIds...)
+	}
+	err = repos.Airlines.Pull(ctx, route.Airline, "routes", objectId)
+	if err != nil && err != repositories.ErrNotFound {
+		c.Error(err)
+		return
+	}
+	invalidate("airlines", route.Airline.Hex())
+	err = repos.Routes.Delete(ctx, objectId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	invalidate("routes", id)
+	c.JSON(http.StatusOK, gin.H{
+		"message": "A route has been deleted"})
+}
+
+func getRouteFlights(ctx context.Context, repos repositories.Repositories, route airline.Route) ([]flight.Flight, error) {
+	flights := make([]flight.Flight, 0)
+	if len(route.Flights) == 0 {
+		return flights, nil
+	}
+	for _, x := range route.Flights {
+		flightData, err := repos.Flights.FindById(ctx, x)
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		flights = append(flights, flightData)
+	}
+	return flights, nil
+}
+
+func resolveRoute(ctx context.Context, repos repositories.Repositories, route airline.Route) (RouteData, error) {
+	var err error
+	routeData := RouteData{Route: route}
+	routeData.From, err = findAirport(ctx, repos, route.From)
+	if err != nil {
+		return routeData, err
+	}
+	routeData.To, err = findAirport(ctx, repos, route.To)
+	if err != nil {
+		return routeData, err
+	}
+	routeData.FlightData, err = getRouteFlights(ctx, repos, route)
+	return routeData, err
+}
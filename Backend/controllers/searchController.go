@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/search"
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 50
+)
+
+// aircraft facets and the fields they count
+var searchFacets = []struct {
+	name  string
+	field string
+}{{"manufacturer", "general.manufacturer"}, {"condition", "general.condition"}, {"location", "general.location"}, {"tag", "tags"}}
+
+type searchResult struct {
+	Aircraft []aircraft.Aircraft       `json:"aircraft"`
+	Airlines []airline.Airline         `json:"airlines"`
+	Airports []airport.Airport         `json:"airports"`
+	Total    map[string]int            `json:"total"`
+	Facets   map[string][]search.Count `json:"facets"`
+}
+
+// the omnibox: searches aircraft, airlines and airports at once by q, with fuzzy=false typos do not match.
+// types limits the searched collections and manufacturer, condition, location and tag narrow the aircraft
+func Search(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		c.Error(apierrors.Validation("Provide a search query q"))
+		return
+	}
+	limit := defaultSearchLimit
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSearchLimit {
+			c.Error(apierrors.Validation("limit must be between 1 and " + strconv.Itoa(maxSearchLimit)))
+			return
+		}
+		limit = parsed
+	}
+	types := map[string]bool{"aircraft": true, "airlines": true, "airports": true}
+	if value := c.Query("types"); value != "" {
+		types = make(map[string]bool)
+		for _, x := range strings.Split(value, ",") {
+			if x != "aircraft" && x != "airlines" && x != "airports" {
+				c.Error(apierrors.Validation("types are aircraft, airlines and airports"))
+				return
+			}
+			types[x] = true
+		}
+	}
+	fuzzy := c.Query("fuzzy") != "false"
+	var aircraftConditions []repositories.Condition
+	for _, x := range searchFacets {
+		if value := c.Query(x.name); value != "" {
+			aircraftConditions = append(aircraftConditions, repositories.Condition{Field: x.field, Operator: repositories.Eq, Values: []interface{}{value}})
+		}
+	}
+
+	key := cache.Key("search", c.Request.URL.Query().Encode())
+	var result searchResult
+	err := responseCache.Get(key, &result)
+	if err == nil {
+		log.Printf("Request to the cache")
+		c.JSON(http.StatusOK, result)
+		return
+	} else if err != cache.ErrMiss {
+		c.Error(err)
+		return
+	}
+	log.Printf("Request to MongoDB")
+	repos := getRepositories(c)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	result = searchResult{
+		Aircraft: make([]aircraft.Aircraft, 0),
+		Airlines: make([]airline.Airline, 0),
+		Airports: make([]airport.Airport, 0),
+		Total:    make(map[string]int),
+		Facets:   make(map[string][]search.Count)}
+	if types["aircraft"] {
+		hits, err := search.Find(ctx, search.NewTarget(repos.Aircraft, search.AircraftFields, aircraftConditions), query, fuzzy)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		values := make(map[string][]string)
+		for i, x := range hits {
+			var airplane aircraft.Aircraft
+			if err = bson.Unmarshal(x.Document, &airplane); err != nil {
+				c.Error(err)
+				return
+			}
+			if i < limit {
+				result.Aircraft = append(result.Aircraft, airplane)
+			}
+			if airplane.General != nil {
+				values["manufacturer"] = append(values["manufacturer"], airplane.General.Manufacturer)
+				values["condition"] = append(values["condition"], airplane.General.Condition)
+				values["location"] = append(values["location"], airplane.General.Location)
+			}
+			values["tag"] = append(values["tag"], airplane.Tags...)
+		}
+		for _, x := range searchFacets {
+			result.Facets[x.name] = search.Counts(values[x.name])
+		}
+		result.Total["aircraft"] = len(hits)
+	}
+	if types["airlines"] {
+		hits, err := search.Find(ctx, search.NewTarget(repos.Airlines, search.AirlineFields, nil), query, fuzzy)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for i := 0; i < len(hits) && i < limit; i++ {
+			var airlineData airline.Airline
+			if err = bson.Unmarshal(hits[i].Document, &airlineData); err != nil {
+				c.Error(err)
+				return
+			}
+			result.Airlines = append(result.Airlines, airlineData)
+		}
+		result.Total["airlines"] = len(hits)
+	}
+	if types["airports"] {
+		hits, err := search.Find(ctx, search.NewTarget(repos.Airports, search.AirportFields, nil), query, fuzzy)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		for i := 0; i < len(hits) && i < limit; i++ {
+			var airportData airport.Airport
+			if err = bson.Unmarshal(hits[i].Document, &airportData); err != nil {
+				c.Error(err)
+				return
+			}
+			result.Airports = append(result.Airports, airportData)
+		}
+		result.Total["airports"] = len(hits)
+	}
+	// any change to the searched collections evicts the result
+	cacheSet(key, "search", result, cache.Tag("aircraft"), cache.Tag("airlines"), cache.Tag("airports"))
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,46 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// a unit of work step changing the array of a (dotted) field of one document, with Toggle, AddToSet or Pull
+func changeArray(change func(context.Context, primitive.ObjectID, string, interface{}) error, id primitive.ObjectID, field string, values interface{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return change(ctx, id, field, values)
+	}
+}
+
+// an undo setting a field back to the value it had before the unit of work
+func restoreField(update func(context.Context, primitive.ObjectID, bson.M) error, id primitive.ObjectID, field string, value interface{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return update(ctx, id, bson.M{field: value})
+	}
+}
+
+// a unit of work step applying a change and undoing it, counters go back by what they were
+// incremented by and the fields the change set for the first time are removed again
+func applyChange(apply func(context.Context, primitive.ObjectID, []repositories.Condition, repositories.Change) error, id primitive.ObjectID, change repositories.Change) (func(ctx context.Context) error, func(ctx context.Context) error) {
+	reverse := repositories.Change{Inc: bson.M{}}
+	for field, value := range change.Inc {
+		switch v := value.(type) {
+		case int:
+			reverse.Inc[field] = -v
+		case float64:
+			reverse.Inc[field] = -v
+		}
+	}
+	for field := range change.Set {
+		reverse.Unset = append(reverse.Unset, field)
+	}
+	return func(ctx context.Context) error {
+			return apply(ctx, id, nil, change)
+		},
+		func(ctx context.Context) error {
+			return apply(ctx, id, nil, reverse)
+		}
+}
@@ -2,111 +2,95 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"log"
 	"net/http"
 	"time"
 
 	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
 	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
 	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/services/auth"
+	"github.com/arttkachev/X-Airlines/Backend/services/password"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/gin-gonic/gin"
 
-	"github.com/go-redis/redis"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func GetUsers(c *gin.Context) {
-	userService := services.GetUserService()
+	repos := getRepositories(c)
+	query, err := parseListQuery(c, "password")
+	if err != nil {
+		c.Error(apierrors.Validation(err.Error()))
+		return
+	}
 	// storage for found users
-	users := make([]user.User, 0)
-	val, err := userService.RedisClient.Get("users").Result()
-	if err == redis.Nil {
+	var page struct {
+		Items []user.User `json:"items"`
+		repositories.Page
+	}
+	err = responseCache.Get(pageKey("users", c), &page)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		// create context
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		// get a stream of documents (cursor) from mongo collection
-		cur, err := userService.Collection.Find(ctx, bson.M{})
+		// get a page of users from the repository
+		page.Items, page.Page, err = repos.Users.List(ctx, query)
 		// check on errors
-		if err != nil {
+		if err == repositories.ErrInvalidCursor {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		} else if err != nil {
 			// return if error
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// cursor must be closed on exit form function
-		defer cur.Close(ctx)
-		// loop through all users with mongo cursor
-		for cur.Next(ctx) {
-			var user user.User
-			// decode mongo cursor into the user data type
-			err = cur.Decode(&user)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			// append found user
-			users = append(users, user)
-		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(users)
-		userService.RedisClient.Set("users", string(data), 0)
+		cacheSet(pageKey("users", c), "users", page, cache.Tag("users"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &users)
+		log.Printf("Request to the cache")
 	}
-	c.JSON(http.StatusOK, users)
+	c.JSON(http.StatusOK, page)
 }
 
 func GetUserByAirline(c *gin.Context) {
+	repos := getRepositories(c)
 	airline := c.Query("airlines")
-	userService := services.GetUserService()
+	// users keep airline ids, not their hex strings
+	airlineObjectId, err := apierrors.ObjectID("airlines", airline)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	users := make([]user.User, 0)
-	val, err := userService.RedisClient.Get("users/" + airline).Result()
-	if err == redis.Nil {
+	err = responseCache.Get(cache.Key("users", "airline", airline), &users)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
-		cur, err := userService.Collection.Find(ctx, bson.M{"airlines": airline})
-		defer cur.Close(ctx)
+		users, err = repos.Users.Find(ctx, bson.M{"airlines": airlineObjectId})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		for cur.Next(ctx) {
-			var user user.User
-			err = cur.Decode(&user)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			users = append(users, user)
-		}
 		if len(users) == 0 {
-			c.JSON(http.StatusNotFound, gin.H{
-				"message": "Users not found"})
+			c.Error(apierrors.NotFound("Users not found"))
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(users)
-		userService.RedisClient.Set("users/"+airline, string(data), 0)
+		cacheSet(cache.Key("users", "airline", airline), "users", users, cache.Tag("users"))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &users)
+		log.Printf("Request to the cache")
 	}
 	c.JSON(http.StatusOK, users)
 }
@@ -116,276 +100,220 @@ func UpdateUser(c *gin.Context) {
 	var user user.User
 	err := c.ShouldBindJSON(&user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	// create context
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	// get db collection
-	var userService = services.GetUserService()
-	collection := userService.Collection
 	// fetch "id" from the user input
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	// create a filter and mongo aggregation conds for PUT request
-	filter := bson.D{{"_id", objectId}}
-	update := bson.D{{"$set", bson.D{
-		{"name", bson.D{
-			{"$cond", bson.D{
-				{"if", user.Name == ""},
-				{"then", "$name"},
-				{"else", user.Name}}}}},
-
-		{"email", bson.D{
-			{"$cond", bson.D{
-				{"if", user.Email == ""},
-				{"then", "$email"},
-				{"else", user.Email}}}}},
-
-		{"password", bson.D{
-			{"$cond", bson.D{
-				{"if", user.Password == ""},
-				{"then", "$password"},
-				{"else", user.Password}}}}},
-
-		{"isAdmin", bson.D{
-			{"$cond", bson.D{
-				{"if", user.IsAdmin != nil},
-				{"then", user.IsAdmin},
-				{"else", "$isAdmin"}}}}},
-
-		{"balance", bson.D{
-			{"$cond", bson.D{
-				{"if", user.Balance != nil},
-				{"then", user.Balance},
-				{"else", "$balance"}}}}}}}}
-
+	objectId := paramId(c, "id")
+	if user.IsAdmin != nil && !isAdmin(getCurrentUser(c)) {
+		c.Error(apierrors.Forbidden("Only admins can change admin rights"))
+		return
+	}
+	// never store a plain password
+	if user.Password != "" {
+		user.Password, err = password.Hash(user.Password)
+		if err != nil {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		}
+	}
+	fields := givenFields(bson.M{
+		"name":     user.Name,
+		"email":    user.Email,
+		"password": user.Password,
+		"isAdmin":  user.IsAdmin})
 	// update
-	_, err = collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+	err = getRepositories(c).Users.Update(ctx, objectId, fields)
+	if err == repositories.ErrDuplicate {
+		c.Error(apierrors.Conflict("The name is already taken"))
 		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	// a new password signs the user out of every client holding a refresh token
+	if user.Password != "" {
+		auth.RevokeUserRefreshTokens(objectId)
 	}
 	// clear cache
-	log.Println("Remove user data from Redis")
-	userService.RedisClient.Del("users")
-	userService.RedisClient.Del("users/" + id)
+	invalidate("users", id)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The user has been updated"})
 }
 
 func DeleteUser(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	objectId, _ := primitive.ObjectIDFromHex(id)
-	userService := services.GetUserService()
-	var user user.User
-	userVal, err := userService.RedisClient.Get("users/" + id).Result()
-	if err == redis.Nil {
-		log.Printf("Request to MongoDB")
-		err = userService.Collection.FindOne(ctx, bson.M{"_id": objectId}).Decode(&user)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
+	objectId := paramId(c, "id")
+	// read from the database, cached users have no password to put back on undo
+	user, err := repos.Users.FindById(ctx, objectId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.NotFound("No such user"))
+		return
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
-	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(userVal), &user)
-
 	}
-	airlineService := services.GetAirlineService()
+	work := services.NewUnitOfWork("Delete user " + id)
+	var airlineIds []string
 	for _, x := range user.Airlines {
-		airlineId := x.Hex()
-		airlineObjectId, err := primitive.ObjectIDFromHex(airlineId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+		airlineData, err := repos.Airlines.FindById(ctx, x)
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			c.Error(err)
 			return
 		}
-		_, err = airlineService.Collection.DeleteOne(ctx, bson.M{"_id": airlineObjectId})
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
+		restoredAirline := airlineData
+		if addClosingTransfer(work, repos, ledger.Account{Type: ledger.Airline, ID: x}, airlineData.Balance) {
+			// undoing the closing transfer puts the balance back
+			restoredAirline.Balance = new(int)
 		}
-		log.Println("Remove airline id data from Redis")
-		airlineService.RedisClient.Del("airlines/" + airlineId)
+		work.Add("delete airline "+x.Hex(),
+			func(ctx context.Context) error {
+				return repos.Airlines.Delete(ctx, airlineData.ID)
+			},
+			func(ctx context.Context) error {
+				return repos.Airlines.Insert(ctx, restoredAirline)
+			})
+		airlineIds = append(airlineIds, x.Hex())
 	}
-	deleteResult, _ := userService.Collection.DeleteOne(ctx, bson.M{"_id": objectId})
-	if deleteResult.DeletedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Error on deleting a user"})
-		return
+	restored := user
+	if addClosingTransfer(work, repos, ledger.Account{Type: ledger.User, ID: objectId}, user.Balance) {
+		restored.Balance = new(int)
 	}
+	work.Add("delete the user",
+		func(ctx context.Context) error {
+			return repos.Users.Delete(ctx, objectId)
+		},
+		func(ctx context.Context) error {
+			return repos.Users.Insert(ctx, restored)
+		})
+	err = work.Run(ctx)
 	// clear cache
-	log.Println("Remove user data from Redis")
-	userService.RedisClient.Del("users")
-	userService.RedisClient.Del("users/" + id)
-	log.Println("Remove airlines data from Redis")
-	airlineService.RedisClient.Del("airlines")
+	invalidate("airlines", airlineIds...)
+	invalidate("users", id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
 	c.JSON(http.StatusOK, gin.H{
 		"message": "A user has been deleted"})
 }
 
 func GetUserAirlinesData(c *gin.Context) {
+	repos := getRepositories(c)
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	userId := c.Param("id")
 	var user user.User
-	userService := services.GetUserService()
-	val, err := userService.RedisClient.Get("users/" + userId).Result()
-	if err == redis.Nil {
+	err := responseCache.Get(idKey("users", userId), &user)
+	if err == cache.ErrMiss {
 		log.Printf("Request to MongoDB")
-		aircraftObjectId, err := primitive.ObjectIDFromHex(userId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
-		err = userService.Collection.FindOne(ctx, bson.M{"_id": aircraftObjectId}).Decode(&user)
+		aircraftObjectId := paramId(c, "id")
+		user, err = repos.Users.FindById(ctx, aircraftObjectId)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-		data, _ := json.Marshal(user)
-		userService.RedisClient.Set("users/"+userId, string(data), 0)
+		cacheSet(idKey("users", userId), "users", user, cache.Tag("users", userId))
 	} else if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(err)
 		return
 	} else {
-		log.Printf("Request to Redis")
-		json.Unmarshal([]byte(val), &user)
+		log.Printf("Request to the cache")
 	}
-	airlineService := services.GetAirlineService()
 	var airlines []airline.Airline
 	for _, x := range user.Airlines {
 		airlineId := x.Hex()
-		airlineObjectId, err := primitive.ObjectIDFromHex(airlineId)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
-			return
-		}
+		airlineObjectId := x
 		var airline airline.Airline
-		engineVal, err := airlineService.RedisClient.Get("airlines/" + airlineId).Result()
-		if err == redis.Nil {
+		err = responseCache.Get(idKey("airlines", airlineId), &airline)
+		if err == cache.ErrMiss {
 			log.Printf("Request to MongoDB")
-			err = airlineService.Collection.FindOne(ctx, bson.M{"_id": airlineObjectId}).Decode(&airline)
+			airline, err = repos.Airlines.FindById(ctx, airlineObjectId)
 			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
+				c.Error(err)
 				return
 			}
-			// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-			data, _ := json.Marshal(airline)
-			airlineService.RedisClient.Set("airlines/"+airlineId, string(data), 0)
+			cacheSet(idKey("airlines", airlineId), "airlines", airline, cache.Tag("airlines", airlineId))
 			airlines = append(airlines, airline)
 
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(engineVal), &airline)
+			log.Printf("Request to the cache")
 			airlines = append(airlines, airline)
 
 		}
 	}
 	if len(airlines) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{
-			"message": "The user does not own airlines"})
+		c.Error(apierrors.NotFound("The user does not own airlines"))
 		return
 	}
 	c.JSON(http.StatusOK, airlines)
 }
 
 func UpdateUserAirlines(c *gin.Context) {
+	repos := getRepositories(c)
 	var user user.User
-	userService := services.GetUserService()
-	airlineService := services.GetAirlineService()
 	err := c.ShouldBindJSON(&user)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	id := c.Param("id")
-	userObjectId, _ := primitive.ObjectIDFromHex(id)
-	filter := bson.D{{"_id", userObjectId}}
-	update := bson.D{{"$set", bson.D{
-		{"airlines", bson.D{
-			{"$cond", bson.D{
-				{"if", bson.D{{"$in", bson.A{bson.D{{"$first", bson.A{bson.D{{"$ifNull", bson.A{user.Airlines, bson.A{}}}}}}}, "$airlines"}}}},
-				{"then", bson.D{{"$setDifference", bson.A{"$airlines", user.Airlines}}}},
-				{"else", bson.D{{"$concatArrays", bson.A{"$airlines", bson.D{{"$ifNull", bson.A{user.Airlines, bson.A{}}}}}}}}}}}}}}}
-
-	_, err = userService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
-		return
-	}
+	userObjectId := paramId(c, "id")
+	// ownership of an airline only moves between users through its current owner or an admin
+	airlines := make([]airline.Airline, 0, len(user.Airlines))
 	for _, x := range user.Airlines {
-		var airline airline.Airline
-		airlineId := x.Hex()
-		airlineObjectId, _ := primitive.ObjectIDFromHex(airlineId)
-		airlineVal, err := airlineService.RedisClient.Get("airlines/" + airlineId).Result()
-		if err == redis.Nil {
-			log.Printf("Request to MongoDB")
-			err = airlineService.Collection.FindOne(ctx, bson.M{"_id": airlineObjectId}).Decode(&airline)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"error": err.Error()})
-				return
-			}
-			// Redis value has to be a string, so, we need to Marshal users first and put users on a Redis server
-			data, _ := json.Marshal(airline)
-			airlineService.RedisClient.Set("airlines/"+airlineId, string(data), 0)
-
+		airlineData, err := repos.Airlines.FindById(ctx, x)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No such airline " + x.Hex()))
+			return
 		} else if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
-		} else {
-			log.Printf("Request to Redis")
-			json.Unmarshal([]byte(airlineVal), &airline)
 		}
-		filter := bson.D{{"_id", airlineObjectId}}
-		update := bson.D{{"$set", bson.D{
-			{"owner", bson.D{
-				{"$cond", bson.D{
-					{"if", airline.Owner == userObjectId},
-					{"then", primitive.NilObjectID},
-					{"else", userObjectId}}}}}}}}
-		_, err = airlineService.Collection.UpdateOne(ctx, filter, mongo.Pipeline{update})
+		if !canModify(c, airlineData.Owner) {
+			c.Error(apierrors.Forbidden("Only the owner can give away the airline " + x.Hex()))
+			return
+		}
+		airlines = append(airlines, airlineData)
+	}
+	work := services.NewUnitOfWork("Update the airlines of user " + id)
+	users := []string{id}
+	airlineIds := make([]string, 0, len(airlines))
+	for _, x := range airlines {
+		// toggles the ownership
+		owner := userObjectId
+		if x.Owner == userObjectId {
+			owner = primitive.NilObjectID
+		}
+		err = addOwnerChange(ctx, work, repos, x, owner)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": err.Error()})
+			c.Error(err)
 			return
 		}
-		log.Println("Remove airline id data from Redis")
-		airlineService.RedisClient.Del("airlines/" + airlineId)
+		users = append(users, x.Owner.Hex())
+		airlineIds = append(airlineIds, x.ID.Hex())
+	}
+	err = work.Run(ctx)
+	invalidate("airlines", airlineIds...)
+	invalidate("users", users...)
+	if err != nil {
+		c.Error(err)
+		return
 	}
-	log.Println("Remove users data from Redis")
-	userService.RedisClient.Del("users")
-	userService.RedisClient.Del("users/" + id)
-	log.Println("Remove airline data from Redis")
-	airlineService.RedisClient.Del("airlines")
 	c.JSON(http.StatusOK, gin.H{
 		"message": "The user airlines have been updated"})
 }
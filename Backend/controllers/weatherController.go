@@ -0,0 +1,130 @@
+package controllers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/services/weather"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"github.com/gin-gonic/gin"
+)
+
+// removes cached weather of the stations
+func ClearWeatherCache(stations []string) {
+	for _, x := range stations {
+		invalidate("weather", x)
+	}
+}
+
+func GetAirportWeather(c *gin.Context) {
+	icao := strings.ToUpper(c.Param("icao"))
+	var current weatherModel.Weather
+	err := responseCache.Get(idKey("weather", icao), &current)
+	if err == cache.ErrMiss {
+		log.Printf("Request to MongoDB")
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		current, err = getRepositories(c).Weather.FindByAirport(ctx, icao)
+		if err == repositories.ErrNotFound {
+			c.Error(apierrors.NotFound("No weather for this airport"))
+			return
+		} else if err != nil {
+			c.Error(err)
+			return
+		}
+		cacheSet(idKey("weather", icao), "weather", current, cache.Tag("weather", icao))
+	} else if err != nil {
+		c.Error(err)
+		return
+	} else {
+		log.Printf("Request to the cache")
+	}
+	c.JSON(http.StatusOK, current)
+}
+
+// raw reports, at least one of them
+type weatherReports struct {
+	Metar string `json:"metar"`
+	Taf   string `json:"taf"`
+}
+
+// the airports whose weather was ingested
+type ingestedStations struct {
+	Stations []string `json:"stations"`
+}
+
+// stores raw METAR and TAF reports of an airport
+func UpdateAirportWeather(c *gin.Context) {
+	var reports weatherReports
+	err := c.ShouldBindJSON(&reports)
+	if err != nil {
+		c.Error(validation.Error(err))
+		return
+	}
+	if reports.Metar == "" && reports.Taf == "" {
+		c.Error(apierrors.Validation("Provide a METAR, a TAF or both"))
+		return
+	}
+	icao := strings.ToUpper(c.Param("icao"))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	repos := getRepositories(c)
+	taf := strings.TrimSpace(reports.Taf)
+	if taf != "" && !strings.HasPrefix(strings.ToUpper(taf), "TAF") {
+		taf = "TAF " + taf
+	}
+	// both reports are parsed before anything is stored so a bad TAF leaves the METAR out too
+	now := time.Now()
+	parsed := make([]weather.Report, 0, 2)
+	for _, raw := range []string{reports.Metar, taf} {
+		if raw == "" {
+			continue
+		}
+		report, err := weather.Parse(raw, now)
+		if err != nil {
+			c.Error(apierrors.Validation(err.Error()))
+			return
+		}
+		if report.Station != icao {
+			c.Error(apierrors.Validation("The report does not belong to " + icao))
+			return
+		}
+		parsed = append(parsed, report)
+	}
+	defer ClearWeatherCache([]string{icao})
+	for _, report := range parsed {
+		err = weather.Store(ctx, repos.Weather, repos.Airports, report)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "The airport weather has been updated"})
+}
+
+// ingests the weather drop directory right away
+func IngestWeather(c *gin.Context) {
+	dir := settings.Weather.DropDir
+	if dir == "" {
+		c.Error(apierrors.NotFound("No weather drop directory configured"))
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	repos := getRepositories(c)
+	stations, err := weather.IngestDirectory(ctx, repos.Weather, repos.Airports, dir)
+	ClearWeatherCache(stations)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, ingestedStations{Stations: stations})
+}
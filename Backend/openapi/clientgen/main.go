@@ -0,0 +1,252 @@
+// Command clientgen writes the types and methods of the client package from the OpenAPI document:
+//
+//	go run ./openapi/clientgen -spec openapi.json -out client/client_gen.go
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arttkachev/X-Airlines/Backend/openapi"
+)
+
+const componentPrefix = "#/components/schemas/"
+
+// words kept upper case in Go names
+var initialisms = map[string]bool{"id": true, "url": true, "iata": true, "icao": true, "apu": true, "tbo": true, "hst": true, "json": true, "jwt": true}
+
+var words = regexp.MustCompile(`[A-Z]?[a-z0-9]+|[A-Z]+(?:[A-Z][a-z]|$|[^A-Za-z])?`)
+
+var pathParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+
+func main() {
+	spec := flag.String("spec", "openapi.json", "the OpenAPI document")
+	out := flag.String("out", "client_gen.go", "the Go file to write")
+	pkg := flag.String("package", "client", "the package of the Go file")
+	flag.Parse()
+	data, err := ioutil.ReadFile(*spec)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var document openapi.Document
+	if err = json.Unmarshal(data, &document); err != nil {
+		log.Fatal(err)
+	}
+	source, err := generate(&document, *pkg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err = ioutil.WriteFile(*out, source, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(document *openapi.Document, pkg string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Code generated by clientgen from the OpenAPI document. DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	b.WriteString("import (\n\t\"context\"\n\t\"encoding/json\"\n\t\"net/url\"\n\t\"time\"\n)\n\n")
+	// keeps the imports used whatever the document holds
+	b.WriteString("var (\n\t_ json.RawMessage\n\t_ time.Time\n\t_ url.Values\n)\n\n")
+
+	names := make([]string, 0, len(document.Components.Schemas))
+	for name := range document.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		writeType(&b, name, document.Components.Schemas[name])
+	}
+
+	paths := make([]string, 0, len(document.Paths))
+	for path := range document.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		methods := make([]string, 0, len(document.Paths[path]))
+		for method := range document.Paths[path] {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			writeMethod(&b, path, method, document.Paths[path][method])
+		}
+	}
+	return format.Source(b.Bytes())
+}
+
+func writeType(b *bytes.Buffer, name string, schema *openapi.Schema) {
+	if schema.Type != "object" || schema.Properties == nil {
+		fmt.Fprintf(b, "type %s %s\n\n", name, goType(schema, true))
+		return
+	}
+	required := map[string]bool{}
+	for _, x := range schema.Required {
+		required[x] = true
+	}
+	properties := make([]string, 0, len(schema.Properties))
+	for property := range schema.Properties {
+		properties = append(properties, property)
+	}
+	sort.Strings(properties)
+	fmt.Fprintf(b, "type %s struct {\n", name)
+	for _, property := range properties {
+		tag := property
+		if !required[property] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(b, "\t%s %s `json:%q`\n", goName(property), goType(schema.Properties[property], required[property]), tag)
+	}
+	b.WriteString("}\n\n")
+}
+
+// the Go type of a schema, optional structs and nullable values are pointers
+func goType(schema *openapi.Schema, required bool) string {
+	pointer := func(t string) string {
+		if schema.Nullable {
+			return "*" + t
+		}
+		return t
+	}
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, componentPrefix)
+		if required {
+			return name
+		}
+		return "*" + name
+	}
+	switch schema.Type {
+	case "string":
+		if schema.Format == "date-time" {
+			if required && !schema.Nullable {
+				return "time.Time"
+			}
+			return "*time.Time"
+		}
+		if schema.Format == "byte" {
+			return "[]byte"
+		}
+		return pointer("string")
+	case "integer":
+		return pointer("int64")
+	case "number":
+		return pointer("float64")
+	case "boolean":
+		return pointer("bool")
+	case "array":
+		return "[]" + elementType(schema.Items)
+	case "object":
+		if schema.AdditionalProperties != nil {
+			return "map[string]" + elementType(schema.AdditionalProperties)
+		}
+	}
+	return "json.RawMessage"
+}
+
+// elements of arrays and maps are values
+func elementType(schema *openapi.Schema) string {
+	if schema == nil {
+		return "json.RawMessage"
+	}
+	return strings.TrimPrefix(goType(schema, true), "*")
+}
+
+func writeMethod(b *bytes.Buffer, path string, method string, operation *openapi.Operation) {
+	var params, query []string
+	for _, x := range operation.Parameters {
+		if x.In == "path" {
+			params = append(params, goParam(x.Name)+" string")
+		} else if x.In == "query" {
+			query = append(query, x.Name)
+		}
+	}
+	if len(query) > 0 {
+		params = append(params, "query url.Values")
+	}
+	body := "nil"
+	if operation.RequestBody != nil {
+		params = append(params, "body "+goType(operation.RequestBody.Content["application/json"].Schema, true))
+		body = "body"
+	}
+	queryArg := "nil"
+	if len(query) > 0 {
+		queryArg = "query"
+	}
+	urlPath := "\"" + pathParam.ReplaceAllStringFunc(path, func(x string) string {
+		return "\" + url.PathEscape(" + goParam(x[1:len(x)-1]) + ") + \""
+	}) + "\""
+	urlPath = strings.TrimSuffix(urlPath, " + \"\"")
+
+	name := upperFirst(operation.OperationID)
+	comment := name
+	if operation.Summary != "" {
+		comment += ": " + strings.ToLower(operation.Summary[:1]) + operation.Summary[1:]
+	}
+	fmt.Fprintf(b, "// %s\n//\n// %s %s", comment, strings.ToUpper(method), path)
+	if len(query) > 0 {
+		fmt.Fprintf(b, "?%s", strings.Join(query, "&"))
+	}
+	b.WriteString("\n")
+
+	var result *openapi.Schema
+	if response := operation.Responses["200"]; response != nil {
+		if content, ok := response.Content["application/json"]; ok {
+			result = content.Schema
+		}
+	}
+	params = append([]string{"ctx context.Context"}, params...)
+	signature := fmt.Sprintf("func (c *Client) %s(%s)", name, strings.Join(params, ", "))
+	call := fmt.Sprintf("c.do(ctx, %q, %s, %s, %s, ", strings.ToUpper(method), urlPath, queryArg, body)
+	switch {
+	case result == nil:
+		fmt.Fprintf(b, "%s error {\n\treturn %snil)\n}\n\n", signature, call)
+	case result.Ref != "":
+		t := goType(result, true)
+		fmt.Fprintf(b, "%s (*%s, error) {\n\tvar out %s\n\tif err := %s&out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n\n",
+			signature, t, t, call)
+	default:
+		t := goType(result, true)
+		fmt.Fprintf(b, "%s (%s, error) {\n\tvar out %s\n\terr := %s&out)\n\treturn out, err\n}\n\n", signature, t, t, call)
+	}
+}
+
+// a Go name of a JSON name, e.g. ID for id and RefreshToken for refreshToken
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range words.FindAllString(name, -1) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+		} else {
+			b.WriteString(upperFirst(word))
+		}
+	}
+	return b.String()
+}
+
+func goParam(name string) string {
+	name = goName(name)
+	for i, r := range name {
+		if r >= 'a' && r <= 'z' {
+			if i > 1 {
+				i--
+			}
+			return strings.ToLower(name[:i]) + name[i:]
+		}
+	}
+	return strings.ToLower(name)
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
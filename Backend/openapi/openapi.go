@@ -0,0 +1,223 @@
+// Package openapi builds the OpenAPI 3 document of the API from the registered gin routes and the
+// structs their handlers bind and respond with, so the document cannot drift from the code
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+}
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// an empty list makes the operation public, nil keeps the document security
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Doc describes a handler, values of Request and Response stand for the types the handler binds and responds with
+type Doc struct {
+	Summary string
+	// no authentication, the route is outside of the authorized group
+	Public bool
+	Query  []Param
+	// the request body follows the create rules
+	Create   bool
+	Request  interface{}
+	Response interface{}
+}
+
+// a query parameter, Type is a JSON schema type and string by default
+type Param struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+}
+
+// a page of a list endpoint, the items and the cursor of the next page
+type page struct {
+	item reflect.Type
+}
+
+// PageOf stands for a page of items like item in Doc.Response
+func PageOf(item interface{}) interface{} {
+	return page{item: reflect.TypeOf(item)}
+}
+
+// the {"message": ...} response of handlers that only report success
+type Message struct {
+	Message string `json:"message"`
+}
+
+// the cookie of the session a sign in starts
+const SessionCookie = "x-airlines_api"
+
+// :name and *name segments of gin paths
+var pathParam = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+
+// handler closures end with funcN elements
+var closure = regexp.MustCompile(`^func[0-9]+$`)
+
+// the routes of this package are left out of the document
+var ownPackage = reflect.TypeOf(Document{}).PkgPath() + "."
+
+// OperationID is the name of a gin handler without its package, receiver and closure suffixes,
+// e.g. SignUp for github.com/arttkachev/X-Airlines/Backend/services/auth.(*AuthService).SignUp-fm
+func OperationID(handler string) string {
+	handler = strings.TrimSuffix(handler, "-fm")
+	handler = handler[strings.LastIndex(handler, "/")+1:]
+	elements := strings.Split(handler, ".")
+	for len(elements) > 1 && closure.MatchString(elements[len(elements)-1]) {
+		elements = elements[:len(elements)-1]
+	}
+	return elements[len(elements)-1]
+}
+
+// Build documents every route, docs are keyed by operation id and routes without one still get
+// their path, parameters and the error response
+func Build(info Info, routes gin.RoutesInfo, docs map[string]Doc) *Document {
+	g := newGenerator()
+	for _, x := range docs {
+		g.collect(x.Request)
+		g.collect(x.Response)
+	}
+	g.collect(Message{})
+	g.collect(errorModel)
+	g.name()
+	document := &Document{
+		OpenAPI: "3.0.3",
+		Info:    info,
+		Paths:   map[string]map[string]*Operation{},
+		Security: []map[string][]string{
+			{"bearerAuth": {}},
+			{"cookieAuth": {}}},
+	}
+	errorSchema := g.body(errorModel, false)
+	g.body(Message{}, false)
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].Path+routes[i].Method < routes[j].Path+routes[j].Method
+	})
+	for _, route := range routes {
+		if strings.HasPrefix(route.Handler, ownPackage) {
+			continue
+		}
+		id := OperationID(route.Handler)
+		doc := docs[id]
+		operation := &Operation{
+			OperationID: id,
+			Summary:     doc.Summary,
+			Responses: map[string]*Response{
+				"default": {Description: "Error", Content: jsonContent(errorSchema)}},
+		}
+		if tag := strings.Split(strings.TrimPrefix(route.Path, "/"), "/")[0]; tag != "" {
+			operation.Tags = []string{tag}
+		}
+		if doc.Public {
+			operation.Security = &[]map[string][]string{}
+		}
+		for _, x := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+			operation.Parameters = append(operation.Parameters, pathParameter(x[1]))
+		}
+		for _, x := range doc.Query {
+			kind := x.Type
+			if kind == "" {
+				kind = "string"
+			}
+			operation.Parameters = append(operation.Parameters, Parameter{
+				Name:        x.Name,
+				In:          "query",
+				Description: x.Description,
+				Required:    x.Required,
+				Schema:      &Schema{Type: kind}})
+		}
+		if doc.Request != nil {
+			operation.RequestBody = &RequestBody{Required: true, Content: jsonContent(g.body(doc.Request, doc.Create))}
+		}
+		operation.Responses["200"] = &Response{Description: "OK"}
+		if doc.Response != nil {
+			operation.Responses["200"].Content = jsonContent(g.body(doc.Response, false))
+		}
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*Operation{}
+		}
+		document.Paths[path][strings.ToLower(route.Method)] = operation
+	}
+	document.Components = Components{
+		Schemas: g.components,
+		SecuritySchemes: map[string]*SecurityScheme{
+			"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			"cookieAuth": {Type: "apiKey", In: "cookie", Name: SessionCookie}},
+	}
+	return document
+}
+
+// ids, as the BindObjectIds middleware reads them, are ObjectIDs
+func pathParameter(name string) Parameter {
+	parameter := Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}}
+	if name == "id" || strings.HasSuffix(name, "Id") {
+		parameter.Schema = objectIDSchema()
+	}
+	return parameter
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
@@ -0,0 +1,351 @@
+package openapi
+
+import (
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// a JSON schema as far as the models need one
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+}
+
+const componentPrefix = "#/components/schemas/"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	pageType     = reflect.TypeOf(repositories.Page{})
+	errorModel   = apierrors.Error{}
+)
+
+func objectIDSchema() *Schema {
+	return &Schema{Type: "string", Pattern: "^[0-9a-fA-F]{24}$", Description: "ObjectID"}
+}
+
+// turns the structs of the models into component schemas
+type generator struct {
+	// named structs in the order they are found
+	types      []reflect.Type
+	names      map[reflect.Type]string
+	creates    map[reflect.Type]bool
+	components map[string]*Schema
+}
+
+func newGenerator() *generator {
+	return &generator{
+		names:      map[reflect.Type]string{},
+		creates:    map[reflect.Type]bool{},
+		components: map[string]*Schema{},
+	}
+}
+
+// finds the named structs of a Doc value
+func (g *generator) collect(value interface{}) {
+	switch x := value.(type) {
+	case nil:
+	case page:
+		g.collectType(x.item)
+	default:
+		g.collectType(reflect.TypeOf(value))
+	}
+}
+
+func (g *generator) collectType(t reflect.Type) {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return
+	}
+	if t.Name() != "" {
+		if _, ok := g.names[t]; ok {
+			return
+		}
+		g.names[t] = ""
+		g.types = append(g.types, t)
+	}
+	for i := 0; i < t.NumField(); i++ {
+		g.collectType(t.Field(i).Type)
+	}
+}
+
+// names the structs by their type name, names used in several packages get the package name in front,
+// e.g. AircraftGeneral and AirlineGeneral
+func (g *generator) name() {
+	count := map[string]int{}
+	for _, t := range g.types {
+		count[upperFirst(t.Name())]++
+	}
+	for _, t := range g.types {
+		name := upperFirst(t.Name())
+		if count[name] > 1 {
+			name = upperFirst(path.Base(t.PkgPath())) + name
+		}
+		g.names[t] = name
+	}
+}
+
+// the schema of a Doc value, structs are referenced
+func (g *generator) body(value interface{}, create bool) *Schema {
+	p, ok := value.(page)
+	if !ok {
+		return g.schema(reflect.TypeOf(value), create)
+	}
+	name := g.names[p.item] + "Page"
+	if _, ok := g.components[name]; !ok {
+		schema := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{"items": {Type: "array", Items: g.schema(p.item, false)}},
+			Required:   []string{"items"},
+		}
+		g.fields(pageType, false, schema)
+		g.components[name] = schema
+	}
+	return &Schema{Ref: componentPrefix + name}
+}
+
+func (g *generator) schema(t reflect.Type, create bool) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return objectIDSchema()
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := g.schema(t.Elem(), create)
+		if schema.Ref == "" {
+			schema.Nullable = true
+		}
+		return schema
+	case reflect.Struct:
+		if t.Name() == "" {
+			schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+			g.fields(t, create, schema)
+			return schema
+		}
+		return &Schema{Ref: componentPrefix + g.component(t, create)}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem(), create)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem(), create)}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	}
+	// interface{} and anything else can be any value
+	return &Schema{}
+}
+
+// the component of a named struct, structs with create rules have a second component for create requests
+func (g *generator) component(t reflect.Type, create bool) string {
+	name, ok := g.names[t]
+	if !ok || name == "" {
+		name = upperFirst(t.Name())
+		g.names[t] = name
+	}
+	create = create && g.hasCreateRules(t, map[reflect.Type]bool{})
+	if create {
+		name += "Create"
+	}
+	if _, ok := g.components[name]; !ok {
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		// added before the fields so recursive types end
+		g.components[name] = schema
+		g.fields(t, create, schema)
+	}
+	return name
+}
+
+func (g *generator) hasCreateRules(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if has, ok := g.creates[t]; ok {
+		return has
+	}
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	has := false
+	for i := 0; i < t.NumField() && !has; i++ {
+		field := t.Field(i)
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Ptr || fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Map {
+			fieldType = fieldType.Elem()
+		}
+		has = field.Tag.Get("create") != "" ||
+			(fieldType.Kind() == reflect.Struct && fieldType != timeType && g.hasCreateRules(fieldType, visited))
+	}
+	g.creates[t] = has
+	return has
+}
+
+// adds the json fields of a struct to an object schema, embedded structs are flattened
+func (g *generator) fields(t reflect.Type, create bool, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				g.fields(embedded, create, schema)
+				continue
+			}
+		}
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := g.schema(field.Type, create)
+		required := rules(property, field.Tag.Get("binding"))
+		if create && rules(property, field.Tag.Get("create")) {
+			required = true
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+	sort.Strings(schema.Required)
+}
+
+// applies the validation rules of a tag to the schema of a field, tells if the field is required
+func rules(schema *Schema, tag string) bool {
+	required := false
+	if tag == "" {
+		return false
+	}
+	for _, rule := range strings.Split(tag, ",") {
+		name, param := rule, ""
+		if i := strings.Index(rule, "="); i >= 0 {
+			name, param = rule[:i], rule[i+1:]
+		}
+		if name == "required" {
+			required = true
+			continue
+		}
+		// the rules of the elements are not shown and references cannot have siblings
+		if name == "dive" {
+			break
+		}
+		if schema.Ref != "" {
+			continue
+		}
+		value, _ := strconv.ParseFloat(param, 64)
+		switch name {
+		case "min", "gte", "gt":
+			bound(schema, value, true)
+			schema.ExclusiveMinimum = name == "gt"
+		case "max", "lte", "lt":
+			bound(schema, value, false)
+			schema.ExclusiveMaximum = name == "lt"
+		case "oneof":
+			schema.Enum = strings.Fields(param)
+		case "email":
+			schema.Format = "email"
+		case "nefield":
+			describe(schema, "must differ from "+lowerFirst(param))
+		default:
+			if pattern, ok := validation.Pattern(rule); ok {
+				schema.Pattern = pattern
+				describe(schema, "matched regardless of case")
+			}
+		}
+	}
+	return required
+}
+
+// a lower or upper bound of a length, a number of items or a value
+func bound(schema *Schema, value float64, lower bool) {
+	n := int(value)
+	switch {
+	case schema.Type == "string" && lower:
+		schema.MinLength = &n
+	case schema.Type == "string":
+		schema.MaxLength = &n
+	case schema.Type == "array" && lower:
+		schema.MinItems = &n
+	case schema.Type == "array":
+		schema.MaxItems = &n
+	case lower:
+		schema.Minimum = &value
+	default:
+		schema.Maximum = &value
+	}
+}
+
+func describe(schema *Schema, text string) {
+	if schema.Description != "" {
+		text = schema.Description + ", " + text
+	}
+	schema.Description = text
+}
+
+func float(value float64) *float64 {
+	return &value
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+	r[0] = unicode.ToLower(r[0])
+	return string(r)
+}
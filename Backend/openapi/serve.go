@@ -0,0 +1,35 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed swagger.html
+var swaggerUI []byte
+
+// Serve adds GET /openapi.json with the document of the routes of router and GET /docs with Swagger UI,
+// the page is bundled and loads the swagger-ui-dist scripts of a pinned version.
+// The document is built on the first request, after every route has been added
+func Serve(router *gin.Engine, info Info, docs map[string]Doc) {
+	var once sync.Once
+	var document []byte
+	var err error
+	router.GET("/openapi.json", func(c *gin.Context) {
+		once.Do(func() {
+			document, err = json.Marshal(Build(info, router.Routes(), docs))
+		})
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		c.Data(http.StatusOK, "application/json", document)
+	})
+	router.GET("/docs", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", swaggerUI)
+	})
+}
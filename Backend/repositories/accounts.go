@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// the balances of accounts that have no document of their own, like the system account,
+// one document per account type
+type AccountRepository interface {
+	// changes the balance by amount in one update and returns it, a missing account starts at 0
+	Add(ctx context.Context, accountType string, amount int) (int, error)
+	// creates the account with balance unless it exists, returns its balance
+	Open(ctx context.Context, accountType string, balance int) (int, error)
+}
+
+type accountRepository struct {
+	base
+}
+
+// the stored balance of an account
+type accountBalance struct {
+	Balance int `bson:"balance"`
+}
+
+func (r accountRepository) Add(ctx context.Context, accountType string, amount int) (int, error) {
+	return r.upsertBalance(ctx, accountType, Change{Inc: bson.M{"balance": amount}})
+}
+
+func (r accountRepository) Open(ctx context.Context, accountType string, balance int) (int, error) {
+	return r.upsertBalance(ctx, accountType, Change{SetOnInsert: bson.M{"balance": balance}})
+}
+
+func (r accountRepository) upsertBalance(ctx context.Context, accountType string, change Change) (int, error) {
+	document, err := r.upsert(ctx, bson.M{"type": accountType}, change)
+	// a concurrent upsert inserted the account first, the unique index turned this one down
+	if err == ErrDuplicate {
+		document, err = r.upsert(ctx, bson.M{"type": accountType}, change)
+	}
+	if err != nil {
+		return 0, err
+	}
+	var result accountBalance
+	err = bson.Unmarshal(document, &result)
+	return result.Balance, err
+}
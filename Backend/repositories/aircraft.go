@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AircraftRepository interface {
+	// equality filter on (dotted) fields, nil finds everything
+	Find(ctx context.Context, filter bson.M) ([]aircraft.Aircraft, error)
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]aircraft.Aircraft, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (aircraft.Aircraft, error)
+	Insert(ctx context.Context, airplane aircraft.Aircraft) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	Searchable
+}
+
+type aircraftRepository struct {
+	base
+}
+
+func (r aircraftRepository) Find(ctx context.Context, filter bson.M) ([]aircraft.Aircraft, error) {
+	documents, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]aircraft.Aircraft, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r aircraftRepository) List(ctx context.Context, query Query) ([]aircraft.Aircraft, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]aircraft.Aircraft, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r aircraftRepository) FindById(ctx context.Context, id primitive.ObjectID) (aircraft.Aircraft, error) {
+	var result aircraft.Aircraft
+	document, err := r.findOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r aircraftRepository) Insert(ctx context.Context, airplane aircraft.Aircraft) error {
+	return r.insert(ctx, airplane)
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AirlineRepository interface {
+	// equality filter on (dotted) fields, nil finds everything
+	Find(ctx context.Context, filter bson.M) ([]airline.Airline, error)
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]airline.Airline, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (airline.Airline, error)
+	Insert(ctx context.Context, airlineData airline.Airline) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	// changes the document like Apply and returns it as changed
+	Modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (airline.Airline, error)
+	Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	Searchable
+}
+
+type airlineRepository struct {
+	base
+}
+
+func (r airlineRepository) Find(ctx context.Context, filter bson.M) ([]airline.Airline, error) {
+	documents, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]airline.Airline, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r airlineRepository) List(ctx context.Context, query Query) ([]airline.Airline, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]airline.Airline, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r airlineRepository) FindById(ctx context.Context, id primitive.ObjectID) (airline.Airline, error) {
+	var result airline.Airline
+	document, err := r.findOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r airlineRepository) Insert(ctx context.Context, airlineData airline.Airline) error {
+	return r.insert(ctx, airlineData)
+}
+
+func (r airlineRepository) Modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (airline.Airline, error) {
+	var result airline.Airline
+	document, err := r.modify(ctx, id, where, change)
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
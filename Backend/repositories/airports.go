@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AirportRepository interface {
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]airport.Airport, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (airport.Airport, error)
+	// the first airport matching the equality filter on (dotted) fields
+	FindOne(ctx context.Context, filter bson.M) (airport.Airport, error)
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	// upserts airports by their filters, inserted and updated count the airports that changed
+	UpsertMany(ctx context.Context, upserts []Upsert) (inserted int64, updated int64, err error)
+	Searchable
+}
+
+type airportRepository struct {
+	base
+}
+
+func (r airportRepository) List(ctx context.Context, query Query) ([]airport.Airport, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]airport.Airport, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r airportRepository) FindById(ctx context.Context, id primitive.ObjectID) (airport.Airport, error) {
+	return r.FindOne(ctx, bson.M{"_id": id})
+}
+
+func (r airportRepository) FindOne(ctx context.Context, filter bson.M) (airport.Airport, error) {
+	var result airport.Airport
+	document, err := r.findOne(ctx, filter)
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r airportRepository) UpsertMany(ctx context.Context, upserts []Upsert) (int64, int64, error) {
+	return r.upsertMany(ctx, upserts)
+}
@@ -0,0 +1,55 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// one check program per aircraft model
+type CheckProgramRepository interface {
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]aircraft.CheckProgram, Page, error)
+	FindByModel(ctx context.Context, model string) (aircraft.CheckProgram, error)
+	// changes the program of the model, a new document gets the SetOnInsert fields too
+	Upsert(ctx context.Context, model string, change Change) (aircraft.CheckProgram, error)
+}
+
+type checkProgramRepository struct {
+	base
+}
+
+func (r checkProgramRepository) List(ctx context.Context, query Query) ([]aircraft.CheckProgram, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]aircraft.CheckProgram, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r checkProgramRepository) FindByModel(ctx context.Context, model string) (aircraft.CheckProgram, error) {
+	var result aircraft.CheckProgram
+	document, err := r.findOne(ctx, bson.M{"model": model})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r checkProgramRepository) Upsert(ctx context.Context, model string, change Change) (aircraft.CheckProgram, error) {
+	var result aircraft.CheckProgram
+	document, err := r.upsert(ctx, bson.M{"model": model}, change)
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
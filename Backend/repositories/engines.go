@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type EngineRepository interface {
+	// equality filter on (dotted) fields, nil finds everything
+	Find(ctx context.Context, filter bson.M) ([]aircraft.Engine, error)
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]aircraft.Engine, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (aircraft.Engine, error)
+	Insert(ctx context.Context, engine aircraft.Engine) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type engineRepository struct {
+	base
+}
+
+func (r engineRepository) Find(ctx context.Context, filter bson.M) ([]aircraft.Engine, error) {
+	documents, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]aircraft.Engine, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r engineRepository) List(ctx context.Context, query Query) ([]aircraft.Engine, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]aircraft.Engine, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r engineRepository) FindById(ctx context.Context, id primitive.ObjectID) (aircraft.Engine, error) {
+	var result aircraft.Engine
+	document, err := r.findOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r engineRepository) Insert(ctx context.Context, engine aircraft.Engine) error {
+	return r.insert(ctx, engine)
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// the entries of the ledger, entries are never changed once they are written
+type LedgerRepository interface {
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]ledger.Entry, Page, error)
+	Insert(ctx context.Context, entry ledger.Entry) error
+	// only undoes an entry that is part of a failed posting
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// derives the balance of an account from its entries
+	Balance(ctx context.Context, account ledger.Account) (int, error)
+}
+
+type ledgerRepository struct {
+	base
+}
+
+func (r ledgerRepository) List(ctx context.Context, query Query) ([]ledger.Entry, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]ledger.Entry, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r ledgerRepository) Insert(ctx context.Context, entry ledger.Entry) error {
+	return r.insert(ctx, entry)
+}
+
+func (r ledgerRepository) Balance(ctx context.Context, account ledger.Account) (int, error) {
+	conditions := []Condition{
+		{Field: "accountType", Operator: Eq, Values: []interface{}{account.Type}},
+		// entries of the system account have no account id
+		{Field: "account", Operator: Exists, Values: []interface{}{false}}}
+	if account.ID != primitive.NilObjectID {
+		conditions[1] = Condition{Field: "account", Operator: Eq, Values: []interface{}{account.ID}}
+	}
+	sum, err := r.sum(ctx, conditions, "amount")
+	return int(sum), err
+}
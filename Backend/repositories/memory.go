@@ -0,0 +1,702 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var errDuplicateId = errors.New("A document with the same id already exists")
+
+// in-memory store for tests and local runs, documents go through the same
+// bson encoding as with Mongo so the typed repositories behave the same way
+type memoryStore struct {
+	mutex     sync.RWMutex
+	documents map[primitive.ObjectID]bson.M
+	// insertion order, Mongo returns documents in natural order
+	order []primitive.ObjectID
+	// fields with a unique index
+	unique []string
+}
+
+func newMemoryStore(unique ...string) *memoryStore {
+	return &memoryStore{documents: make(map[primitive.ObjectID]bson.M), unique: unique}
+}
+
+// tells whether another document holds the same value of a unique field, like a unique index would
+func (s *memoryStore) taken(id primitive.ObjectID, document bson.M) bool {
+	for _, field := range s.unique {
+		value, ok := lookup(document, field)
+		if !ok {
+			continue
+		}
+		for otherId, other := range s.documents {
+			if otherId == id {
+				continue
+			}
+			if otherValue, ok := lookup(other, field); ok && equal(value, otherValue) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// round-trips a value through bson so filters and documents hold the same types
+func normalize(value interface{}) (interface{}, error) {
+	data, err := bson.Marshal(bson.M{"v": value})
+	if err != nil {
+		return nil, err
+	}
+	var wrapper bson.M
+	if err = bson.Unmarshal(data, &wrapper); err != nil {
+		return nil, err
+	}
+	return toM(wrapper["v"]), nil
+}
+
+// nested documents are kept as bson.M
+func toM(value interface{}) interface{} {
+	switch v := value.(type) {
+	case primitive.D:
+		m := make(bson.M, len(v))
+		for _, x := range v {
+			m[x.Key] = toM(x.Value)
+		}
+		return m
+	case bson.M:
+		for key, x := range v {
+			v[key] = toM(x)
+		}
+		return v
+	case primitive.A:
+		for i, x := range v {
+			v[i] = toM(x)
+		}
+		return v
+	}
+	return value
+}
+
+func lookup(document bson.M, path string) (interface{}, bool) {
+	var current interface{} = document
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(bson.M)
+		if !ok {
+			return nil, false
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+func equal(a interface{}, b interface{}) bool {
+	if reflect.DeepEqual(a, b) {
+		return true
+	}
+	// ints may be stored as int32 or int64
+	x, ok := number(a)
+	if !ok {
+		return false
+	}
+	y, ok := number(b)
+	return ok && x == y
+}
+
+func number(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// equality on every filter field, a field holding an array matches any of its elements
+func matches(document bson.M, filter bson.M) bool {
+	for path, want := range filter {
+		got, ok := lookup(document, path)
+		if !ok {
+			if want != nil {
+				return false
+			}
+			continue
+		}
+		if equal(got, want) {
+			continue
+		}
+		array, ok := got.(primitive.A)
+		if !ok {
+			return false
+		}
+		found := false
+		for _, x := range array {
+			if equal(x, want) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *memoryStore) find(ctx context.Context, filter bson.M) ([]bson.Raw, error) {
+	normalized, err := normalize(filter)
+	if err != nil {
+		return nil, err
+	}
+	filterM, _ := normalized.(bson.M)
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	documents := make([]bson.Raw, 0)
+	for _, id := range s.order {
+		document := s.documents[id]
+		if !matches(document, filterM) {
+			continue
+		}
+		data, err := bson.Marshal(document)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, data)
+	}
+	return documents, nil
+}
+
+func (s *memoryStore) findOne(ctx context.Context, filter bson.M) (bson.Raw, error) {
+	documents, err := s.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(documents) == 0 {
+		return nil, ErrNotFound
+	}
+	return documents[0], nil
+}
+
+// conditions holding the same types as the documents
+func normalizeConditions(conditions []Condition) ([]Condition, error) {
+	normalized := make([]Condition, len(conditions))
+	for i, x := range conditions {
+		if x.Operator == Or {
+			nested := make([]Condition, len(x.Values))
+			for j, y := range x.Values {
+				nested[j] = y.(Condition)
+			}
+			nested, err := normalizeConditions(nested)
+			if err != nil {
+				return nil, err
+			}
+			values := make([]interface{}, len(nested))
+			for j, y := range nested {
+				values[j] = y
+			}
+			normalized[i] = Condition{Operator: Or, Values: values}
+			continue
+		}
+		values, err := normalize(x.Values)
+		if err != nil {
+			return nil, err
+		}
+		array, _ := values.(primitive.A)
+		normalized[i] = Condition{Field: x.Field, Operator: x.Operator, Values: array}
+	}
+	return normalized, nil
+}
+
+// the documents satisfying every condition in insertion order, the caller holds the lock
+func (s *memoryStore) matching(conditions []Condition) []bson.M {
+	var found []bson.M
+	for _, id := range s.order {
+		document := s.documents[id]
+		matched := true
+		for _, x := range conditions {
+			if !satisfies(document, x) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			found = append(found, document)
+		}
+	}
+	return found
+}
+
+func (s *memoryStore) match(ctx context.Context, conditions []Condition, limit int) ([]bson.Raw, error) {
+	conditions, err := normalizeConditions(conditions)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	documents := make([]bson.Raw, 0)
+	for _, x := range s.matching(conditions) {
+		if limit > 0 && len(documents) == limit {
+			break
+		}
+		data, err := bson.Marshal(x)
+		if err != nil {
+			return nil, err
+		}
+		documents = append(documents, data)
+	}
+	return documents, nil
+}
+
+// there is no text index in memory, searches get their matches from the word patterns
+func (s *memoryStore) text(ctx context.Context, text string, conditions []Condition, limit int) ([]bson.Raw, error) {
+	return make([]bson.Raw, 0), nil
+}
+
+func (s *memoryStore) list(ctx context.Context, query Query) ([]bson.Raw, Page, error) {
+	conditions, err := normalizeConditions(query.Conditions)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	sorts := sortFields(query.Sort)
+	var after []interface{}
+	if query.After != "" {
+		if after, err = decodeCursor(query.After, sorts); err != nil {
+			return nil, Page{}, err
+		}
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	found := s.matching(conditions)
+	total := int64(len(found))
+	// orders a document against the sort values of another one or of the cursor
+	order := func(a bson.M, values []interface{}) int {
+		for i, x := range sorts {
+			value, _ := lookup(a, x.Field)
+			result := compare(value, values[i])
+			if x.Descending {
+				result = -result
+			}
+			if result != 0 {
+				return result
+			}
+		}
+		return 0
+	}
+	keys := func(document bson.M) []interface{} {
+		values := make([]interface{}, len(sorts))
+		for i, x := range sorts {
+			values[i], _ = lookup(document, x.Field)
+		}
+		return values
+	}
+	sort.SliceStable(found, func(i, j int) bool {
+		return order(found[i], keys(found[j])) < 0
+	})
+	documents := make([]bson.Raw, 0)
+	for _, x := range found {
+		if after != nil && order(x, after) <= 0 {
+			continue
+		}
+		if query.Limit > 0 && len(documents) > query.Limit {
+			break
+		}
+		data, err := bson.Marshal(x)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		documents = append(documents, data)
+	}
+	return paginate(documents, query.Limit, sorts, total)
+}
+
+func (s *memoryStore) insert(ctx context.Context, document interface{}) error {
+	normalized, err := normalize(document)
+	if err != nil {
+		return err
+	}
+	documentM, ok := normalized.(bson.M)
+	if !ok {
+		return errors.New("Documents must encode to a bson document")
+	}
+	id, ok := documentM["_id"].(primitive.ObjectID)
+	if !ok {
+		return errors.New("Documents need an ObjectID _id")
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.documents[id]; ok {
+		return errDuplicateId
+	}
+	if s.taken(id, documentM) {
+		return ErrDuplicate
+	}
+	s.documents[id] = documentM
+	s.order = append(s.order, id)
+	return nil
+}
+
+func (s *memoryStore) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	return s.apply(ctx, id, nil, Change{Set: fields})
+}
+
+func (s *memoryStore) apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error {
+	_, err := s.modify(ctx, id, where, change)
+	return err
+}
+
+func (s *memoryStore) modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (bson.Raw, error) {
+	where, err := normalizeConditions(where)
+	if err != nil {
+		return nil, err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	document, ok := s.documents[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	for _, x := range where {
+		if !satisfies(document, x) {
+			return nil, ErrNotFound
+		}
+	}
+	if _, err = s.replace(id, document, change, false); err != nil {
+		return nil, err
+	}
+	return bson.Marshal(s.documents[id])
+}
+
+func (s *memoryStore) upsert(ctx context.Context, filter bson.M, change Change) (bson.Raw, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	document, _, _, err := s.upsertOne(filter, change)
+	if err != nil {
+		return nil, err
+	}
+	return bson.Marshal(document)
+}
+
+func (s *memoryStore) upsertMany(ctx context.Context, upserts []Upsert) (int64, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var inserted, updated int64
+	for _, x := range upserts {
+		_, isNew, modified, err := s.upsertOne(x.Filter, x.Change)
+		if err != nil {
+			return inserted, updated, err
+		}
+		if isNew {
+			inserted++
+		} else if modified {
+			updated++
+		}
+	}
+	return inserted, updated, nil
+}
+
+// changes the first document matching the filter or inserts one from the filter fields,
+// the caller holds the lock
+func (s *memoryStore) upsertOne(filter bson.M, change Change) (bson.M, bool, bool, error) {
+	normalized, err := normalize(filter)
+	if err != nil {
+		return nil, false, false, err
+	}
+	filterM, _ := normalized.(bson.M)
+	for _, id := range s.order {
+		if matches(s.documents[id], filterM) {
+			modified, err := s.replace(id, s.documents[id], change, false)
+			return s.documents[id], false, modified, err
+		}
+	}
+	document := bson.M{}
+	for path, value := range filterM {
+		setPath(document, path, value)
+	}
+	if err = applyChange(document, change, true); err != nil {
+		return nil, false, false, err
+	}
+	id, ok := document["_id"].(primitive.ObjectID)
+	if !ok {
+		id = primitive.NewObjectID()
+		document["_id"] = id
+	}
+	if s.taken(id, document) {
+		return nil, false, false, ErrDuplicate
+	}
+	s.documents[id] = document
+	s.order = append(s.order, id)
+	return document, true, true, nil
+}
+
+// puts a changed copy of the document in its place unless it takes a unique value of another one,
+// tells whether anything changed. The caller holds the lock
+func (s *memoryStore) replace(id primitive.ObjectID, document bson.M, change Change, insert bool) (bool, error) {
+	copied, err := normalize(document)
+	if err != nil {
+		return false, err
+	}
+	changed := copied.(bson.M)
+	if err = applyChange(changed, change, insert); err != nil {
+		return false, err
+	}
+	if s.taken(id, changed) {
+		return false, ErrDuplicate
+	}
+	s.documents[id] = changed
+	return !reflect.DeepEqual(document, changed), nil
+}
+
+func applyChange(document bson.M, change Change, insert bool) error {
+	sets := []bson.M{change.Set}
+	if insert {
+		sets = append(sets, change.SetOnInsert)
+	}
+	for _, fields := range sets {
+		for path, value := range fields {
+			normalized, err := normalize(value)
+			if err != nil {
+				return err
+			}
+			setPath(document, path, normalized)
+		}
+	}
+	for _, path := range change.Unset {
+		unsetPath(document, path)
+	}
+	for path, value := range change.Inc {
+		normalized, err := normalize(value)
+		if err != nil {
+			return err
+		}
+		current, _ := lookup(document, path)
+		sum, ok := add(current, normalized)
+		if !ok {
+			return errors.New("Cannot increment the field " + path)
+		}
+		setPath(document, path, sum)
+	}
+	for path, value := range change.Push {
+		normalized, err := normalize(value)
+		if err != nil {
+			return err
+		}
+		// like $push, a missing field becomes an array but a null one is an error
+		current, found := lookup(document, path)
+		array, ok := current.(primitive.A)
+		if found && !ok {
+			return errors.New("The field " + path + " is not an array")
+		}
+		setPath(document, path, append(array, normalized))
+	}
+	return nil
+}
+
+// sums numbers like $inc does, a missing value counts as 0 and integers stay integers
+func add(a interface{}, b interface{}) (interface{}, bool) {
+	integer := func(value interface{}) (int64, bool) {
+		switch v := value.(type) {
+		case nil:
+			return 0, true
+		case int32:
+			return int64(v), true
+		case int64:
+			return v, true
+		}
+		return 0, false
+	}
+	if x, ok := integer(a); ok {
+		if y, ok := integer(b); ok {
+			return x + y, true
+		}
+	}
+	x, ok := number(a)
+	if a == nil {
+		x, ok = 0, true
+	}
+	if !ok {
+		return nil, false
+	}
+	y, ok := number(b)
+	return x + y, ok
+}
+
+func setPath(document bson.M, path string, value interface{}) {
+	keys := strings.Split(path, ".")
+	current := document
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(bson.M)
+		if !ok {
+			next = bson.M{}
+			current[key] = next
+		}
+		current = next
+	}
+	current[keys[len(keys)-1]] = value
+}
+
+func unsetPath(document bson.M, path string) {
+	keys := strings.Split(path, ".")
+	current := document
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := current[key].(bson.M)
+		if !ok {
+			return
+		}
+		current = next
+	}
+	delete(current, keys[len(keys)-1])
+}
+
+// the array of a (dotted) field, created when the field is missing
+func (s *memoryStore) array(id primitive.ObjectID, field string) (bson.M, string, primitive.A, error) {
+	document, ok := s.documents[id]
+	if !ok {
+		return nil, "", nil, ErrNotFound
+	}
+	keys := strings.Split(field, ".")
+	parent := document
+	for _, key := range keys[:len(keys)-1] {
+		next, ok := parent[key].(bson.M)
+		if !ok {
+			next = bson.M{}
+			parent[key] = next
+		}
+		parent = next
+	}
+	key := keys[len(keys)-1]
+	switch current := parent[key].(type) {
+	case primitive.A:
+		return parent, key, current, nil
+	case nil:
+		return parent, key, primitive.A{}, nil
+	}
+	return nil, "", nil, errors.New("The field " + field + " is not an array")
+}
+
+func (s *memoryStore) addToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	normalized, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	parent, key, array, err := s.array(id, field)
+	if err != nil {
+		return err
+	}
+	for _, x := range array {
+		if equal(x, normalized) {
+			parent[key] = array
+			return nil
+		}
+	}
+	parent[key] = append(array, normalized)
+	return nil
+}
+
+func (s *memoryStore) pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	normalized, err := normalize(value)
+	if err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	parent, key, array, err := s.array(id, field)
+	if err != nil {
+		return err
+	}
+	kept := primitive.A{}
+	for _, x := range array {
+		if !equal(x, normalized) {
+			kept = append(kept, x)
+		}
+	}
+	parent[key] = kept
+	return nil
+}
+
+func (s *memoryStore) toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error {
+	normalized, err := normalize(values)
+	if err != nil {
+		return err
+	}
+	toggled, _ := normalized.(primitive.A)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.documents[id]; !ok {
+		return ErrNotFound
+	} else if len(toggled) == 0 {
+		return nil
+	}
+	parent, key, array, err := s.array(id, field)
+	if err != nil {
+		return err
+	}
+	contains := func(values primitive.A, value interface{}) bool {
+		for _, x := range values {
+			if equal(x, value) {
+				return true
+			}
+		}
+		return false
+	}
+	if !contains(array, toggled[0]) {
+		parent[key] = append(array, toggled...)
+		return nil
+	}
+	// the difference is a set like with $setDifference
+	kept := primitive.A{}
+	for _, x := range array {
+		if !contains(toggled, x) && !contains(kept, x) {
+			kept = append(kept, x)
+		}
+	}
+	parent[key] = kept
+	return nil
+}
+
+func (s *memoryStore) delete(ctx context.Context, id primitive.ObjectID) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if _, ok := s.documents[id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.documents, id)
+	for i, x := range s.order {
+		if x == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) sum(ctx context.Context, conditions []Condition, field string) (float64, error) {
+	conditions, err := normalizeConditions(conditions)
+	if err != nil {
+		return 0, err
+	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	var sum float64
+	for _, document := range s.matching(conditions) {
+		value, _ := lookup(document, field)
+		// like $sum, values that are not numbers are skipped
+		if x, ok := number(value); ok {
+			sum += x
+		}
+	}
+	return sum, nil
+}
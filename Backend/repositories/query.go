@@ -0,0 +1,337 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidCursor = errors.New("Invalid cursor")
+
+// operators of a filter condition
+const (
+	Eq  = "eq"
+	Ne  = "ne"
+	Gt  = "gt"
+	Gte = "gte"
+	Lt  = "lt"
+	Lte = "lte"
+	In  = "in"
+	// takes true or false
+	Exists = "exists"
+	// takes a case-insensitive regular expression
+	Match = "match"
+	// takes conditions, one of them has to hold, the field is not used
+	Or = "or"
+)
+
+// a (dotted) field compared with its values, eq and in match any of the values and ne none of them,
+// the other operators take one value
+type Condition struct {
+	Field    string
+	Operator string
+	Values   []interface{}
+}
+
+type Sort struct {
+	Field      string
+	Descending bool
+}
+
+// a page of documents matching every condition, After is the Next of the previous page
+// and a Limit of 0 lists everything
+type Query struct {
+	Conditions []Condition
+	Sort       []Sort
+	Limit      int
+	After      string
+}
+
+// where a page ends and how many documents match the query in total
+type Page struct {
+	Next  string `json:"next,omitempty"`
+	Total int64  `json:"total"`
+}
+
+// the sort of the query with _id last, so every document has a distinct position
+func sortFields(sorts []Sort) []Sort {
+	for _, x := range sorts {
+		if x.Field == "_id" {
+			return sorts
+		}
+	}
+	return append(append([]Sort(nil), sorts...), Sort{Field: "_id"})
+}
+
+func mongoFilter(conditions []Condition) bson.M {
+	if len(conditions) == 0 {
+		return bson.M{}
+	}
+	and := bson.A{}
+	for _, x := range conditions {
+		and = append(and, mongoCondition(x))
+	}
+	return bson.M{"$and": and}
+}
+
+func mongoCondition(condition Condition) bson.M {
+	switch condition.Operator {
+	case Ne:
+		return bson.M{condition.Field: bson.M{"$nin": condition.Values}}
+	case Gt, Gte, Lt, Lte:
+		return bson.M{condition.Field: bson.M{"$" + condition.Operator: condition.Values[0]}}
+	case Exists:
+		return bson.M{condition.Field: bson.M{"$exists": condition.Values[0]}}
+	case Match:
+		return bson.M{condition.Field: primitive.Regex{Pattern: condition.Values[0].(string), Options: "i"}}
+	case Or:
+		or := bson.A{}
+		for _, x := range condition.Values {
+			or = append(or, mongoCondition(x.(Condition)))
+		}
+		return bson.M{"$or": or}
+	}
+	return bson.M{condition.Field: bson.M{"$in": condition.Values}}
+}
+
+// documents after the cursor position in sort order, null sorts before any value as it does in Mongo
+func mongoCursorFilter(sorts []Sort, values []interface{}) bson.M {
+	or := bson.A{}
+	for i, x := range sorts {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[sorts[j].Field] = values[j]
+		}
+		switch {
+		case values[i] == nil && x.Descending:
+			continue
+		case values[i] == nil:
+			clause[x.Field] = bson.M{"$ne": nil}
+		case x.Descending:
+			clause["$or"] = bson.A{bson.M{x.Field: bson.M{"$lt": values[i]}}, bson.M{x.Field: nil}}
+		default:
+			clause[x.Field] = bson.M{"$gt": values[i]}
+		}
+		or = append(or, clause)
+	}
+	if len(or) == 0 {
+		// the cursor is at the very end
+		return bson.M{"_id": bson.M{"$exists": false}}
+	}
+	return bson.M{"$or": or}
+}
+
+func encodeCursor(values []interface{}) (string, error) {
+	data, err := bson.Marshal(bson.M{"v": values})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(cursor string, sorts []Sort) ([]interface{}, error) {
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var wrapper bson.M
+	if err = bson.Unmarshal(data, &wrapper); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	values, ok := toM(wrapper["v"]).(primitive.A)
+	if !ok || len(values) != len(sorts) {
+		return nil, ErrInvalidCursor
+	}
+	return values, nil
+}
+
+// cuts the extra document fetched past the limit and turns the last one kept into the next cursor
+func paginate(documents []bson.Raw, limit int, sorts []Sort, total int64) ([]bson.Raw, Page, error) {
+	page := Page{Total: total}
+	if limit <= 0 || len(documents) <= limit {
+		return documents, page, nil
+	}
+	documents = documents[:limit]
+	var last bson.M
+	if err := bson.Unmarshal(documents[limit-1], &last); err != nil {
+		return nil, page, err
+	}
+	last = toM(last).(bson.M)
+	values := make([]interface{}, len(sorts))
+	for i, x := range sorts {
+		values[i], _ = lookup(last, x.Field)
+	}
+	next, err := encodeCursor(values)
+	if err != nil {
+		return nil, page, err
+	}
+	page.Next = next
+	return documents, page, nil
+}
+
+// order of values of different types, the way Mongo sorts them
+func typeRank(value interface{}) int {
+	switch value.(type) {
+	case nil, primitive.Null, primitive.Undefined:
+		return 0
+	case int32, int64, float64, primitive.Decimal128:
+		return 1
+	case string, primitive.Symbol:
+		return 2
+	case bson.M:
+		return 3
+	case primitive.A:
+		return 4
+	case primitive.Binary:
+		return 5
+	case primitive.ObjectID:
+		return 6
+	case bool:
+		return 7
+	case primitive.DateTime, time.Time:
+		return 8
+	case primitive.Timestamp:
+		return 9
+	}
+	return 10
+}
+
+// -1, 0 or 1 like Mongo orders a and b, values of the same type without a natural order are equal
+func compare(a interface{}, b interface{}) int {
+	if ra, rb := typeRank(a), typeRank(b); ra != rb {
+		return sign(ra - rb)
+	}
+	if x, ok := number(a); ok {
+		y, _ := number(b)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+		return 0
+	}
+	switch x := a.(type) {
+	case string:
+		return stringOrder(x, b.(string))
+	case primitive.ObjectID:
+		return stringOrder(x.Hex(), b.(primitive.ObjectID).Hex())
+	case bool:
+		y := b.(bool)
+		if x == y {
+			return 0
+		} else if y {
+			return -1
+		}
+		return 1
+	case primitive.DateTime:
+		if y, ok := b.(primitive.DateTime); ok {
+			return sign(int(x - y))
+		}
+	}
+	return 0
+}
+
+func stringOrder(a string, b string) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// whether a document satisfies a condition, a field holding an array satisfies it when one of its elements does
+func satisfies(document bson.M, condition Condition) bool {
+	if condition.Operator == Or {
+		for _, x := range condition.Values {
+			if satisfies(document, x.(Condition)) {
+				return true
+			}
+		}
+		return false
+	}
+	got, ok := lookup(document, condition.Field)
+	candidates := []interface{}{got}
+	if array, isArray := got.(primitive.A); isArray {
+		candidates = append(candidates, array...)
+	}
+	equalsAny := func() bool {
+		if !ok {
+			return false
+		}
+		for _, x := range candidates {
+			for _, y := range condition.Values {
+				if equal(x, y) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	switch condition.Operator {
+	case Eq, In:
+		return equalsAny()
+	case Ne:
+		return !equalsAny()
+	case Exists:
+		return ok == condition.Values[0].(bool)
+	}
+	if !ok {
+		return false
+	}
+	if condition.Operator == Match {
+		pattern, err := regexp.Compile("(?i)" + condition.Values[0].(string))
+		if err != nil {
+			return false
+		}
+		for _, x := range candidates {
+			if text, isString := x.(string); isString && pattern.MatchString(text) {
+				return true
+			}
+		}
+		return false
+	}
+	want := condition.Values[0]
+	for _, x := range candidates {
+		// like Mongo, only values of the same type are compared
+		if typeRank(x) != typeRank(want) {
+			continue
+		}
+		order := compare(x, want)
+		switch condition.Operator {
+		case Gt:
+			if order > 0 {
+				return true
+			}
+		case Gte:
+			if order >= 0 {
+				return true
+			}
+		case Lt:
+			if order < 0 {
+				return true
+			}
+		case Lte:
+			if order <= 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
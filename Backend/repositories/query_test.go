@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"context"
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	id := primitive.NewObjectID()
+	at := primitive.NewDateTimeFromTime(time.Date(2026, time.October, 18, 12, 30, 0, 0, time.UTC))
+	tests := []struct {
+		name   string
+		values []interface{}
+	}{
+		{"id only", []interface{}{id}},
+		{"number and id", []interface{}{int32(42), id}},
+		{"float, string and id", []interface{}{1.5, "A320", id}},
+		{"missing value", []interface{}{nil, id}},
+		{"date and bool", []interface{}{at, true, id}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cursor, err := encodeCursor(test.values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sorts := make([]Sort, len(test.values))
+			values, err := decodeCursor(cursor, sorts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual([]interface{}(values), test.values) {
+				t.Errorf("%#v, want %#v", values, test.values)
+			}
+		})
+	}
+}
+
+func TestDecodeInvalidCursor(t *testing.T) {
+	valid, err := encodeCursor([]interface{}{"A320", primitive.NewObjectID()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notAnArray, _ := bson.Marshal(bson.M{"v": "A320"})
+	tests := []struct {
+		name   string
+		cursor string
+		sorts  int
+	}{
+		{"not base64", "not a cursor!", 2},
+		{"not bson", base64.RawURLEncoding.EncodeToString([]byte("A320")), 2},
+		{"not an array", base64.RawURLEncoding.EncodeToString(notAnArray), 2},
+		// the cursor of another sort
+		{"other sort", valid, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := decodeCursor(test.cursor, make([]Sort, test.sorts)); err != ErrInvalidCursor {
+				t.Errorf("error %v, want %v", err, ErrInvalidCursor)
+			}
+		})
+	}
+}
+
+// following the next cursors lists every document once, in the order of a single unlimited query
+func TestListPages(t *testing.T) {
+	ctx := context.Background()
+	s := newMemoryStore()
+	prices := []interface{}{3.5, nil, 1.0, 3.5, 2.0, nil, 1.0}
+	for i, x := range prices {
+		document := bson.M{"_id": primitive.NewObjectID(), "name": string(rune('g' - i))}
+		if x != nil {
+			document["price"] = x
+		}
+		if err := s.insert(ctx, document); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name  string
+		query Query
+	}{
+		{"insertion order", Query{}},
+		{"price with missing ones first", Query{Sort: []Sort{{Field: "price"}}}},
+		{"price descending", Query{Sort: []Sort{{Field: "price", Descending: true}}}},
+		{"name", Query{Sort: []Sort{{Field: "name"}}}},
+		{"filtered", Query{
+			Conditions: []Condition{{Field: "price", Operator: Exists, Values: []interface{}{true}}},
+			Sort:       []Sort{{Field: "price", Descending: true}, {Field: "name"}}}},
+	}
+	ids := func(documents []bson.Raw) []primitive.ObjectID {
+		result := make([]primitive.ObjectID, len(documents))
+		for i, x := range documents {
+			result[i] = x.Lookup("_id").ObjectID()
+		}
+		return result
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			all, page, err := s.list(ctx, test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page.Next != "" {
+				t.Errorf("next %q without a limit", page.Next)
+			}
+			for _, limit := range []int{1, 2, 3} {
+				query := test.query
+				query.Limit = limit
+				var paged []bson.Raw
+				for pages := 0; pages <= len(all); pages++ {
+					documents, page, err := s.list(ctx, query)
+					if err != nil {
+						t.Fatal(err)
+					}
+					if page.Total != int64(len(all)) {
+						t.Errorf("total %d, want %d", page.Total, len(all))
+					}
+					paged = append(paged, documents...)
+					if page.Next == "" {
+						break
+					}
+					query.After = page.Next
+				}
+				if got, want := ids(paged), ids(all); !reflect.DeepEqual(got, want) {
+					t.Errorf("pages of %d list %v, want %v", limit, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPushLikeMongo(t *testing.T) {
+	tests := []struct {
+		name     string
+		document bson.M
+		ok       bool
+	}{
+		{"missing field", bson.M{}, true},
+		{"array", bson.M{"log": primitive.A{"a"}}, true},
+		{"null", bson.M{"log": nil}, false},
+		{"string", bson.M{"log": "a"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := applyChange(test.document, Change{Push: bson.M{"log": "b"}}, false)
+			if ok := err == nil; ok != test.ok {
+				t.Fatalf("error %v, want ok %v", err, test.ok)
+			}
+			if !test.ok {
+				return
+			}
+			log := test.document["log"].(primitive.A)
+			if log[len(log)-1] != "b" {
+				t.Errorf("log %v does not end with the pushed value", log)
+			}
+		})
+	}
+}
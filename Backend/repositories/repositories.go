@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNotFound = errors.New("Not found")
+
+// a unique field of the document is already taken by another one
+var ErrDuplicate = errors.New("Duplicate key")
+
+// repositories of every aggregate, controllers get them injected at startup
+type Repositories struct {
+	Users    UserRepository
+	Aircraft AircraftRepository
+	Engines  EngineRepository
+	Airlines AirlineRepository
+	Routes   RouteRepository
+	Flights  FlightRepository
+	Reviews  ReviewRepository
+	Airports AirportRepository
+	Weather  WeatherRepository
+	// aircraft maintenance programs by model
+	CheckPrograms CheckProgramRepository
+	Ledger        LedgerRepository
+	Accounts      AccountRepository
+}
+
+// collections of the Mongo-backed repositories
+type Collections struct {
+	Users         *mongo.Collection
+	Aircraft      *mongo.Collection
+	Engines       *mongo.Collection
+	Airlines      *mongo.Collection
+	Routes        *mongo.Collection
+	Flights       *mongo.Collection
+	Reviews       *mongo.Collection
+	Airports      *mongo.Collection
+	Weather       *mongo.Collection
+	CheckPrograms *mongo.Collection
+	Ledger        *mongo.Collection
+	Accounts      *mongo.Collection
+}
+
+func NewMongoRepositories(collections Collections) Repositories {
+	return Repositories{
+		Users:         userRepository{base{mongoStore{collections.Users}}},
+		Aircraft:      aircraftRepository{base{mongoStore{collections.Aircraft}}},
+		Engines:       engineRepository{base{mongoStore{collections.Engines}}},
+		Airlines:      airlineRepository{base{mongoStore{collections.Airlines}}},
+		Routes:        routeRepository{base{mongoStore{collections.Routes}}},
+		Flights:       flightRepository{base{mongoStore{collections.Flights}}},
+		Reviews:       reviewRepository{base{mongoStore{collections.Reviews}}},
+		Airports:      airportRepository{base{mongoStore{collections.Airports}}},
+		Weather:       weatherRepository{base{mongoStore{collections.Weather}}},
+		CheckPrograms: checkProgramRepository{base{mongoStore{collections.CheckPrograms}}},
+		Ledger:        ledgerRepository{base{mongoStore{collections.Ledger}}},
+		Accounts:      accountRepository{base{mongoStore{collections.Accounts}}},
+	}
+}
+
+// repositories that keep everything in memory, for handler tests with httptest
+func NewMemoryRepositories() Repositories {
+	return Repositories{
+		Users:         userRepository{base{newMemoryStore("name")}},
+		Aircraft:      aircraftRepository{base{newMemoryStore()}},
+		Engines:       engineRepository{base{newMemoryStore()}},
+		Airlines:      airlineRepository{base{newMemoryStore()}},
+		Routes:        routeRepository{base{newMemoryStore()}},
+		Flights:       flightRepository{base{newMemoryStore()}},
+		Reviews:       reviewRepository{base{newMemoryStore()}},
+		Airports:      airportRepository{base{newMemoryStore("icao")}},
+		Weather:       weatherRepository{base{newMemoryStore("airport")}},
+		CheckPrograms: checkProgramRepository{base{newMemoryStore("model")}},
+		Ledger:        ledgerRepository{base{newMemoryStore()}},
+		Accounts:      accountRepository{base{newMemoryStore("type")}},
+	}
+}
+
+// the methods every repository shares
+type base struct {
+	store
+}
+
+// sets the given (dotted) fields, ErrNotFound when there is no such document
+func (r base) Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	return r.set(ctx, id, fields)
+}
+
+// changes the document when it also satisfies every where condition, ErrNotFound otherwise
+func (r base) Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error {
+	return r.apply(ctx, id, where, change)
+}
+
+// removes values from the array of a (dotted) field when its first value is in it and appends them otherwise,
+// values is a slice
+func (r base) Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error {
+	return r.toggle(ctx, id, field, values)
+}
+
+// adds value to the array of a (dotted) field unless it is already there
+func (r base) AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return r.addToSet(ctx, id, field, value)
+}
+
+// removes every occurrence of value from the array of a (dotted) field
+func (r base) Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return r.pull(ctx, id, field, value)
+}
+
+// ErrNotFound when there is no such document
+func (r base) Delete(ctx context.Context, id primitive.ObjectID) error {
+	return r.delete(ctx, id)
+}
+
+// the raw documents the search service ranks
+type Searchable interface {
+	// at most limit documents matching the words of text with the text index, best first,
+	// each with its text score in a score field
+	Text(ctx context.Context, text string, conditions []Condition, limit int) ([]bson.Raw, error)
+	// at most limit documents matching every condition, in no particular order
+	Match(ctx context.Context, conditions []Condition, limit int) ([]bson.Raw, error)
+}
+
+func (r base) Text(ctx context.Context, text string, conditions []Condition, limit int) ([]bson.Raw, error) {
+	return r.text(ctx, text, conditions, limit)
+}
+
+func (r base) Match(ctx context.Context, conditions []Condition, limit int) ([]bson.Raw, error) {
+	return r.match(ctx, conditions, limit)
+}
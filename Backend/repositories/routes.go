@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RouteRepository interface {
+	// equality filter on (dotted) fields, nil finds everything
+	Find(ctx context.Context, filter bson.M) ([]airline.Route, error)
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]airline.Route, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (airline.Route, error)
+	Insert(ctx context.Context, route airline.Route) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type routeRepository struct {
+	base
+}
+
+func (r routeRepository) Find(ctx context.Context, filter bson.M) ([]airline.Route, error) {
+	documents, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]airline.Route, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r routeRepository) List(ctx context.Context, query Query) ([]airline.Route, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]airline.Route, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r routeRepository) FindById(ctx context.Context, id primitive.ObjectID) (airline.Route, error) {
+	var result airline.Route
+	document, err := r.findOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r routeRepository) Insert(ctx context.Context, route airline.Route) error {
+	return r.insert(ctx, route)
+}
@@ -0,0 +1,296 @@
+package repositories
+
+import (
+	"context"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// a change of one document, every field is a (dotted) path
+type Change struct {
+	Set   bson.M
+	Unset []string
+	// adds to numbers, a missing field counts as 0
+	Inc bson.M
+	// appends to arrays, a missing field starts a new one
+	Push bson.M
+	// only written when an upsert inserts the document
+	SetOnInsert bson.M
+}
+
+func (c Change) empty() bool {
+	return len(c.Set) == 0 && len(c.Unset) == 0 && len(c.Inc) == 0 && len(c.Push) == 0 && len(c.SetOnInsert) == 0
+}
+
+func (c Change) mongoUpdate() bson.M {
+	update := bson.M{}
+	for operator, fields := range map[string]bson.M{"$set": c.Set, "$inc": c.Inc, "$push": c.Push, "$setOnInsert": c.SetOnInsert} {
+		if len(fields) > 0 {
+			update[operator] = fields
+		}
+	}
+	if len(c.Unset) > 0 {
+		unset := bson.M{}
+		for _, x := range c.Unset {
+			unset[x] = ""
+		}
+		update["$unset"] = unset
+	}
+	return update
+}
+
+// the change of the document matching the equality filter, inserted with the filter fields when there is none
+type Upsert struct {
+	Filter bson.M
+	Change Change
+}
+
+// raw document storage shared by the typed repositories,
+// filters are equality matches on (dotted) fields and updates set (dotted) fields
+type store interface {
+	find(ctx context.Context, filter bson.M) ([]bson.Raw, error)
+	findOne(ctx context.Context, filter bson.M) (bson.Raw, error)
+	list(ctx context.Context, query Query) ([]bson.Raw, Page, error)
+	// at most limit documents matching every condition, in no particular order
+	match(ctx context.Context, conditions []Condition, limit int) ([]bson.Raw, error)
+	// at most limit documents matching the words of text with the text index, best first,
+	// each with its text score in a score field
+	text(ctx context.Context, text string, conditions []Condition, limit int) ([]bson.Raw, error)
+	insert(ctx context.Context, document interface{}) error
+	set(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	// changes the document when it also satisfies every where condition, ErrNotFound otherwise
+	apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	// changes the document like apply and returns it as changed
+	modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (bson.Raw, error)
+	// the changed or inserted document
+	upsert(ctx context.Context, filter bson.M, change Change) (bson.Raw, error)
+	upsertMany(ctx context.Context, upserts []Upsert) (inserted int64, updated int64, err error)
+	// adds to or removes from the array of a (dotted) field
+	addToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	// removes values from the array of a (dotted) field when the first one is in it, appends them otherwise
+	toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	delete(ctx context.Context, id primitive.ObjectID) error
+	// the sum of a numeric (dotted) field over the documents matching every condition
+	sum(ctx context.Context, conditions []Condition, field string) (float64, error)
+}
+
+type mongoStore struct {
+	collection *mongo.Collection
+}
+
+func (s mongoStore) find(ctx context.Context, filter bson.M) ([]bson.Raw, error) {
+	if filter == nil {
+		filter = bson.M{}
+	}
+	return s.all(ctx, filter, options.Find())
+}
+
+func (s mongoStore) list(ctx context.Context, query Query) ([]bson.Raw, Page, error) {
+	filter := mongoFilter(query.Conditions)
+	total, err := s.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	sorts := sortFields(query.Sort)
+	if query.After != "" {
+		values, err := decodeCursor(query.After, sorts)
+		if err != nil {
+			return nil, Page{}, err
+		}
+		filter = bson.M{"$and": bson.A{filter, mongoCursorFilter(sorts, values)}}
+	}
+	order := bson.D{}
+	for _, x := range sorts {
+		direction := 1
+		if x.Descending {
+			direction = -1
+		}
+		order = append(order, bson.E{Key: x.Field, Value: direction})
+	}
+	findOptions := options.Find().SetSort(order)
+	if query.Limit > 0 {
+		// one more tells whether there is a next page
+		findOptions.SetLimit(int64(query.Limit) + 1)
+	}
+	documents, err := s.all(ctx, filter, findOptions)
+	if err != nil {
+		return nil, Page{}, err
+	}
+	return paginate(documents, query.Limit, sorts, total)
+}
+
+func (s mongoStore) findOne(ctx context.Context, filter bson.M) (bson.Raw, error) {
+	document, err := s.collection.FindOne(ctx, filter).DecodeBytes()
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	return document, err
+}
+
+func (s mongoStore) insert(ctx context.Context, document interface{}) error {
+	_, err := s.collection.InsertOne(ctx, document)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (s mongoStore) match(ctx context.Context, conditions []Condition, limit int) ([]bson.Raw, error) {
+	return s.all(ctx, mongoFilter(conditions), options.Find().SetLimit(int64(limit)))
+}
+
+func (s mongoStore) text(ctx context.Context, text string, conditions []Condition, limit int) ([]bson.Raw, error) {
+	score := bson.M{"score": bson.M{"$meta": "textScore"}}
+	filter := bson.M{"$and": bson.A{bson.M{"$text": bson.M{"$search": text}}, mongoFilter(conditions)}}
+	return s.all(ctx, filter, options.Find().SetProjection(score).SetSort(score).SetLimit(int64(limit)))
+}
+
+func (s mongoStore) all(ctx context.Context, filter bson.M, findOptions *options.FindOptions) ([]bson.Raw, error) {
+	cur, err := s.collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+	documents := make([]bson.Raw, 0)
+	for cur.Next(ctx) {
+		// the cursor reuses its buffer
+		documents = append(documents, append(bson.Raw(nil), cur.Current...))
+	}
+	return documents, cur.Err()
+}
+
+func (s mongoStore) set(ctx context.Context, id primitive.ObjectID, fields bson.M) error {
+	return s.apply(ctx, id, nil, Change{Set: fields})
+}
+
+func idFilter(id primitive.ObjectID, where []Condition) bson.M {
+	filter := bson.M{"_id": id}
+	if len(where) > 0 {
+		filter = bson.M{"$and": bson.A{filter, mongoFilter(where)}}
+	}
+	return filter
+}
+
+func (s mongoStore) apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error {
+	filter := idFilter(id, where)
+	// Mongo rejects empty updates, nothing to change only tells whether the document is there
+	if change.empty() {
+		count, err := s.collection.CountDocuments(ctx, filter)
+		if err == nil && count == 0 {
+			err = ErrNotFound
+		}
+		return err
+	}
+	return s.update(ctx, filter, change.mongoUpdate())
+}
+
+func (s mongoStore) modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (bson.Raw, error) {
+	filter := idFilter(id, where)
+	if change.empty() {
+		return s.findOne(ctx, filter)
+	}
+	document, err := s.collection.FindOneAndUpdate(ctx, filter, change.mongoUpdate(),
+		options.FindOneAndUpdate().SetReturnDocument(options.After)).DecodeBytes()
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotFound
+	}
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	return document, err
+}
+
+func (s mongoStore) upsert(ctx context.Context, filter bson.M, change Change) (bson.Raw, error) {
+	document, err := s.collection.FindOneAndUpdate(ctx, filter, change.mongoUpdate(),
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).DecodeBytes()
+	if mongo.IsDuplicateKeyError(err) {
+		return nil, ErrDuplicate
+	}
+	return document, err
+}
+
+func (s mongoStore) upsertMany(ctx context.Context, upserts []Upsert) (int64, int64, error) {
+	if len(upserts) == 0 {
+		return 0, 0, nil
+	}
+	models := make([]mongo.WriteModel, len(upserts))
+	for i, x := range upserts {
+		models[i] = mongo.NewUpdateOneModel().SetFilter(x.Filter).SetUpdate(x.Change.mongoUpdate()).SetUpsert(true)
+	}
+	result, err := s.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if result == nil {
+		return 0, 0, err
+	}
+	return result.UpsertedCount, result.ModifiedCount, err
+}
+
+func (s mongoStore) update(ctx context.Context, filter bson.M, update interface{}) error {
+	result, err := s.collection.UpdateOne(ctx, filter, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s mongoStore) addToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return s.update(ctx, bson.M{"_id": id}, bson.M{"$addToSet": bson.M{field: value}})
+}
+
+func (s mongoStore) pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error {
+	return s.update(ctx, bson.M{"_id": id}, bson.M{"$pull": bson.M{field: value}})
+}
+
+func (s mongoStore) toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error {
+	list := reflect.ValueOf(values)
+	if list.Kind() != reflect.Slice || list.Len() == 0 {
+		return s.apply(ctx, id, nil, Change{})
+	}
+	// literals, so values starting with $ are not read as field paths
+	first := bson.M{"$literal": list.Index(0).Interface()}
+	all := bson.M{"$literal": values}
+	current := bson.M{"$ifNull": bson.A{"$" + field, bson.A{}}}
+	toggled := bson.M{"$cond": bson.M{
+		"if":   bson.M{"$in": bson.A{first, current}},
+		"then": bson.M{"$setDifference": bson.A{current, all}},
+		"else": bson.M{"$concatArrays": bson.A{current, all}}}}
+	return s.update(ctx, bson.M{"_id": id}, mongo.Pipeline{bson.D{{Key: "$set", Value: bson.M{field: toggled}}}})
+}
+
+func (s mongoStore) delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := s.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s mongoStore) sum(ctx context.Context, conditions []Condition, field string) (float64, error) {
+	cur, err := s.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: mongoFilter(conditions)}},
+		{{Key: "$group", Value: bson.M{"_id": nil, "sum": bson.M{"$sum": "$" + field}}}}})
+	if err != nil {
+		return 0, err
+	}
+	defer cur.Close(ctx)
+	var result []struct {
+		Sum float64 `bson:"sum"`
+	}
+	if err = cur.All(ctx, &result); err != nil || len(result) == 0 {
+		return 0, err
+	}
+	return result[0].Sum, nil
+}
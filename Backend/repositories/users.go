@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type UserRepository interface {
+	// equality filter on (dotted) fields, nil finds everything
+	Find(ctx context.Context, filter bson.M) ([]user.User, error)
+	// a page of the documents matching the query conditions, in query order
+	List(ctx context.Context, query Query) ([]user.User, Page, error)
+	FindById(ctx context.Context, id primitive.ObjectID) (user.User, error)
+	FindByName(ctx context.Context, name string) (user.User, error)
+	Insert(ctx context.Context, userData user.User) error
+	Update(ctx context.Context, id primitive.ObjectID, fields bson.M) error
+	Apply(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) error
+	// changes the document like Apply and returns it as changed
+	Modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (user.User, error)
+	Toggle(ctx context.Context, id primitive.ObjectID, field string, values interface{}) error
+	AddToSet(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Pull(ctx context.Context, id primitive.ObjectID, field string, value interface{}) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type userRepository struct {
+	base
+}
+
+func (r userRepository) Find(ctx context.Context, filter bson.M) ([]user.User, error) {
+	documents, err := r.find(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]user.User, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (r userRepository) List(ctx context.Context, query Query) ([]user.User, Page, error) {
+	documents, page, err := r.list(ctx, query)
+	if err != nil {
+		return nil, page, err
+	}
+	result := make([]user.User, len(documents))
+	for i, x := range documents {
+		if err = bson.Unmarshal(x, &result[i]); err != nil {
+			return nil, page, err
+		}
+	}
+	return result, page, nil
+}
+
+func (r userRepository) FindById(ctx context.Context, id primitive.ObjectID) (user.User, error) {
+	var result user.User
+	document, err := r.findOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r userRepository) Insert(ctx context.Context, userData user.User) error {
+	return r.insert(ctx, userData)
+}
+
+func (r userRepository) FindByName(ctx context.Context, name string) (user.User, error) {
+	var result user.User
+	document, err := r.findOne(ctx, bson.M{"name": name})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r userRepository) Modify(ctx context.Context, id primitive.ObjectID, where []Condition, change Change) (user.User, error) {
+	var result user.User
+	document, err := r.modify(ctx, id, where, change)
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"context"
+
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// the current weather of the airports, one document per airport ICAO code
+type WeatherRepository interface {
+	FindByAirport(ctx context.Context, icao string) (weatherModel.Weather, error)
+	// changes the weather of the airport, a new document gets the SetOnInsert fields too
+	Upsert(ctx context.Context, icao string, change Change) (weatherModel.Weather, error)
+}
+
+type weatherRepository struct {
+	base
+}
+
+func (r weatherRepository) FindByAirport(ctx context.Context, icao string) (weatherModel.Weather, error) {
+	var result weatherModel.Weather
+	document, err := r.findOne(ctx, bson.M{"airport": icao})
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
+
+func (r weatherRepository) Upsert(ctx context.Context, icao string, change Change) (weatherModel.Weather, error) {
+	var result weatherModel.Weather
+	document, err := r.upsert(ctx, bson.M{"airport": icao}, change)
+	if err != nil {
+		return result, err
+	}
+	err = bson.Unmarshal(document, &result)
+	return result, err
+}
@@ -2,95 +2,87 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/cache"
+	"github.com/arttkachev/X-Airlines/Backend/config"
 	aircraftController "github.com/arttkachev/X-Airlines/Backend/controllers"
 	airlineController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	airportController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	authorization "github.com/arttkachev/X-Airlines/Backend/controllers"
+	controllers "github.com/arttkachev/X-Airlines/Backend/controllers"
 	engineController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	flightController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	ledgerController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	maintenanceController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	marketplaceController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	reviewController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	routeController "github.com/arttkachev/X-Airlines/Backend/controllers"
 	userController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	weatherController "github.com/arttkachev/X-Airlines/Backend/controllers"
+	"github.com/arttkachev/X-Airlines/Backend/openapi"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
 	"github.com/arttkachev/X-Airlines/Backend/services"
 	auth "github.com/arttkachev/X-Airlines/Backend/services/auth"
+	"github.com/arttkachev/X-Airlines/Backend/services/backoff"
+	"github.com/arttkachev/X-Airlines/Backend/services/health"
+	"github.com/arttkachev/X-Airlines/Backend/services/integrity"
+	"github.com/arttkachev/X-Airlines/Backend/services/maintenance"
+	"github.com/arttkachev/X-Airlines/Backend/services/ourairports"
+	"github.com/arttkachev/X-Airlines/Backend/services/search"
+	"github.com/arttkachev/X-Airlines/Backend/services/weather"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/gin-contrib/sessions"
 	redisSession "github.com/gin-contrib/sessions/redis"
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis"
-	"github.com/joho/godotenv"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
 )
 
-const port = ":3000"
+var apiInfo = openapi.Info{Title: "X-Airlines API", Version: "1.0.0"}
 
 // Endpoints
 func Welcome(c *gin.Context) {
 	c.JSON(200, gin.H{"Message": "Welcome to X-Airlines Backend"})
 }
 
-func main() {
-	// init redis store for user session cookies
-	store, _ := redisSession.NewStore(10, "tcp", "localhost:6379", "", []byte("secret"))
-
-	// init Redis client
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     "localhost:6379",
-		Password: "",
-		DB:       0,
-	})
-	// Ping Redis clinet
-	redisStatus := redisClient.Ping()
-	// print redist status result
-	fmt.Println(redisStatus)
-
-	// load .env file
-	err := godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-		return
-	}
-
-	// Mongo connection
-	// create a client
-	client, err := mongo.NewClient(options.Client().ApplyURI(os.Getenv("CONNECTION_STRING")))
+// prints the integrity report, exits with 1 when there are issues left
+func checkIntegrity(repos repositories.Repositories, args []string) {
+	flags := flag.NewFlagSet("integrity", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "repair the references that are found broken")
+	flags.Parse(args)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	report, err := integrity.Check(ctx, repos, *fix)
+	data, _ := json.MarshalIndent(report, "", "  ")
+	fmt.Println(string(data))
 	if err != nil {
 		log.Fatal(err)
 	}
-
-	// create a context (context is how long an OS is going to wait before a connection esablished)
-	ctx, _ := context.WithTimeout(context.Background(), 10*time.Second) // wait 10 seconds
-
-	// connect to db
-	err = client.Connect(ctx)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	// make sure to disconnect d when a main func exits. "defer" provides this possibility for us
-	defer client.Disconnect(ctx)
-
-	// check that cnnection works by printing a list of database names of the client
-	database, err := client.ListDatabaseNames(ctx, bson.M{}) // params (context, filter for returned db namesgo )
-	if err != nil {
-		log.Fatal(err)
+	if len(report.Issues) > 0 && !report.Fixed {
+		os.Exit(1)
 	}
+}
 
-	fmt.Println(database)
-	AuthService := auth.AuthService{}
-	services.CreateUserService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("USERS")), redisClient)
-	services.CreateAircraftService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("AIRCRAFT")), redisClient)
-	services.CreateEngineService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("ENGINES")), redisClient)
-	services.CreateAirlineService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("AIRLINES")), redisClient)
-	services.CreateFlightService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("FLIGHTS")), redisClient)
-	services.CreateReviewService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("REVIEWS")), redisClient)
-	services.CreateRouteService(client.Database(os.Getenv("DATABASE")).Collection(os.Getenv("ROUTES")), redisClient)
-
+// registers the routes, the sessions are kept in store and handlers use repos
+func newRouter(AuthService auth.AuthService, store sessions.Store, repos repositories.Repositories) *gin.Engine {
 	// Routing
 	// create a router
 	gin.SetMode(gin.ReleaseMode)
 	router := gin.New()
+	// errors are written by one handler, malformed ids never reach the others
+	router.Use(apierrors.Handler(), controllers.BindObjectIds(), controllers.Repositories(repos))
 	router.Use(sessions.Sessions("x-airlines_api", store))
 	authorized := router.Group("/")
 	authorized.Use(AuthService.AuthMiddleware())
@@ -99,48 +91,106 @@ func main() {
 		authorized.GET("/users", userController.GetUsers)
 		authorized.GET("/users/airline_filter", userController.GetUserByAirline)
 		authorized.GET("/users/:id/get_airlines", userController.GetUserAirlinesData)
-		authorized.PUT("/users/:id", userController.UpdateUser)
-		authorized.PUT("/users/:id/update_airlines", userController.UpdateUserAirlines)
-		authorized.DELETE("/users/:id", userController.DeleteUser)
+		authorized.PUT("/users/:id", authorization.RequireSelf(), userController.UpdateUser)
+		authorized.PUT("/users/:id/update_airlines", authorization.RequireSelf(), userController.UpdateUserAirlines)
+		authorized.DELETE("/users/:id", authorization.RequireSelf(), userController.DeleteUser)
 
 		// aircraft
 		authorized.GET("/aircraft", aircraftController.GetAircraft)
 		authorized.GET("/aircraft/:id", aircraftController.GetAircraftById)
 		authorized.GET("/aircraft/aircraft_filter", aircraftController.GetAircraftByType)
 		authorized.POST("/aircraft", aircraftController.CreateAircraft)
-		authorized.DELETE("/aircraft/:id", aircraftController.DeleteAircraft)
-		authorized.PUT("/aircraft/:id/update_airframe", aircraftController.UpdateAirframe)
-		authorized.PUT("/aircraft/:id/update_exterior", aircraftController.UpdateExterior)
-		authorized.PUT("/aircraft/:id/update_interior", aircraftController.UpdateInterior)
-		authorized.PUT("/aircraft/:id/update_engines", aircraftController.UpdateEngines)
-		authorized.PUT("/aircraft/:id/update_cockpit", aircraftController.UpdateCockpit)
-		authorized.PUT("/aircraft/:id/update_general", aircraftController.UpdateGeneral)
-		authorized.PUT("/aircraft/:id/update_performance", aircraftController.UpdatePerformance)
-		authorized.PUT("/aircraft/:id/update_tags", aircraftController.UpdateTags)
-		authorized.PUT("/aircraft/:id/update_owner", aircraftController.UpdateOwner)
+		authorized.DELETE("/aircraft/:id", authorization.RequireAircraftOwner(), aircraftController.DeleteAircraft)
+		authorized.PUT("/aircraft/:id/update_airframe", authorization.RequireAircraftOwner(), aircraftController.UpdateAirframe)
+		authorized.PUT("/aircraft/:id/update_exterior", authorization.RequireAircraftOwner(), aircraftController.UpdateExterior)
+		authorized.PUT("/aircraft/:id/update_interior", authorization.RequireAircraftOwner(), aircraftController.UpdateInterior)
+		authorized.PUT("/aircraft/:id/update_engines", authorization.RequireAircraftOwner(), aircraftController.UpdateEngines)
+		authorized.PUT("/aircraft/:id/update_cockpit", authorization.RequireAircraftOwner(), aircraftController.UpdateCockpit)
+		authorized.PUT("/aircraft/:id/update_general", authorization.RequireAircraftOwner(), aircraftController.UpdateGeneral)
+		authorized.PUT("/aircraft/:id/update_performance", authorization.RequireAircraftOwner(), aircraftController.UpdatePerformance)
+		authorized.PUT("/aircraft/:id/update_apu", authorization.RequireAircraftOwner(), aircraftController.UpdateAPU)
+		authorized.POST("/aircraft/:id/service_apu", authorization.RequireAircraftOwner(), aircraftController.ServiceAPU)
+		authorized.PUT("/aircraft/:id/update_tags", authorization.RequireAircraftOwner(), aircraftController.UpdateTags)
+		authorized.PUT("/aircraft/:id/update_owner", authorization.RequireAdmin(), aircraftController.UpdateOwner)
 		authorized.GET("/aircraft/:id/get_owner", aircraftController.GetOwnerData)
 		authorized.GET("/aircraft/:id/get_engines", aircraftController.GetEngineData)
 		authorized.GET("/aircraft/:id/get_airline", aircraftController.GetAirlineData)
 
 		// engines
 		authorized.GET("/engines", engineController.GetEngines)
+		authorized.GET("/engines/due", engineController.GetDueEngines)
 		authorized.GET("/engines/:id", engineController.GetEngineById)
 		authorized.POST("/engines", engineController.CreateEngine)
-		authorized.PUT("/engines/:id", engineController.UpdateEngine)
-		authorized.DELETE("/engines/:id", engineController.DeleteEngine)
+		authorized.PUT("/engines/:id", authorization.RequireEngineOwner(), engineController.UpdateEngine)
+		authorized.DELETE("/engines/:id", authorization.RequireEngineOwner(), engineController.DeleteEngine)
+		authorized.POST("/engines/:id/overhaul", authorization.RequireEngineOwner(), engineController.OverhaulEngine)
 
 		// airlines
 		authorized.GET("/airlines", airlineController.GetAirlines)
 		authorized.POST("/airlines", airlineController.CreateAirline)
-		authorized.DELETE("/airlines/:id", airlineController.DeleteAirline)
+		authorized.DELETE("/airlines/:id", authorization.RequireAirlineOwner(), airlineController.DeleteAirline)
 
-		authorized.PUT("/airlines/:id/update_general", airlineController.UpdateAirlineGeneral)
-		authorized.PUT("/airlines/:id/update_review", airlineController.UpdateReviews)
-		authorized.PUT("/airlines/:id/update_routes", airlineController.UpdateRoutes)
-		authorized.PUT("/airlines/:id/update_fleet", airlineController.UpdateFleet)
-		authorized.PUT("/airlines/:id/update_owner", airlineController.UpdateAirlineOwner)
+		authorized.PUT("/airlines/:id/update_general", authorization.RequireAirlineOwner(), airlineController.UpdateAirlineGeneral)
+		authorized.PUT("/airlines/:id/update_review", authorization.RequireAirlineOwner(), airlineController.UpdateReviews)
+		authorized.PUT("/airlines/:id/update_routes", authorization.RequireAirlineOwner(), airlineController.UpdateRoutes)
+		authorized.PUT("/airlines/:id/update_fleet", authorization.RequireAirlineOwner(), airlineController.UpdateFleet)
+		authorized.PUT("/airlines/:id/update_owner", authorization.RequireAdmin(), airlineController.UpdateAirlineOwner)
 		authorized.GET("/airlines/:id/get_fleet", airlineController.GetFleetData)
 		authorized.GET("/airlines/:id/get_owner", airlineController.GetAirlineOwnerData)
+
+		// reviews
+		authorized.GET("/airlines/:id/reviews", reviewController.GetReviews)
+		authorized.POST("/airlines/:id/reviews", reviewController.CreateReview)
+		authorized.PUT("/airlines/:id/reviews/:reviewId/hide", authorization.RequireAdmin(), reviewController.HideReview)
+		authorized.DELETE("/airlines/:id/reviews/:reviewId", reviewController.DeleteReview)
+
+		// routes
+		authorized.POST("/airlines/:id/routes", authorization.RequireAirlineOwner(), routeController.CreateRoute)
+		authorized.GET("/airlines/:id/routes", routeController.GetAirlineRoutes)
+		authorized.GET("/routes/:id", routeController.GetRouteById)
+		authorized.DELETE("/routes/:id", authorization.RequireRouteOwner(), routeController.DeleteRoute)
+
+		// airports
+		authorized.GET("/airports", airportController.GetAirports)
+		authorized.GET("/airports/:icao", airportController.GetAirportByICAO)
+		authorized.GET("/airports/iata/:iata", airportController.GetAirportByIATA)
+		authorized.POST("/airports/import", authorization.RequireAdmin(), airportController.ImportAirports)
+		authorized.GET("/airports/:icao/weather", weatherController.GetAirportWeather)
+		authorized.PUT("/airports/:icao/weather", authorization.RequireAdmin(), weatherController.UpdateAirportWeather)
+		authorized.POST("/weather/ingest", authorization.RequireAdmin(), weatherController.IngestWeather)
+
+		// flights
+		authorized.GET("/flights", flightController.GetFlights)
+		authorized.GET("/flights/:id", flightController.GetFlightById)
+		authorized.POST("/flights", flightController.CreateFlight)
+		authorized.PUT("/flights/:id", authorization.RequireFlightOwner(), flightController.UpdateFlight)
+		authorized.PUT("/flights/:id/cancel", authorization.RequireFlightOwner(), flightController.CancelFlight)
+		authorized.PUT("/flights/:id/complete", authorization.RequireFlightOwner(), flightController.CompleteFlight)
+
+		// marketplace
+		authorized.GET("/marketplace", marketplaceController.GetMarketplace)
+		authorized.PUT("/aircraft/:id/list_for_sale", authorization.RequireAircraftOwner(), marketplaceController.ListAircraftForSale)
+		authorized.PUT("/aircraft/:id/unlist", authorization.RequireAircraftOwner(), marketplaceController.UnlistAircraft)
+		authorized.POST("/marketplace/:id/buy", marketplaceController.BuyAircraft)
+
+		// ledger
+		authorized.GET("/users/:id/ledger", authorization.RequireSelf(), ledgerController.GetUserLedger)
+		authorized.POST("/users/:id/ledger", authorization.RequireAdmin(), ledgerController.AdjustUserBalance)
+		authorized.GET("/airlines/:id/ledger", authorization.RequireAirlineOwner(), ledgerController.GetAirlineLedger)
+		authorized.POST("/airlines/:id/ledger", authorization.RequireAdmin(), ledgerController.AdjustAirlineBalance)
+
+		// maintenance
+		authorized.GET("/check_programs", maintenanceController.GetCheckPrograms)
+		authorized.PUT("/check_programs/:model", authorization.RequireAdmin(), maintenanceController.UpdateCheckProgram)
+		authorized.GET("/aircraft/:id/checks", maintenanceController.GetAircraftChecks)
+		authorized.POST("/aircraft/:id/checks", authorization.RequireAircraftOwner(), maintenanceController.ScheduleCheck)
+
+		// search
+		authorized.GET("/search", controllers.Search)
+
+		// integrity
+		authorized.GET("/integrity", authorization.RequireAdmin(), controllers.CheckIntegrity)
+		authorized.POST("/integrity/fix", authorization.RequireAdmin(), controllers.FixIntegrity)
 	}
 
 	// handlers
@@ -151,6 +201,227 @@ func main() {
 	router.POST("/refresh", AuthService.Refresh)
 	router.GET("/", Welcome)
 
-	// listen and serve
-	router.Run(port)
+	// liveness and readiness probes
+	router.GET("/healthz", controllers.Healthz)
+	router.GET("/readyz", controllers.Readyz)
+
+	// the OpenAPI document of the routes and Swagger UI
+	openapi.Serve(router, apiInfo, controllers.OpenAPI)
+	return router
+}
+
+// prints the OpenAPI document of the routes, the handlers need no database for it
+func printOpenAPI() {
+	router := newRouter(auth.AuthService{}, nil, repositories.Repositories{})
+	data, err := json.MarshalIndent(openapi.Build(apiInfo, router.Routes(), controllers.OpenAPI), "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(string(data))
+}
+
+func main() {
+	// settings of the profile, flags come before a subcommand, e.g. -profile prod integrity --fix
+	cfg, args, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal(err)
+	}
+	command := ""
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	// "openapi" prints the OpenAPI document instead of serving
+	if command == "openapi" {
+		printOpenAPI()
+		return
+	}
+
+	// "config" prints the settings without their secrets
+	if command == "config" {
+		fmt.Print(cfg)
+		if err = cfg.Validate(); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	if err = cfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Profile %s", cfg.Profile)
+
+	// SIGINT or SIGTERM stops the startup retries, the watchers and the server
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	retry := backoff.Policy{Attempts: cfg.Startup.Attempts, Delay: cfg.Startup.Delay, MaxDelay: cfg.Startup.MaxDelay}
+
+	// init Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr:     cfg.Redis.Addr,
+		Password: cfg.Redis.Password,
+		DB:       cfg.Redis.DB,
+	})
+	defer redisClient.Close()
+	pingRedis := func(ctx context.Context) error {
+		return redisClient.WithContext(ctx).Ping().Err()
+	}
+
+	// init redis store for user session cookies, it needs Redis to be up
+	var store redisSession.Store
+	err = backoff.Retry(ctx, retry, "redis", func(ctx context.Context) error {
+		if err := pingWithin(ctx, pingRedis); err != nil {
+			return err
+		}
+		var err error
+		store, err = redisSession.NewStore(10, "tcp", cfg.Redis.Addr, cfg.Redis.Password, []byte(cfg.Session.Secret))
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Connected to Redis at %s", cfg.Redis.Addr)
+
+	// Mongo connection
+	// create a client
+	client, err := mongo.NewClient(options.Client().ApplyURI(cfg.Mongo.URI))
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Connect starts the client in the background, the ping tells if the server answers
+	if err = client.Connect(ctx); err != nil {
+		log.Fatal(err)
+	}
+	// the startup context may be done already, so the disconnect gets its own
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := client.Disconnect(ctx); err != nil {
+			log.Printf("Disconnecting from MongoDB: %v", err)
+		}
+	}()
+	pingMongo := func(ctx context.Context) error {
+		return client.Ping(ctx, readpref.Primary())
+	}
+	if err = backoff.Retry(ctx, retry, "mongo", func(ctx context.Context) error { return pingWithin(ctx, pingMongo) }); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Connected to MongoDB")
+
+	database := client.Database(cfg.Mongo.Database)
+	collections := cfg.Mongo.Collections
+	services.CreateUserService(database.Collection(collections.Users), redisClient)
+	services.CreateAircraftService(database.Collection(collections.Aircraft), redisClient)
+	services.CreateEngineService(database.Collection(collections.Engines), redisClient)
+	services.CreateAirlineService(database.Collection(collections.Airlines), redisClient)
+	services.CreateFlightService(database.Collection(collections.Flights), redisClient)
+	services.CreateReviewService(database.Collection(collections.Reviews), redisClient)
+	services.CreateRouteService(database.Collection(collections.Routes), redisClient)
+	services.CreateAirportService(database.Collection(collections.Airports), redisClient)
+	services.CreateWeatherService(database.Collection(collections.Weather), redisClient)
+	services.CreateLedgerService(database.Collection(collections.Ledger), database.Collection(collections.Accounts), redisClient)
+	indexCtx, cancelIndexes := context.WithTimeout(ctx, time.Minute)
+	defer cancelIndexes()
+	if err = services.GetUserService().EnsureIndexes(indexCtx); err != nil {
+		log.Fatal(err)
+	}
+	if err = services.GetLedgerService().EnsureIndexes(indexCtx); err != nil {
+		log.Fatal(err)
+	}
+	services.CreateCheckProgramService(database.Collection(collections.CheckPrograms), redisClient)
+	if err = search.EnsureIndexes(indexCtx, services.GetAircraftService().Collection, services.GetAirlineService().Collection,
+		services.GetAirportService().Collection); err != nil {
+		log.Fatal(err)
+	}
+	if err = ourairports.EnsureIndexes(indexCtx, services.GetAirportService().Collection); err != nil {
+		log.Fatal(err)
+	}
+
+	// handlers read and write through the repositories
+	repos := repositories.NewMongoRepositories(repositories.Collections{
+		Users:         services.GetUserService().Collection,
+		Aircraft:      services.GetAircraftService().Collection,
+		Engines:       services.GetEngineService().Collection,
+		Airlines:      services.GetAirlineService().Collection,
+		Routes:        services.GetRouteService().Collection,
+		Flights:       services.GetFlightService().Collection,
+		Reviews:       services.GetReviewService().Collection,
+		Airports:      services.GetAirportService().Collection,
+		Weather:       services.GetWeatherService().Collection,
+		CheckPrograms: services.GetCheckProgramService().Collection,
+		Ledger:        services.GetLedgerService().Collection,
+		Accounts:      services.GetLedgerService().Accounts,
+	})
+
+	controllers.UseConfig(cfg)
+	controllers.UseHealthChecks(health.Check{Name: "mongo", Ping: pingMongo}, health.Check{Name: "redis", Ping: pingRedis})
+	auth.UseConfig(cfg.Auth)
+	maintenance.UseConfig(cfg.Maintenance)
+
+	// where handler reads are cached: redis, lru or none
+	switch cfg.Cache.Backend {
+	case "none":
+		controllers.UseCache(cache.NewNoop())
+	case "lru":
+		controllers.UseCache(cache.NewLRU(cfg.Cache.Size))
+	default:
+		controllers.UseCache(cache.NewRedis(redisClient))
+	}
+	AuthService := auth.AuthService{Repositories: repos}
+
+	// "integrity [--fix]" checks the references between collections instead of serving
+	if command == "integrity" {
+		checkIntegrity(repos, args[1:])
+		return
+	}
+
+	// custom rules and json field names of request validation
+	if err = validation.Register(); err != nil {
+		log.Fatal(err)
+	}
+
+	// balances kept before the ledger get their opening entries
+	migrateCtx, cancelMigration := context.WithTimeout(ctx, 5*time.Minute)
+	defer cancelMigration()
+	opened, err := services.GetLedgerService().OpenBalances(migrateCtx, repos)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if opened > 0 {
+		log.Printf("Opened the ledger balances of %d accounts", opened)
+	}
+
+	// ingest METAR and TAF files dropped into the weather drop directory
+	if cfg.Weather.DropDir != "" {
+		go weather.WatchDirectory(ctx, repos.Weather, repos.Airports,
+			cfg.Weather.DropDir, cfg.Weather.DropInterval, weatherController.ClearWeatherCache)
+	}
+
+	// start and release airframe checks
+	go maintenance.WatchChecks(ctx, repos.Aircraft, cfg.Maintenance.ChecksSweepInterval,
+		maintenanceController.ClearAircraftCache)
+
+	server := &http.Server{Addr: cfg.Addr, Handler: newRouter(AuthService, store, repos), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	log.Printf("Listening on %s", cfg.Addr)
+
+	// drain the requests in flight, a second signal kills the process right away
+	<-ctx.Done()
+	stop()
+	log.Printf("Shutting down, waiting up to %s for requests in flight", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutting down: %v", err)
+	}
+}
+
+// one startup attempt may take at most 5 seconds
+func pingWithin(ctx context.Context, ping func(ctx context.Context) error) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	return ping(ctx)
 }
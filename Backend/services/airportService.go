@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var airportService AirportService
+
+type AirportService struct {
+	Collection  *mongo.Collection
+	RedisClient *redis.Client
+}
+
+func CreateAirportService(collection *mongo.Collection, redisClient *redis.Client) *AirportService {
+	airportService.Collection = collection
+	airportService.RedisClient = redisClient
+	return &airportService
+}
+func GetAirportService() *AirportService {
+	return &airportService
+}
@@ -2,14 +2,19 @@ package auth
 
 import (
 	"context"
-	"crypto/sha256"
+	"io"
 	"log"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
 	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
 	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/arttkachev/X-Airlines/Backend/services/password"
+	"github.com/arttkachev/X-Airlines/Backend/validation"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
@@ -19,46 +24,91 @@ import (
 	//"go.mongodb.org/mongo-driver/x/mongo/driver/session"
 )
 
-type AuthService struct{}
+// context key of the signed in user.User
+const UserKey = "user"
+
+type AuthService struct {
+	Repositories repositories.Repositories
+}
 
 type Claims struct {
-	Username string `json:"username"`
+	UserID string `json:"userId"`
 	jwt.StandardClaims
 }
 type JWTOutput struct {
-	Token   string    `json:"token"`
-	Expires time.Time `json:"expires"`
+	Token          string    `json:"token"`
+	Expires        time.Time `json:"expires"`
+	RefreshToken   string    `json:"refreshToken"`
+	RefreshExpires time.Time `json:"refreshExpires"`
 }
 
 func (handler *AuthService) SignUp(c *gin.Context) {
 	var user user.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
+		return
+	}
+	if err := validation.Create(&user); err != nil {
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	user.ID = primitive.NewObjectID()
 	var userService = services.GetUserService()
-	collection := userService.Collection
 	if user.Airlines == nil {
 		user.Airlines = make([]primitive.ObjectID, 0)
 	}
-	h := sha256.New()
-	_, err := collection.InsertOne(ctx, bson.M{
-		"name":     user.Name,
-		"email":    user.Email,
-		"password": string(h.Sum([]byte(user.Password))),
-		"isAdmin":  user.IsAdmin,
-		"balance":  user.Balance,
-		"airlines": user.Airlines,
-	})
+	hash, err := password.Hash(user.Password)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error()})
+		c.Error(apierrors.Validation(err.Error()))
 		return
 	}
+	user.Password = hash
+	// admin rights are only granted by admins through UpdateUser
+	isAdmin := false
+	user.IsAdmin = &isAdmin
+	// the balance only changes through ledger postings
+	balance := 0
+	user.Balance = &balance
+	_, err = handler.Repositories.Users.FindByName(ctx, user.Name)
+	if err == nil {
+		c.Error(apierrors.Conflict("The name is already taken"))
+		return
+	} else if err != repositories.ErrNotFound {
+		c.Error(err)
+		return
+	}
+	// the user and the starting balance are created together, a failed posting removes the user again
+	work := services.NewUnitOfWork("Sign up " + user.Name)
+	work.Add("insert the user",
+		func(ctx context.Context) error {
+			return handler.Repositories.Users.Insert(ctx, user)
+		},
+		func(ctx context.Context) error {
+			return handler.Repositories.Users.Delete(ctx, user.ID)
+		})
+	// new users get the starting balance from the system account
+	startingBalance := settings.StartingBalance
+	if startingBalance > 0 {
+		services.GetLedgerService().AddTransfer(work, handler.Repositories, ledger.Transfer{
+			From:   ledger.Account{Type: ledger.System},
+			To:     ledger.Account{Type: ledger.User, ID: user.ID},
+			Amount: startingBalance,
+			Type:   ledger.Opening})
+	}
+	err = work.Run(ctx)
+	// two sign ups with the same name may race past the check, the unique index stops the second
+	if err == repositories.ErrDuplicate {
+		c.Error(apierrors.Conflict("The name is already taken"))
+		return
+	} else if err != nil {
+		c.Error(err)
+		return
+	}
+	if startingBalance > 0 {
+		user.Balance = &startingBalance
+	}
 	// clear cache
 	log.Println("Remove user data from Redis")
 	userService.RedisClient.Del("users")
@@ -68,122 +118,147 @@ func (handler *AuthService) SignUp(c *gin.Context) {
 func (handler *AuthService) SignIn(c *gin.Context) {
 	var user user.User
 	if err := c.ShouldBindJSON(&user); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": err.Error()})
+		c.Error(validation.Error(err))
 		return
 	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	userService := services.GetUserService()
-	h := sha256.New()
-	cur := userService.Collection.FindOne(ctx, bson.M{
-		"name":     user.Name,
-		"password": string(h.Sum([]byte(user.Password))),
-	})
-	if cur.Err() != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid username or password"})
+	stored, err := handler.Repositories.Users.FindByName(ctx, user.Name)
+	if err != nil && err != repositories.ErrNotFound {
+		c.Error(err)
 		return
 	}
+	ok, rehash := password.Check(stored.Password, user.Password)
+	if !ok {
+		c.Error(apierrors.Unauthorized("Invalid username or password"))
+		return
+	}
+	// replace legacy hashes while the plain password is at hand
+	if rehash {
+		hash, err := password.Hash(user.Password)
+		if err == nil {
+			err = handler.Repositories.Users.Update(ctx, stored.ID, bson.M{"password": hash})
+		}
+		if err != nil {
+			log.Printf("Rehash password of %s: %v", stored.Name, err)
+		}
+	}
 	sessionToken := xid.New().String()
 	session := sessions.Default(c)
-	session.Set("name", user.Name)
+	session.Set("userId", stored.ID.Hex())
 	session.Set("token", sessionToken)
 	session.Save()
-	c.JSON(http.StatusOK, gin.H{
-		"message": "User signed in"})
+	// clients without cookies authenticate with the tokens
+	jwtOutput, err := issueTokens(stored.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, jwtOutput)
+}
+
+type SignOutRequest struct {
+	RefreshToken string `json:"refreshToken"`
+	All          bool   `json:"all"`
+}
 
-	// // Expiration time for a token is gonna be 10 mins
-	// expirationTime := time.Now().Add(10 * time.Minute)
-	// claims := &Claims{
-	// 	Username: user.Name,
-	// 	StandardClaims: jwt.StandardClaims{
-	// 		ExpiresAt: expirationTime.Unix(),
-	// 	},
-	// }
-	// token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	// tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SECRET")))
-	// if err != nil {
-	// 	c.JSON(http.StatusInternalServerError, gin.H{
-	// 		"error": err.Error()})
-	// 	return
-	// }
-	// jwtOutput := JWTOutput{
-	// 	Token:   tokenString,
-	// 	Expires: expirationTime,
-	// }
-	// c.JSON(http.StatusOK, jwtOutput)
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
+// clears the session and revokes the given refresh token or, with all set, every refresh token of the user
 func (handler *AuthService) SignOut(c *gin.Context) {
+	var request SignOutRequest
+	if err := c.ShouldBindJSON(&request); err != nil && err != io.EOF {
+		c.Error(validation.Error(err))
+		return
+	}
 	session := sessions.Default(c)
+	userId, _ := session.Get("userId").(string)
+	if request.RefreshToken != "" {
+		if request.All && userId == "" {
+			userId, _ = services.GetUserService().RedisClient.Get(refreshTokenPrefix + hashToken(request.RefreshToken)).Result()
+		}
+		revokeRefreshToken(request.RefreshToken)
+	}
+	if objectId, err := primitive.ObjectIDFromHex(userId); request.All && err == nil {
+		RevokeUserRefreshTokens(objectId)
+	}
 	session.Clear()
 	session.Save()
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Signed out"})
 }
 
+// exchanges a refresh token for a new access token and a new refresh token, the old one is revoked
 func (handler *AuthService) Refresh(c *gin.Context) {
-	tokenValue := c.GetHeader("Authorization")
-	claims := &Claims{}
-	tkn, err := jwt.ParseWithClaims(tokenValue, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(os.Getenv("JWT_SECRET")), nil
-	})
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": err.Error()})
+	var request RefreshRequest
+	if err := c.ShouldBindJSON(&request); err != nil || request.RefreshToken == "" {
+		c.Error(apierrors.Validation("refreshToken is required"))
 		return
 	}
-	if tkn == nil || !tkn.Valid {
-		c.JSON(http.StatusUnauthorized, gin.H{
-			"error": "Invalid token"})
+	userId, err := consumeRefreshToken(request.RefreshToken)
+	if err == errInvalidRefreshToken {
+		c.Error(apierrors.Unauthorized(err.Error()))
+		return
+	} else if err != nil {
+		c.Error(err)
 		return
 	}
-	if time.Unix(claims.ExpiresAt, 0).Sub(time.Now()) > 30*time.Second {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Token is not expired yet"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err = handler.Repositories.Users.FindById(ctx, userId)
+	if err == repositories.ErrNotFound {
+		c.Error(apierrors.Unauthorized(errInvalidRefreshToken.Error()))
+		return
+	} else if err != nil {
+		c.Error(err)
 		return
 	}
-	expirationTime := time.Now().Add(5 * time.Minute)
-	claims.ExpiresAt = expirationTime.Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(os.Getenv("JWT_SECRET"))
+	jwtOutput, err := issueTokens(userId)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		c.Error(err)
 		return
 	}
-	jwtOutput := JWTOutput{
-		Token:   tokenString,
-		Expires: expirationTime,
-	}
 	c.JSON(http.StatusOK, jwtOutput)
 }
 
+// accepts a Bearer access token or a session cookie and loads the signed in user into the context
 func (handler *AuthService) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		sessionToken := session.Get("token")
-		if sessionToken == nil {
-			c.JSON(http.StatusForbidden, gin.H{
-				"message": "Not logged in",
-			})
-			c.Abort()
+		var userId string
+		if header := c.GetHeader("Authorization"); strings.HasPrefix(header, "Bearer ") {
+			claims, err := parseAccessToken(strings.TrimPrefix(header, "Bearer "))
+			if err != nil {
+				apierrors.Abort(c, apierrors.Unauthorized(err.Error()))
+				return
+			}
+			userId = claims.UserID
+		} else {
+			session := sessions.Default(c)
+			if session.Get("token") == nil {
+				apierrors.Abort(c, apierrors.Unauthorized("Not logged in"))
+				return
+			}
+			userId, _ = session.Get("userId").(string)
+		}
+		// sessions from before they carried user ids have to sign in again
+		objectId, err := primitive.ObjectIDFromHex(userId)
+		if err != nil {
+			apierrors.Abort(c, apierrors.Unauthorized("Not logged in"))
+			return
 		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		currentUser, err := handler.Repositories.Users.FindById(ctx, objectId)
+		if err == repositories.ErrNotFound {
+			apierrors.Abort(c, apierrors.Unauthorized("The user no longer exists"))
+			return
+		} else if err != nil {
+			apierrors.Abort(c, err)
+			return
+		}
+		c.Set(UserKey, currentUser)
 		c.Next()
-
-		// tokenValue := c.GetHeader("Authorization")
-		// claims := &Claims{}
-		// tkn, err := jwt.ParseWithClaims(tokenValue, claims, func(token *jwt.Token) (interface{}, error) {
-		// 	return []byte(os.Getenv("JWT_SECRET")), nil
-		// })
-		// if err != nil {
-		// 	c.AbortWithStatus(http.StatusUnauthorized)
-		// }
-		// if tkn == nil || !tkn.Valid {
-		// 	c.AbortWithStatus(http.StatusUnauthorized)
-		// }
-		// c.Next()
 	}
 }
@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/config"
+	"github.com/arttkachev/X-Airlines/Backend/services"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/go-redis/redis"
+	"github.com/rs/xid"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAccessTTL  = 10 * time.Minute
+	defaultRefreshTTL = 30 * 24 * time.Hour
+	// a refresh token hash maps to the id of its user
+	refreshTokenPrefix = "refresh_tokens/"
+	// the refresh token hashes of a user, used to revoke all of them at once
+	userRefreshTokensPrefix = "user_refresh_tokens/"
+)
+
+var errInvalidRefreshToken = errors.New("Invalid refresh token")
+
+// the token settings, injected at startup
+var settings config.Auth
+
+func UseConfig(c config.Auth) {
+	settings = c
+}
+
+func ttlOr(ttl time.Duration, fallback time.Duration) time.Duration {
+	if ttl <= 0 {
+		return fallback
+	}
+	return ttl
+}
+
+func jwtSecret() ([]byte, error) {
+	if settings.JWTSecret == "" {
+		return nil, errors.New("The JWT secret is not configured")
+	}
+	return []byte(settings.JWTSecret), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// issues a short-lived access token and a refresh token, only the refresh token hash is kept in Redis.
+// Tokens carry the user id, names can change.
+func issueTokens(userId primitive.ObjectID) (JWTOutput, error) {
+	var output JWTOutput
+	secret, err := jwtSecret()
+	if err != nil {
+		return output, err
+	}
+	now := time.Now()
+	output.Expires = now.Add(ttlOr(settings.AccessTTL, defaultAccessTTL))
+	claims := &Claims{
+		UserID: userId.Hex(),
+		StandardClaims: jwt.StandardClaims{
+			Id:        xid.New().String(),
+			IssuedAt:  now.Unix(),
+			ExpiresAt: output.Expires.Unix(),
+		},
+	}
+	output.Token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(secret)
+	if err != nil {
+		return output, err
+	}
+	random := make([]byte, 32)
+	if _, err = rand.Read(random); err != nil {
+		return output, err
+	}
+	output.RefreshToken = base64.RawURLEncoding.EncodeToString(random)
+	refreshTTL := ttlOr(settings.RefreshTTL, defaultRefreshTTL)
+	output.RefreshExpires = now.Add(refreshTTL)
+	redisClient := services.GetUserService().RedisClient
+	hash := hashToken(output.RefreshToken)
+	if err = redisClient.Set(refreshTokenPrefix+hash, userId.Hex(), refreshTTL).Err(); err != nil {
+		return output, err
+	}
+	redisClient.SAdd(userRefreshTokensPrefix+userId.Hex(), hash)
+	redisClient.Expire(userRefreshTokensPrefix+userId.Hex(), refreshTTL)
+	return output, nil
+}
+
+// consumes a refresh token and returns the id of its user, a token can only be used once
+func consumeRefreshToken(token string) (primitive.ObjectID, error) {
+	redisClient := services.GetUserService().RedisClient
+	hash := hashToken(token)
+	userId, err := redisClient.Get(refreshTokenPrefix + hash).Result()
+	if err == redis.Nil {
+		return primitive.NilObjectID, errInvalidRefreshToken
+	} else if err != nil {
+		return primitive.NilObjectID, err
+	}
+	// losing the race against a concurrent refresh with the same token
+	deleted, err := redisClient.Del(refreshTokenPrefix + hash).Result()
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+	if deleted == 0 {
+		return primitive.NilObjectID, errInvalidRefreshToken
+	}
+	redisClient.SRem(userRefreshTokensPrefix+userId, hash)
+	// tokens issued before they carried user ids are not honoured
+	objectId, err := primitive.ObjectIDFromHex(userId)
+	if err != nil {
+		return primitive.NilObjectID, errInvalidRefreshToken
+	}
+	return objectId, nil
+}
+
+func revokeRefreshToken(token string) {
+	redisClient := services.GetUserService().RedisClient
+	hash := hashToken(token)
+	userId, err := redisClient.Get(refreshTokenPrefix + hash).Result()
+	if err != nil {
+		return
+	}
+	redisClient.Del(refreshTokenPrefix + hash)
+	redisClient.SRem(userRefreshTokensPrefix+userId, hash)
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of a user, access tokens run out on their own
+func RevokeUserRefreshTokens(userId primitive.ObjectID) {
+	redisClient := services.GetUserService().RedisClient
+	hashes, err := redisClient.SMembers(userRefreshTokensPrefix + userId.Hex()).Result()
+	if err != nil {
+		return
+	}
+	for _, x := range hashes {
+		redisClient.Del(refreshTokenPrefix + x)
+	}
+	redisClient.Del(userRefreshTokensPrefix + userId.Hex())
+}
+
+func parseAccessToken(tokenString string) (*Claims, error) {
+	secret, err := jwtSecret()
+	if err != nil {
+		return nil, err
+	}
+	claims := &Claims{}
+	tkn, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("Unexpected signing method")
+		}
+		return secret, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tkn == nil || !tkn.Valid || !primitive.IsValidObjectID(claims.UserID) {
+		return nil, errors.New("Invalid token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,44 @@
+package backoff
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+// how often and how long to wait between attempts, the delay doubles up to MaxDelay
+type Policy struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+// Retry calls attempt until it succeeds, the attempts run out or ctx is done. Waits get up to a fifth
+// of jitter so restarted instances do not retry in lockstep
+func Retry(ctx context.Context, policy Policy, name string, attempt func(ctx context.Context) error) error {
+	delay := policy.Delay
+	for i := 1; ; i++ {
+		err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+		if i >= policy.Attempts {
+			return fmt.Errorf("%s: gave up after %d attempts: %w", name, i, err)
+		}
+		wait := delay + time.Duration(rand.Int63n(int64(delay)/5+1))
+		log.Printf("%s: attempt %d failed, retrying in %s: %v", name, i, wait.Round(time.Millisecond), err)
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		delay *= 2
+		if delay > policy.MaxDelay {
+			delay = policy.MaxDelay
+		}
+	}
+}
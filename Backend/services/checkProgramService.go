@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var checkProgramService CheckProgramService
+
+type CheckProgramService struct {
+	Collection  *mongo.Collection
+	RedisClient *redis.Client
+}
+
+func CreateCheckProgramService(collection *mongo.Collection, redisClient *redis.Client) *CheckProgramService {
+	checkProgramService.Collection = collection
+	checkProgramService.RedisClient = redisClient
+	return &checkProgramService
+}
+func GetCheckProgramService() *CheckProgramService {
+	return &checkProgramService
+}
@@ -0,0 +1,37 @@
+// Package geo holds the navigation math used to plan routes and flights.
+package geo
+
+import "math"
+
+// mean earth radius in nautical miles
+const earthRadiusNM = 3440.065
+
+// TaxiMinutes is the taxi out and taxi in allowance added to the air time of every flight
+const TaxiMinutes = 20
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// DistanceNM returns the great-circle distance in nautical miles between two points given in degrees
+func DistanceNM(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := radians(lat1), radians(lat2)
+	deltaPhi := radians(lat2 - lat1)
+	deltaLambda := radians(lon2 - lon1)
+	a := math.Sin(deltaPhi/2)*math.Sin(deltaPhi/2) +
+		math.Cos(phi1)*math.Cos(phi2)*math.Sin(deltaLambda/2)*math.Sin(deltaLambda/2)
+	return 2 * earthRadiusNM * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// AirTimeMinutes returns the time in minutes needed to fly distance nautical miles at cruiseSpeed knots
+func AirTimeMinutes(distance float64, cruiseSpeed float64) float64 {
+	if cruiseSpeed <= 0 {
+		return 0
+	}
+	return math.Ceil(distance / cruiseSpeed * 60)
+}
+
+// BlockTimeMinutes returns the gate to gate time in minutes
+func BlockTimeMinutes(distance float64, cruiseSpeed float64) float64 {
+	return AirTimeMinutes(distance, cruiseSpeed) + TaxiMinutes
+}
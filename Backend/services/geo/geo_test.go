@@ -0,0 +1,57 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDistanceNM(t *testing.T) {
+	tests := []struct {
+		name                   string
+		lat1, lon1, lat2, lon2 float64
+		distance               float64
+		tolerance              float64
+	}{
+		// published distances are measured on the ellipsoid, the sphere is within half a percent
+		{"JFK to LHR", 40.639447, -73.779317, 51.4706, -0.461941, 2999, 15},
+		{"LAX to JFK", 33.942536, -118.408075, 40.639447, -73.779317, 2151, 11},
+		{"one degree of latitude", 0, 0, 1, 0, 60.04, 0.01},
+		{"same point", 51.4706, -0.461941, 51.4706, -0.461941, 0, 0},
+		{"antipodes", 0, 0, 0, 180, math.Pi * earthRadiusNM, 0.001},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance := DistanceNM(test.lat1, test.lon1, test.lat2, test.lon2)
+			if math.Abs(distance-test.distance) > test.tolerance {
+				t.Errorf("%.2f NM, want %.2f ± %.2f", distance, test.distance, test.tolerance)
+			}
+			// the distance does not depend on the direction
+			if back := DistanceNM(test.lat2, test.lon2, test.lat1, test.lon1); math.Abs(back-distance) > 1e-9 {
+				t.Errorf("%.2f NM back, want %.2f", back, distance)
+			}
+		})
+	}
+}
+
+func TestBlockTimeMinutes(t *testing.T) {
+	tests := []struct {
+		distance    float64
+		cruiseSpeed float64
+		airTime     float64
+		blockTime   float64
+	}{
+		{450, 450, 60, 80},
+		// partial minutes round up
+		{451, 450, 61, 81},
+		{2999, 480, 375, 395},
+		{100, 0, 0, TaxiMinutes},
+	}
+	for _, test := range tests {
+		if airTime := AirTimeMinutes(test.distance, test.cruiseSpeed); airTime != test.airTime {
+			t.Errorf("air time of %v NM at %v kt is %v, want %v", test.distance, test.cruiseSpeed, airTime, test.airTime)
+		}
+		if blockTime := BlockTimeMinutes(test.distance, test.cruiseSpeed); blockTime != test.blockTime {
+			t.Errorf("block time of %v NM at %v kt is %v, want %v", test.distance, test.cruiseSpeed, blockTime, test.blockTime)
+		}
+	}
+}
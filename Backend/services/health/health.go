@@ -0,0 +1,57 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	OK          = "ok"
+	Unavailable = "unavailable"
+)
+
+// a dependency the server needs, Ping fails when it cannot be reached
+type Check struct {
+	Name string
+	Ping func(ctx context.Context) error
+}
+
+type Status struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Status `json:"checks,omitempty"`
+}
+
+// Run pings the dependencies at once, the report is ok when every one of them answers
+func Run(ctx context.Context, checks []Check) Report {
+	report := Report{Status: OK, Checks: make(map[string]Status, len(checks))}
+	var mutex sync.Mutex
+	var wait sync.WaitGroup
+	for _, x := range checks {
+		wait.Add(1)
+		go func(check Check) {
+			defer wait.Done()
+			start := time.Now()
+			err := check.Ping(ctx)
+			status := Status{Status: OK, LatencyMs: float64(time.Since(start).Microseconds()) / 1000}
+			if err != nil {
+				status.Status = Unavailable
+				status.Error = err.Error()
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Checks[check.Name] = status
+			if err != nil {
+				report.Status = Unavailable
+			}
+		}(x)
+	}
+	wait.Wait()
+	return report
+}
@@ -0,0 +1,222 @@
+package integrity
+
+import (
+	"context"
+	"sort"
+
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// kinds of issues
+const (
+	// the referenced document does not exist
+	Dangling = "dangling"
+	// only one side of a relation holds the reference
+	Asymmetric = "asymmetric"
+	// the same reference is held more than once
+	Duplicate = "duplicate"
+)
+
+// one side of each relation is the source of truth and the other side is repaired to match it:
+// Airline.Fleet for Aircraft.General.History, Engine.OwningAircraft for Aircraft.Engines
+// and Airline.Owner for User.Airlines
+type Issue struct {
+	Kind       string             `json:"kind"`
+	Collection string             `json:"collection"`
+	Document   primitive.ObjectID `json:"document"`
+	Field      string             `json:"field"`
+	Reference  primitive.ObjectID `json:"reference"`
+	Repair     string             `json:"repair"`
+}
+
+type Report struct {
+	Issues []Issue `json:"issues"`
+	Fixed  bool    `json:"fixed"`
+}
+
+// a repair of one document field, applied in fix mode
+type change struct {
+	update func(context.Context, primitive.ObjectID, bson.M) error
+	id     primitive.ObjectID
+	field  string
+	value  interface{}
+}
+
+type checker struct {
+	report  Report
+	changes []change
+}
+
+func (c *checker) add(kind string, collection string, id primitive.ObjectID, field string, reference primitive.ObjectID, repair string) {
+	c.report.Issues = append(c.report.Issues, Issue{
+		Kind:       kind,
+		Collection: collection,
+		Document:   id,
+		Field:      field,
+		Reference:  reference,
+		Repair:     repair})
+}
+
+// compares the references a document holds with the ones it should hold,
+// reports the differences and plans the repair when there are any
+func (c *checker) reconcile(update func(context.Context, primitive.ObjectID, bson.M) error, collection string, id primitive.ObjectID, field string,
+	current []primitive.ObjectID, wanted map[primitive.ObjectID]bool, exists func(primitive.ObjectID) bool) {
+	result := make([]primitive.ObjectID, 0, len(wanted))
+	held := make(map[primitive.ObjectID]bool)
+	for _, x := range current {
+		switch {
+		case held[x]:
+			c.add(Duplicate, collection, id, field, x, "remove")
+		case !exists(x):
+			c.add(Dangling, collection, id, field, x, "remove")
+		case !wanted[x]:
+			c.add(Asymmetric, collection, id, field, x, "remove")
+		default:
+			result = append(result, x)
+		}
+		held[x] = true
+	}
+	var missing []primitive.ObjectID
+	for x := range wanted {
+		if !held[x] {
+			missing = append(missing, x)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].Hex() < missing[j].Hex()
+	})
+	for _, x := range missing {
+		c.add(Asymmetric, collection, id, field, x, "add")
+		result = append(result, x)
+	}
+	if len(result) != len(current) || len(missing) > 0 {
+		c.changes = append(c.changes, change{update, id, field, result})
+	}
+}
+
+// scans users, aircraft, engines and airlines for broken references, with fix it also repairs them
+func Check(ctx context.Context, repos repositories.Repositories, fix bool) (Report, error) {
+	c := &checker{report: Report{Issues: []Issue{}}}
+	users, err := repos.Users.Find(ctx, nil)
+	if err != nil {
+		return c.report, err
+	}
+	airplanes, err := repos.Aircraft.Find(ctx, nil)
+	if err != nil {
+		return c.report, err
+	}
+	engines, err := repos.Engines.Find(ctx, nil)
+	if err != nil {
+		return c.report, err
+	}
+	airlines, err := repos.Airlines.Find(ctx, nil)
+	if err != nil {
+		return c.report, err
+	}
+	userIds := make(map[primitive.ObjectID]bool)
+	for _, x := range users {
+		userIds[x.ID] = true
+	}
+	aircraftIds := make(map[primitive.ObjectID]bool)
+	for _, x := range airplanes {
+		aircraftIds[x.ID] = true
+	}
+	engineIds := make(map[primitive.ObjectID]bool)
+	for _, x := range engines {
+		engineIds[x.ID] = true
+	}
+	airlineIds := make(map[primitive.ObjectID]bool)
+	for _, x := range airlines {
+		airlineIds[x.ID] = true
+	}
+	isUser := func(id primitive.ObjectID) bool { return userIds[id] }
+	isAircraft := func(id primitive.ObjectID) bool { return aircraftIds[id] }
+	isEngine := func(id primitive.ObjectID) bool { return engineIds[id] }
+	isAirline := func(id primitive.ObjectID) bool { return airlineIds[id] }
+
+	// fleets are the truth for aircraft histories, owners for user airlines
+	histories := make(map[primitive.ObjectID]map[primitive.ObjectID]bool)
+	owned := make(map[primitive.ObjectID]map[primitive.ObjectID]bool)
+	for _, x := range airlines {
+		fleet := make(map[primitive.ObjectID]bool)
+		for _, aircraftId := range x.Fleet {
+			if !isAircraft(aircraftId) {
+				continue
+			}
+			fleet[aircraftId] = true
+			if histories[aircraftId] == nil {
+				histories[aircraftId] = make(map[primitive.ObjectID]bool)
+			}
+			histories[aircraftId][x.ID] = true
+		}
+		c.reconcile(repos.Airlines.Update, "airlines", x.ID, "fleet", x.Fleet, fleet, isAircraft)
+		if x.Owner == primitive.NilObjectID {
+			continue
+		}
+		if !isUser(x.Owner) {
+			c.add(Dangling, "airlines", x.ID, "owner", x.Owner, "clear")
+			c.changes = append(c.changes, change{repos.Airlines.Update, x.ID, "owner", primitive.NilObjectID})
+			continue
+		}
+		if owned[x.Owner] == nil {
+			owned[x.Owner] = make(map[primitive.ObjectID]bool)
+		}
+		owned[x.Owner][x.ID] = true
+	}
+	for _, x := range users {
+		c.reconcile(repos.Users.Update, "users", x.ID, "airlines", x.Airlines, owned[x.ID], isAirline)
+	}
+
+	// owning aircraft are the truth for aircraft engines
+	installed := make(map[primitive.ObjectID]map[primitive.ObjectID]bool)
+	for _, x := range engines {
+		if x.OwningAircraft == primitive.NilObjectID {
+			continue
+		}
+		if !isAircraft(x.OwningAircraft) {
+			c.add(Dangling, "engines", x.ID, "owningAircraft", x.OwningAircraft, "clear")
+			c.changes = append(c.changes, change{repos.Engines.Update, x.ID, "owningAircraft", primitive.NilObjectID})
+			continue
+		}
+		if installed[x.OwningAircraft] == nil {
+			installed[x.OwningAircraft] = make(map[primitive.ObjectID]bool)
+		}
+		installed[x.OwningAircraft][x.ID] = true
+	}
+	for _, x := range airplanes {
+		var history []primitive.ObjectID
+		if x.General != nil {
+			history = x.General.History
+		}
+		c.reconcile(repos.Aircraft.Update, "aircraft", x.ID, "general.history", history, histories[x.ID], isAirline)
+		c.reconcile(repos.Aircraft.Update, "aircraft", x.ID, "engines", x.Engines, installed[x.ID], isEngine)
+	}
+
+	if !fix {
+		return c.report, nil
+	}
+	for _, x := range c.changes {
+		if err = x.update(ctx, x.id, bson.M{x.field: x.value}); err != nil && err != repositories.ErrNotFound {
+			return c.report, err
+		}
+	}
+	c.report.Fixed = true
+	return c.report, nil
+}
+
+// ids of the documents of each collection an issue was found in
+func (r Report) Documents() map[string][]string {
+	documents := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, x := range r.Issues {
+		key := x.Collection + "/" + x.Document.Hex()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		documents[x.Collection] = append(documents[x.Collection], x.Document.Hex())
+	}
+	return documents
+}
@@ -0,0 +1,274 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("Insufficient funds")
+	ErrNoAccount         = errors.New("No such account")
+)
+
+var ledgerService LedgerService
+
+type LedgerService struct {
+	Collection *mongo.Collection
+	// balances of the accounts without a document of their own
+	Accounts    *mongo.Collection
+	RedisClient *redis.Client
+}
+
+func CreateLedgerService(collection *mongo.Collection, accounts *mongo.Collection, redisClient *redis.Client) *LedgerService {
+	ledgerService.Collection = collection
+	ledgerService.Accounts = accounts
+	ledgerService.RedisClient = redisClient
+	return &ledgerService
+}
+func GetLedgerService() *LedgerService {
+	return &ledgerService
+}
+
+func (s *LedgerService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.Collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"accountType", 1}, {"account", 1}, {"createdAt", 1}}},
+		{Keys: bson.D{{"transaction", 1}}}})
+	if err != nil {
+		return err
+	}
+	_, err = s.Accounts.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"type", 1}},
+		Options: options.Index().SetUnique(true)})
+	return err
+}
+
+// changes the stored balance of a user or an airline account and returns it,
+// ErrNotFound when where does not hold
+func modifyBalance(ctx context.Context, repos repositories.Repositories, account ledger.Account, where []repositories.Condition, change repositories.Change) (*int, error) {
+	switch account.Type {
+	case ledger.User:
+		u, err := repos.Users.Modify(ctx, account.ID, where, change)
+		return u.Balance, err
+	case ledger.Airline:
+		a, err := repos.Airlines.Modify(ctx, account.ID, where, change)
+		return a.Balance, err
+	}
+	return nil, ErrNoAccount
+}
+
+// applies one leg of a posting to the account balance and returns the running balance.
+// Debits are rejected when the balance does not cover them, the system account has no limit
+func (s *LedgerService) apply(ctx context.Context, repos repositories.Repositories, account ledger.Account, amount int) (int, error) {
+	if account.Type == ledger.System {
+		return repos.Accounts.Add(ctx, ledger.System, amount)
+	}
+	balance, err := modifyBalance(ctx, repos, account, nil, repositories.Change{})
+	if err == repositories.ErrNotFound {
+		return 0, ErrNoAccount
+	} else if err != nil {
+		return 0, err
+	}
+	// a balance that was never set counts as 0
+	if balance == nil {
+		_, err = modifyBalance(ctx, repos, account,
+			[]repositories.Condition{{Field: "balance", Operator: repositories.Eq, Values: []interface{}{nil}}},
+			repositories.Change{Set: bson.M{"balance": 0}})
+		if err != nil && err != repositories.ErrNotFound {
+			return 0, err
+		}
+	}
+	var where []repositories.Condition
+	if amount < 0 {
+		where = []repositories.Condition{{Field: "balance", Operator: repositories.Gte, Values: []interface{}{-amount}}}
+	}
+	balance, err = modifyBalance(ctx, repos, account, where, repositories.Change{Inc: bson.M{"balance": amount}})
+	if err == repositories.ErrNotFound {
+		return 0, ErrInsufficientFunds
+	} else if err != nil {
+		return 0, err
+	}
+	return *balance, nil
+}
+
+// AddTransfer adds a transfer to work as a debit and a credit entry that update both balances.
+// Undoing it puts the balances back and removes the entries
+func (s *LedgerService) AddTransfer(work *UnitOfWork, repos repositories.Repositories, transfer ledger.Transfer) {
+	if transfer.Amount < 0 {
+		transfer.From, transfer.To = transfer.To, transfer.From
+		transfer.Amount = -transfer.Amount
+	}
+	transaction := primitive.NewObjectID()
+	now := time.Now().UTC()
+	legs := []struct {
+		account ledger.Account
+		amount  int
+	}{
+		{transfer.From, -transfer.Amount},
+		{transfer.To, transfer.Amount}}
+	for _, leg := range legs {
+		leg := leg
+		entry := ledger.Entry{
+			ID:          primitive.NewObjectID(),
+			Transaction: transaction,
+			AccountType: leg.account.Type,
+			Account:     leg.account.ID,
+			Type:        transfer.Type,
+			Amount:      leg.amount,
+			Reference:   transfer.Reference,
+			Description: transfer.Description,
+			CreatedAt:   now}
+		name := fmt.Sprintf("post %d to the %s account %s", leg.amount, leg.account.Type, leg.account.ID.Hex())
+		work.Add(name,
+			func(ctx context.Context) error {
+				balance, err := s.apply(ctx, repos, leg.account, leg.amount)
+				if err != nil {
+					return err
+				}
+				entry.Balance = balance
+				return nil
+			},
+			func(ctx context.Context) error {
+				return s.unapply(ctx, repos, leg.account, leg.amount)
+			})
+		addEntry(work, repos, name, &entry)
+	}
+}
+
+// takes a leg applied by apply back out of the account balance
+func (s *LedgerService) unapply(ctx context.Context, repos repositories.Repositories, account ledger.Account, amount int) error {
+	if account.Type == ledger.System {
+		_, err := repos.Accounts.Add(ctx, ledger.System, -amount)
+		return err
+	}
+	_, err := modifyBalance(ctx, repos, account, nil, repositories.Change{Inc: bson.M{"balance": -amount}})
+	return err
+}
+
+// adds the step recording the entry of a leg, entry is read when the step runs
+func addEntry(work *UnitOfWork, repos repositories.Repositories, name string, entry *ledger.Entry) {
+	work.Add("record the entry of "+name,
+		func(ctx context.Context) error {
+			return repos.Ledger.Insert(ctx, *entry)
+		},
+		func(ctx context.Context) error {
+			return repos.Ledger.Delete(ctx, entry.ID)
+		})
+}
+
+// Transfer posts a transfer as its own unit of work
+func (s *LedgerService) Transfer(ctx context.Context, repos repositories.Repositories, transfer ledger.Transfer) error {
+	work := NewUnitOfWork("Transfer " + transfer.Type)
+	s.AddTransfer(work, repos, transfer)
+	return work.Run(ctx)
+}
+
+// OpenBalances is the migration of the balances kept before the ledger. Users and airlines
+// without entries get an opening entry for their balance, paid by the system account, and a
+// missing balance becomes 0. The system account starts from the sum of its entries.
+// Accounts that have entries are left alone, so it runs at every start. Returns the accounts opened
+func (s *LedgerService) OpenBalances(ctx context.Context, repos repositories.Repositories) (int, error) {
+	systemBalance, err := repos.Ledger.Balance(ctx, ledger.Account{Type: ledger.System})
+	if err != nil {
+		return 0, err
+	}
+	if _, err = repos.Accounts.Open(ctx, ledger.System, systemBalance); err != nil {
+		return 0, err
+	}
+	type balance struct {
+		account ledger.Account
+		balance *int
+	}
+	var balances []balance
+	users, err := repos.Users.Find(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, x := range users {
+		balances = append(balances, balance{ledger.Account{Type: ledger.User, ID: x.ID}, x.Balance})
+	}
+	airlines, err := repos.Airlines.Find(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	for _, x := range airlines {
+		balances = append(balances, balance{ledger.Account{Type: ledger.Airline, ID: x.ID}, x.Balance})
+	}
+	opened := 0
+	for _, x := range balances {
+		if x.balance == nil {
+			_, err = modifyBalance(ctx, repos, x.account,
+				[]repositories.Condition{{Field: "balance", Operator: repositories.Eq, Values: []interface{}{nil}}},
+				repositories.Change{Set: bson.M{"balance": 0}})
+			if err != nil && err != repositories.ErrNotFound {
+				return opened, err
+			}
+			continue
+		}
+		if *x.balance == 0 {
+			continue
+		}
+		entries, _, err := repos.Ledger.List(ctx, repositories.Query{
+			Conditions: []repositories.Condition{
+				{Field: "accountType", Operator: repositories.Eq, Values: []interface{}{x.account.Type}},
+				{Field: "account", Operator: repositories.Eq, Values: []interface{}{x.account.ID}}},
+			Limit: 1})
+		if err != nil {
+			return opened, err
+		}
+		if len(entries) > 0 {
+			continue
+		}
+		if err = s.open(ctx, repos, x.account, *x.balance); err != nil {
+			return opened, err
+		}
+		opened++
+	}
+	return opened, nil
+}
+
+// posts the opening entries of an account that already holds balance, the account balance stays as it is
+func (s *LedgerService) open(ctx context.Context, repos repositories.Repositories, account ledger.Account, balance int) error {
+	transaction := primitive.NewObjectID()
+	now := time.Now().UTC()
+	system := ledger.Entry{
+		ID:          primitive.NewObjectID(),
+		Transaction: transaction,
+		AccountType: ledger.System,
+		Type:        ledger.Opening,
+		Amount:      -balance,
+		Reference:   account.ID,
+		CreatedAt:   now}
+	opening := ledger.Entry{
+		ID:          primitive.NewObjectID(),
+		Transaction: transaction,
+		AccountType: account.Type,
+		Account:     account.ID,
+		Type:        ledger.Opening,
+		Amount:      balance,
+		Balance:     balance,
+		CreatedAt:   now}
+	name := fmt.Sprintf("post %d to the %s account", -balance, ledger.System)
+	work := NewUnitOfWork(fmt.Sprintf("Open the %s account %s", account.Type, account.ID.Hex()))
+	work.Add(name,
+		func(ctx context.Context) error {
+			var err error
+			system.Balance, err = s.apply(ctx, repos, ledger.Account{Type: ledger.System}, -balance)
+			return err
+		},
+		func(ctx context.Context) error {
+			return s.unapply(ctx, repos, ledger.Account{Type: ledger.System}, -balance)
+		})
+	addEntry(work, repos, name, &system)
+	addEntry(work, repos, fmt.Sprintf("the opening balance of the %s account %s", account.Type, account.ID.Hex()), &opening)
+	return work.Run(ctx)
+}
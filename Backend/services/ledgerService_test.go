@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airline"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/ledger"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/user"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func intPtr(x int) *int {
+	return &x
+}
+
+func TestOpenBalances(t *testing.T) {
+	ctx := context.Background()
+	repos := repositories.NewMemoryRepositories()
+	rich := user.User{ID: primitive.NewObjectID(), Name: "rich", Balance: intPtr(300)}
+	broke := user.User{ID: primitive.NewObjectID(), Name: "broke", Balance: intPtr(0)}
+	unset := airline.Airline{ID: primitive.NewObjectID()}
+	for _, err := range []error{
+		repos.Users.Insert(ctx, rich),
+		repos.Users.Insert(ctx, broke),
+		repos.Airlines.Insert(ctx, unset)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := GetLedgerService()
+	// the second run finds every account opened
+	for run, want := range []int{1, 0} {
+		opened, err := service.OpenBalances(ctx, repos)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opened != want {
+			t.Errorf("run %d opened %d accounts, want %d", run+1, opened, want)
+		}
+	}
+	err := service.Transfer(ctx, repos, ledger.Transfer{
+		From:   ledger.Account{Type: ledger.System},
+		To:     ledger.Account{Type: ledger.User, ID: rich.ID},
+		Amount: 50,
+		Type:   ledger.Adjustment})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		account ledger.Account
+		balance int
+	}{
+		{"opened", ledger.Account{Type: ledger.User, ID: rich.ID}, 350},
+		{"zero", ledger.Account{Type: ledger.User, ID: broke.ID}, 0},
+		{"unset", ledger.Account{Type: ledger.Airline, ID: unset.ID}, 0},
+		{"system", ledger.Account{Type: ledger.System}, -350},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			balance, err := repos.Ledger.Balance(ctx, test.account)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if balance != test.balance {
+				t.Errorf("entries sum up to %d, want %d", balance, test.balance)
+			}
+		})
+	}
+	// the stored balances agree with the entries
+	if u, err := repos.Users.FindById(ctx, rich.ID); err != nil || u.Balance == nil || *u.Balance != 350 {
+		t.Errorf("user balance %v (%v), want 350", u.Balance, err)
+	}
+	if a, err := repos.Airlines.FindById(ctx, unset.ID); err != nil || a.Balance == nil || *a.Balance != 0 {
+		t.Errorf("airline balance %v (%v), want 0", a.Balance, err)
+	}
+	if balance, err := repos.Accounts.Add(ctx, ledger.System, 0); err != nil || balance != -350 {
+		t.Errorf("system balance %d (%v), want -350", balance, err)
+	}
+}
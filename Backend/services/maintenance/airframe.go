@@ -0,0 +1,198 @@
+package maintenance
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// due state of one check of an aircraft, remaining values are negative when the check is overdue
+type CheckStatus struct {
+	Type            string                     `json:"type"`
+	Last            *aircraft.MaintenanceEntry `json:"last,omitempty"`
+	DueHours        *float64                   `json:"dueHours,omitempty"`
+	DueCycles       *uint32                    `json:"dueCycles,omitempty"`
+	DueDate         *time.Time                 `json:"dueDate,omitempty"`
+	RemainingHours  *float64                   `json:"remainingHours,omitempty"`
+	RemainingCycles *int64                     `json:"remainingCycles,omitempty"`
+	Overdue         bool                       `json:"overdue"`
+}
+
+func checkRank(check string) int {
+	for i, x := range aircraft.Checks {
+		if x == check {
+			return i
+		}
+	}
+	return -1
+}
+
+func IsCheck(check string) bool {
+	return checkRank(check) >= 0
+}
+
+// LastCheck returns the latest log entry that counts as the check
+func LastCheck(airframe aircraft.Airframe, check string) *aircraft.MaintenanceEntry {
+	var last *aircraft.MaintenanceEntry
+	rank := checkRank(check)
+	for i, x := range airframe.MaintenanceLog {
+		if checkRank(x.Type) >= rank && (last == nil || x.Date.After(last.Date)) {
+			last = &airframe.MaintenanceLog[i]
+		}
+	}
+	return last
+}
+
+// CheckStatuses tells when every check of the program is due next. Aircraft that never had a check
+// count from zero hours and cycles and from the start of their build year
+func CheckStatuses(program aircraft.CheckProgram, airplane aircraft.Aircraft, now time.Time) []CheckStatus {
+	var airframe aircraft.Airframe
+	if airplane.Airframe != nil {
+		airframe = *airplane.Airframe
+	}
+	var hours float64
+	if airframe.TotalTime != nil {
+		hours = *airframe.TotalTime
+	}
+	var cycles uint32
+	if airframe.TotalLandings != nil {
+		cycles = *airframe.TotalLandings
+	}
+	statuses := make([]CheckStatus, 0, len(program.Checks))
+	for _, interval := range program.Checks {
+		status := CheckStatus{Type: interval.Type, Last: LastCheck(airframe, interval.Type)}
+		var baseHours float64
+		var baseCycles uint32
+		var baseDate *time.Time
+		if status.Last != nil {
+			baseHours = status.Last.TotalTime
+			if status.Last.Cycles != nil {
+				baseCycles = *status.Last.Cycles
+			}
+			baseDate = &status.Last.Date
+		} else if airplane.General != nil && airplane.General.Year != nil {
+			built := time.Date(int(*airplane.General.Year), time.January, 1, 0, 0, 0, 0, time.UTC)
+			baseDate = &built
+		}
+		if interval.Hours != nil {
+			due := baseHours + *interval.Hours
+			remaining := due - hours
+			status.DueHours = &due
+			status.RemainingHours = &remaining
+			status.Overdue = status.Overdue || remaining <= 0
+		}
+		if interval.Cycles != nil {
+			due := baseCycles + *interval.Cycles
+			remaining := int64(due) - int64(cycles)
+			status.DueCycles = &due
+			status.RemainingCycles = &remaining
+			status.Overdue = status.Overdue || remaining <= 0
+		}
+		if interval.Months != nil && baseDate != nil {
+			due := baseDate.AddDate(0, int(*interval.Months), 0)
+			status.DueDate = &due
+			status.Overdue = status.Overdue || !due.After(now)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// AccrueAirframe adds the time and one landing of a completed flight to the airframe
+func AccrueAirframe(hours float64) repositories.Change {
+	return repositories.Change{Inc: bson.M{
+		"airframe.totalTime":     hours,
+		"airframe.totalLandings": 1}}
+}
+
+// SweepChecks takes aircraft whose check has started out of service and releases the ones whose check is over,
+// the finished check goes to the airframe maintenance log. It returns the aircraft it changed
+func SweepChecks(ctx context.Context, aircraftRepository repositories.AircraftRepository, now time.Time) ([]primitive.ObjectID, error) {
+	changed := make([]primitive.ObjectID, 0)
+	starting, _, err := aircraftRepository.List(ctx, repositories.Query{Conditions: []repositories.Condition{
+		{Field: "airframe.check.started", Operator: repositories.Eq, Values: []interface{}{false}},
+		{Field: "airframe.check.start", Operator: repositories.Lte, Values: []interface{}{now}}}})
+	if err != nil {
+		return changed, err
+	}
+	for _, x := range starting {
+		err = aircraftRepository.Apply(ctx, x.ID,
+			[]repositories.Condition{{Field: "airframe.check.started", Operator: repositories.Eq, Values: []interface{}{false}}},
+			repositories.Change{Set: bson.M{
+				"airframe.check.started": true,
+				"general.isOperating":    false}})
+		// another sweep got there first
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			// one aircraft does not hold up the others
+			log.Printf("Airframe checks: starting the check of aircraft %s: %v", x.ID.Hex(), err)
+			continue
+		}
+		changed = append(changed, x.ID)
+	}
+	finished, _, err := aircraftRepository.List(ctx, repositories.Query{Conditions: []repositories.Condition{
+		{Field: "airframe.check.end", Operator: repositories.Lte, Values: []interface{}{now}}}})
+	if err != nil {
+		return changed, err
+	}
+	for _, x := range finished {
+		check := x.Airframe.Check
+		entry := aircraft.MaintenanceEntry{
+			Type:   check.Type,
+			Date:   check.End,
+			Cycles: x.Airframe.TotalLandings,
+			Notes:  check.Type + " check"}
+		if x.Airframe.TotalTime != nil {
+			entry.TotalTime = *x.Airframe.TotalTime
+		}
+		release := repositories.Change{
+			Set:   bson.M{"general.isOperating": true},
+			Unset: []string{"airframe.check"},
+			Push:  bson.M{"airframe.maintenanceLog": entry}}
+		// aircraft stored without a log cannot be pushed onto
+		if x.Airframe.MaintenanceLog == nil {
+			release.Set["airframe.maintenanceLog"] = []aircraft.MaintenanceEntry{entry}
+			release.Push = nil
+		}
+		err = aircraftRepository.Apply(ctx, x.ID,
+			[]repositories.Condition{{Field: "airframe.check.end", Operator: repositories.Eq, Values: []interface{}{check.End}}},
+			release)
+		if err == repositories.ErrNotFound {
+			continue
+		} else if err != nil {
+			log.Printf("Airframe checks: releasing aircraft %s: %v", x.ID.Hex(), err)
+			continue
+		}
+		changed = append(changed, x.ID)
+	}
+	return changed, nil
+}
+
+// WatchChecks sweeps the checks every interval until ctx is done,
+// onSweep is called with the aircraft every pass changed
+func WatchChecks(ctx context.Context, aircraftRepository repositories.AircraftRepository, interval time.Duration, onSweep func([]primitive.ObjectID)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		passCtx, cancel := context.WithTimeout(ctx, interval)
+		changed, err := SweepChecks(passCtx, aircraftRepository, time.Now().UTC())
+		cancel()
+		if err != nil {
+			log.Printf("Airframe checks: %v", err)
+		}
+		if len(changed) > 0 && onSweep != nil {
+			onSweep(changed)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package maintenance
+
+import (
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/config"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// the APU tuning, injected at startup
+var settings = config.Defaults(config.Dev).Maintenance
+
+func UseConfig(c config.Maintenance) {
+	settings = c
+}
+
+func APUInterval() float64 {
+	return settings.APUInterval
+}
+
+// APUHours is the APU time of a flight, the gate time plus the taxi time that is not airborne
+func APUHours(flightTime uint16, blockTime uint16) float64 {
+	taxi := 0.0
+	if blockTime > flightTime {
+		taxi = float64(blockTime - flightTime)
+	}
+	return (settings.APUGroundMinutes + taxi) / 60
+}
+
+func SinceAPUMaintenance(apu aircraft.APU) float64 {
+	if apu.SinceMaintenance != nil {
+		return *apu.SinceMaintenance
+	}
+	if apu.TotalTime != nil {
+		return *apu.TotalTime
+	}
+	return 0
+}
+
+// FillAPURemaining sets the hours left to the APU service, negative values mean it is overdue
+func FillAPURemaining(apu *aircraft.APU) {
+	remaining := APUInterval() - SinceAPUMaintenance(*apu)
+	apu.TimeToMaintenance = &remaining
+}
+
+// AccrueAPU adds APU hours of a completed flight
+func AccrueAPU(apu aircraft.APU, hours float64) repositories.Change {
+	change := repositories.Change{Set: bson.M{}, Inc: bson.M{"apu.totalTime": hours}}
+	// a missing counter starts from the hours the APU has run
+	if apu.SinceMaintenance != nil {
+		change.Inc["apu.sinceMaintenance"] = hours
+	} else {
+		change.Set["apu.sinceMaintenance"] = SinceAPUMaintenance(apu) + hours
+	}
+	return change
+}
@@ -0,0 +1,68 @@
+package maintenance
+
+import (
+	"github.com/arttkachev/X-Airlines/Backend/api/models/aircraft"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// an engine without overhaul records has run its whole life since overhaul
+func SinceOverhaul(engine aircraft.Engine) float64 {
+	if engine.SinceOverhaul != nil {
+		return *engine.SinceOverhaul
+	}
+	if engine.TotalTime != nil {
+		return *engine.TotalTime
+	}
+	return 0
+}
+
+func SinceHotSection(engine aircraft.Engine) float64 {
+	if engine.SinceHotSection != nil {
+		return *engine.SinceHotSection
+	}
+	return SinceOverhaul(engine)
+}
+
+// FillRemaining sets the hours left to the overhaul and the hot section inspection,
+// negative values mean the engine is overdue
+func FillRemaining(engine *aircraft.Engine) {
+	engine.TimeToOverhaul = nil
+	engine.TimeToHotSection = nil
+	if engine.TBO != nil {
+		remaining := float64(*engine.TBO) - SinceOverhaul(*engine)
+		engine.TimeToOverhaul = &remaining
+	}
+	if engine.HST != nil {
+		remaining := float64(*engine.HST) - SinceHotSection(*engine)
+		engine.TimeToHotSection = &remaining
+	}
+}
+
+func PastTBO(engine aircraft.Engine) bool {
+	return engine.TBO != nil && SinceOverhaul(engine) >= float64(*engine.TBO)
+}
+
+// Due tells if the overhaul or the hot section inspection is within threshold hours
+func Due(engine aircraft.Engine, threshold float64) bool {
+	FillRemaining(&engine)
+	return (engine.TimeToOverhaul != nil && *engine.TimeToOverhaul <= threshold) ||
+		(engine.TimeToHotSection != nil && *engine.TimeToHotSection <= threshold)
+}
+
+// AccrueEngineHours adds flight hours to the engine counters
+func AccrueEngineHours(engine aircraft.Engine, hours float64) repositories.Change {
+	change := repositories.Change{Set: bson.M{}, Inc: bson.M{"totalTime": hours}}
+	// missing counters start from the counter they fall back to
+	if engine.SinceOverhaul != nil {
+		change.Inc["sinceOverhaul"] = hours
+	} else {
+		change.Set["sinceOverhaul"] = SinceOverhaul(engine) + hours
+	}
+	if engine.SinceHotSection != nil {
+		change.Inc["sinceHotSection"] = hours
+	} else {
+		change.Set["sinceHotSection"] = SinceHotSection(engine) + hours
+	}
+	return change
+}
@@ -0,0 +1,254 @@
+// Package ourairports imports airports and runways from the public OurAirports CSV dumps
+// (https://ourairports.com/data/airports.csv and runways.csv).
+package ourairports
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/arttkachev/X-Airlines/Backend/api/models/airport"
+	"github.com/arttkachev/X-Airlines/Backend/api/models/flight"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const batchSize = 1000
+
+// DefaultTypes are the airport types imported when no types are requested
+var DefaultTypes = []string{"large_airport", "medium_airport", "small_airport"}
+
+type Result struct {
+	Read     int   `json:"read"`
+	Skipped  int   `json:"skipped"`
+	Inserted int64 `json:"inserted"`
+	Updated  int64 `json:"updated"`
+}
+
+// csv reader addressing columns by their header names
+type table struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newTable(r io.Reader) (*table, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	return &table{reader: reader, columns: columns}, nil
+}
+
+func (t *table) require(names ...string) error {
+	for _, name := range names {
+		if _, ok := t.columns[name]; !ok {
+			return fmt.Errorf("missing column %q", name)
+		}
+	}
+	return nil
+}
+
+func (t *table) get(record []string, name string) string {
+	i, ok := t.columns[name]
+	if !ok || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+func parseInt(value string) *int32 {
+	if value == "" {
+		return nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	result := int32(number)
+	return &result
+}
+
+// ReadRunways reads runways.csv and groups the runways by the airport ident
+func ReadRunways(r io.Reader) (map[string][]airport.Runway, error) {
+	t, err := newTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if err = t.require("airport_ident", "le_ident", "he_ident"); err != nil {
+		return nil, err
+	}
+	runways := make(map[string][]airport.Runway)
+	for {
+		record, err := t.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		ident := t.get(record, "airport_ident")
+		runways[ident] = append(runways[ident], airport.Runway{
+			LowEnd:  t.get(record, "le_ident"),
+			HighEnd: t.get(record, "he_ident"),
+			Length:  parseInt(t.get(record, "length_ft")),
+			Width:   parseInt(t.get(record, "width_ft")),
+			Surface: t.get(record, "surface"),
+			Lighted: t.get(record, "lighted") == "1",
+			Closed:  t.get(record, "closed") == "1",
+		})
+	}
+	return runways, nil
+}
+
+// ReadAirports reads airports.csv keeping the airports of the given types and attaching their runways.
+// Airports are identified by their ICAO code, falling back to the GPS code and the OurAirports ident.
+func ReadAirports(r io.Reader, types []string, runways map[string][]airport.Runway) ([]airport.Airport, int, error) {
+	t, err := newTable(r)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err = t.require("ident", "type", "name", "latitude_deg", "longitude_deg"); err != nil {
+		return nil, 0, err
+	}
+	if len(types) == 0 {
+		types = DefaultTypes
+	}
+	wanted := make(map[string]bool, len(types))
+	for _, x := range types {
+		wanted[x] = true
+	}
+	airports := make([]airport.Airport, 0)
+	skipped := 0
+	for {
+		record, err := t.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, skipped, err
+		}
+		if !wanted[t.get(record, "type")] {
+			skipped++
+			continue
+		}
+		latitude, latErr := strconv.ParseFloat(t.get(record, "latitude_deg"), 64)
+		longitude, lonErr := strconv.ParseFloat(t.get(record, "longitude_deg"), 64)
+		if latErr != nil || lonErr != nil {
+			skipped++
+			continue
+		}
+		ident := t.get(record, "ident")
+		icao := t.get(record, "icao_code")
+		if icao == "" {
+			icao = t.get(record, "gps_code")
+		}
+		if icao == "" {
+			icao = ident
+		}
+		airportRunways := runways[ident]
+		if airportRunways == nil {
+			airportRunways = make([]airport.Runway, 0)
+		}
+		airports = append(airports, airport.Airport{
+			ICAO:         strings.ToUpper(icao),
+			IATA:         strings.ToUpper(t.get(record, "iata_code")),
+			Name:         t.get(record, "name"),
+			Type:         t.get(record, "type"),
+			Latitude:     latitude,
+			Longitude:    longitude,
+			Elevation:    parseInt(t.get(record, "elevation_ft")),
+			Country:      t.get(record, "iso_country"),
+			Region:       t.get(record, "iso_region"),
+			Municipality: t.get(record, "municipality"),
+			Runways:      airportRunways,
+		})
+	}
+	return airports, skipped, nil
+}
+
+// EnsureIndexes creates the lookup indexes of the airports collection
+func EnsureIndexes(ctx context.Context, collection *mongo.Collection) error {
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{"icao", 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{"iata", 1}}},
+		{Keys: bson.D{{"name", 1}}},
+	})
+	return err
+}
+
+// Import upserts the airports of airportsPath into the repository by ICAO code.
+// runwaysPath is optional.
+func Import(ctx context.Context, airports repositories.AirportRepository, airportsPath string, runwaysPath string, types []string) (Result, error) {
+	var result Result
+	runways := make(map[string][]airport.Runway)
+	if runwaysPath != "" {
+		file, err := os.Open(runwaysPath)
+		if err != nil {
+			return result, err
+		}
+		runways, err = ReadRunways(file)
+		file.Close()
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", runwaysPath, err)
+		}
+	}
+	file, err := os.Open(airportsPath)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+	read, skipped, err := ReadAirports(file, types, runways)
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", airportsPath, err)
+	}
+	result.Read = len(read) + skipped
+	result.Skipped = skipped
+	upserts := make([]repositories.Upsert, 0, batchSize)
+	flush := func() error {
+		inserted, updated, err := airports.UpsertMany(ctx, upserts)
+		result.Inserted += inserted
+		result.Updated += updated
+		upserts = upserts[:0]
+		return err
+	}
+	for _, x := range read {
+		upserts = append(upserts, repositories.Upsert{
+			Filter: bson.M{"icao": x.ICAO},
+			Change: repositories.Change{
+				Set: bson.M{
+					"iata":         x.IATA,
+					"name":         x.Name,
+					"type":         x.Type,
+					"latitude":     x.Latitude,
+					"longitude":    x.Longitude,
+					"elevation":    x.Elevation,
+					"country":      x.Country,
+					"region":       x.Region,
+					"municipality": x.Municipality,
+					"runways":      x.Runways},
+				SetOnInsert: bson.M{
+					"_id":        primitive.NewObjectID(),
+					"arrivals":   make([]flight.Flight, 0),
+					"departures": make([]flight.Flight, 0),
+					"topTraffic": make([]primitive.ObjectID, 0)}}})
+		if len(upserts) == batchSize {
+			if err = flush(); err != nil {
+				return result, err
+			}
+		}
+	}
+	return result, flush()
+}
@@ -0,0 +1,159 @@
+package ourairports
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// rows of the OurAirports dumps, airports.csv has no icao_code column in older dumps
+const airportsCSV = `"id","ident","type","name","latitude_deg","longitude_deg","elevation_ft","continent","iso_country","iso_region","municipality","scheduled_service","icao_code","iata_code","gps_code","local_code","home_link","wikipedia_link","keywords"
+3622,"KJFK","large_airport","John F Kennedy International Airport",40.639447,-73.779317,13,"NA","US","US-NY","New York","yes","KJFK","JFK","KJFK","JFK","https://www.jfkairport.com/","https://en.wikipedia.org/wiki/John_F._Kennedy_International_Airport","Manhattan, New York City, NYC, Idlewild"
+6523,"00A","heliport","Total RF Heliport",40.070985,-74.933689,11,"NA","US","US-PA","Bensalem","no","","","K00A","00A","","",""
+2434,"EGLL","large_airport","London Heathrow Airport",51.4706,-0.461941,83,"EU","GB","GB-ENG","London","yes","EGLL","LHR","EGLL","","","https://en.wikipedia.org/wiki/Heathrow_Airport","LON, Londres"
+16507,"09J","small_airport","Pecan Plantation Airport",32.35,-97.6764,,"NA","US","US-TX","Granbury","no","","","09J","09J","","",""
+99999,"XX-0001","small_airport","Broken Coordinates","north","west",,"NA","US","US-TX","Nowhere","no","","","","","","",""
+`
+
+const runwaysCSV = `"id","airport_ref","airport_ident","length_ft","width_ft","surface","lighted","closed","le_ident","le_latitude_deg","le_longitude_deg","le_elevation_ft","le_heading_degT","le_displaced_threshold_ft","he_ident","he_latitude_deg","he_longitude_deg","he_elevation_ft","he_heading_degT","he_displaced_threshold_ft"
+240376,3622,"KJFK",14511,200,"ASP",1,0,"04L",40.6222,-73.7856,12,31,,"22R",40.6512,-73.7626,13,211,
+240377,3622,"KJFK",8400,200,"ASP",1,0,"04R",40.6253,-73.7703,13,31,,"22L",40.6424,-73.7547,13,211,
+232244,2434,"EGLL",12799,164,"ASP",1,0,"09L",51.4775,-0.484913,79,89.6,1013,"27R",51.4777,-0.433223,78,269.6,
+270562,16507,"09J",3200,50,"GRASS",0,1,"17",,,,,,"35",,,,,
+`
+
+func TestReadRunways(t *testing.T) {
+	runways, err := ReadRunways(strings.NewReader(runwaysCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ident   string
+		count   int
+		lowEnd  string
+		length  int32
+		lighted bool
+		closed  bool
+	}{
+		{"KJFK", 2, "04L", 14511, true, false},
+		{"EGLL", 1, "09L", 12799, true, false},
+		{"09J", 1, "17", 3200, false, true},
+	}
+	for _, test := range tests {
+		t.Run(test.ident, func(t *testing.T) {
+			x := runways[test.ident]
+			if len(x) != test.count {
+				t.Fatalf("%d runways, want %d", len(x), test.count)
+			}
+			if x[0].LowEnd != test.lowEnd || x[0].Length == nil || *x[0].Length != test.length ||
+				x[0].Lighted != test.lighted || x[0].Closed != test.closed {
+				t.Errorf("runway %+v, want %s of %d ft lighted %t closed %t", x[0], test.lowEnd, test.length, test.lighted, test.closed)
+			}
+		})
+	}
+	if _, err = ReadRunways(strings.NewReader("\"id\",\"airport_ident\"\n1,\"KJFK\"\n")); err == nil {
+		t.Error("no error without the runway end columns")
+	}
+}
+
+func TestReadAirports(t *testing.T) {
+	runways, err := ReadRunways(strings.NewReader(runwaysCSV))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		types   []string
+		icaos   []string
+		skipped int
+	}{
+		// the heliport is not a default type and the broken coordinates are skipped
+		{"default types", nil, []string{"KJFK", "EGLL", "09J"}, 2},
+		{"requested types", []string{"heliport"}, []string{"K00A"}, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			airports, skipped, err := ReadAirports(strings.NewReader(airportsCSV), test.types, runways)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if skipped != test.skipped {
+				t.Errorf("%d skipped, want %d", skipped, test.skipped)
+			}
+			icaos := make([]string, len(airports))
+			for i, x := range airports {
+				icaos[i] = x.ICAO
+			}
+			if strings.Join(icaos, ",") != strings.Join(test.icaos, ",") {
+				t.Errorf("airports %v, want %v", icaos, test.icaos)
+			}
+		})
+	}
+	airports, _, err := ReadAirports(strings.NewReader(airportsCSV), nil, runways)
+	if err != nil {
+		t.Fatal(err)
+	}
+	jfk := airports[0]
+	if jfk.IATA != "JFK" || jfk.Country != "US" || jfk.Municipality != "New York" || jfk.Elevation == nil || *jfk.Elevation != 13 ||
+		len(jfk.Runways) != 2 || jfk.Latitude != 40.639447 {
+		t.Errorf("airport %+v, want JFK with its 2 runways", jfk)
+	}
+	// no elevation and no runways
+	if pecan := airports[2]; pecan.Elevation != nil || pecan.Runways == nil {
+		t.Errorf("airport %+v, want no elevation and its runways", pecan)
+	}
+	if _, _, err = ReadAirports(strings.NewReader("\"ident\",\"type\"\n\"KJFK\",\"large_airport\"\n"), nil, nil); err == nil {
+		t.Error("no error without the coordinate columns")
+	}
+}
+
+func TestImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ourairports")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	airportsPath := filepath.Join(dir, "airports.csv")
+	runwaysPath := filepath.Join(dir, "runways.csv")
+	if err = ioutil.WriteFile(runwaysPath, []byte(runwaysCSV), 0644); err != nil {
+		t.Fatal(err)
+	}
+	airports := repositories.NewMemoryRepositories().Airports
+	tests := []struct {
+		name     string
+		csv      string
+		inserted int64
+		updated  int64
+	}{
+		{"first import", airportsCSV, 3, 0},
+		{"same dump again", airportsCSV, 0, 0},
+		{"renamed airport", strings.Replace(airportsCSV, "London Heathrow Airport", "Heathrow", 1), 0, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := ioutil.WriteFile(airportsPath, []byte(test.csv), 0644); err != nil {
+				t.Fatal(err)
+			}
+			result, err := Import(context.Background(), airports, airportsPath, runwaysPath, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Read != 5 || result.Skipped != 2 || result.Inserted != test.inserted || result.Updated != test.updated {
+				t.Errorf("result %+v, want 5 read, 2 skipped, %d inserted and %d updated", result, test.inserted, test.updated)
+			}
+		})
+	}
+	heathrow, err := airports.FindOne(context.Background(), bson.M{"icao": "EGLL"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if heathrow.Name != "Heathrow" || len(heathrow.Runways) != 1 {
+		t.Errorf("airport %+v, want the renamed Heathrow with its runway", heathrow)
+	}
+}
@@ -0,0 +1,48 @@
+package password
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt only looks at the first 72 bytes of a password
+const maxLength = 72
+
+var ErrTooLong = errors.New("The password must not be longer than 72 bytes")
+
+// Hash hashes a password with bcrypt
+func Hash(password string) (string, error) {
+	if len(password) > maxLength {
+		return "", ErrTooLong
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// sha256 hashes stored before bcrypt were the password bytes appended to the hash of an empty input
+func legacyHash(password string) string {
+	h := sha256.New()
+	return string(h.Sum([]byte(password)))
+}
+
+func isBcrypt(stored string) bool {
+	return strings.HasPrefix(stored, "$2a$") || strings.HasPrefix(stored, "$2b$") || strings.HasPrefix(stored, "$2y$")
+}
+
+// Check tells if the password matches the stored hash and if the hash should be replaced
+// by a fresh one, which is the case for legacy hashes and bcrypt hashes of a lower cost
+func Check(stored string, password string) (bool, bool) {
+	if !isBcrypt(stored) {
+		ok := stored != "" && subtle.ConstantTimeCompare([]byte(stored), []byte(legacyHash(password))) == 1
+		return ok, ok
+	}
+	if bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) != nil {
+		return false, false
+	}
+	cost, err := bcrypt.Cost([]byte(stored))
+	return true, err != nil || cost < bcrypt.DefaultCost
+}
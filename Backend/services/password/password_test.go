@@ -0,0 +1,73 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func bcryptHash(t *testing.T, password string, cost int) string {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), cost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(hash)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		stored   string
+		password string
+		ok       bool
+		rehash   bool
+	}{
+		{"legacy hash", legacyHash("secret"), "secret", true, true},
+		{"legacy hash, wrong password", legacyHash("secret"), "Secret", false, false},
+		{"no stored hash", "", "", false, false},
+		{"bcrypt", bcryptHash(t, "secret", bcrypt.DefaultCost), "secret", true, false},
+		{"bcrypt, wrong password", bcryptHash(t, "secret", bcrypt.DefaultCost), "secret ", false, false},
+		{"bcrypt of a lower cost", bcryptHash(t, "secret", bcrypt.MinCost), "secret", true, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ok, rehash := Check(test.stored, test.password)
+			if ok != test.ok || rehash != test.rehash {
+				t.Errorf("Check = %t, %t, want %t, %t", ok, rehash, test.ok, test.rehash)
+			}
+		})
+	}
+}
+
+// a legacy hash is replaced on login by a bcrypt hash that needs no rehash
+func TestRehashLegacy(t *testing.T) {
+	stored := legacyHash("secret")
+	ok, rehash := Check(stored, "secret")
+	if !ok || !rehash {
+		t.Fatalf("Check = %t, %t, want a match to rehash", ok, rehash)
+	}
+	stored, err := Hash("secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !isBcrypt(stored) {
+		t.Fatalf("%q is no bcrypt hash", stored)
+	}
+	if ok, rehash = Check(stored, "secret"); !ok || rehash {
+		t.Errorf("Check = %t, %t after the rehash, want a match to keep", ok, rehash)
+	}
+	if ok, _ = Check(stored, "other"); ok {
+		t.Error("another password matches the new hash")
+	}
+}
+
+func TestHashTooLong(t *testing.T) {
+	if _, err := Hash(strings.Repeat("x", maxLength)); err != nil {
+		t.Errorf("error %v for %d bytes", err, maxLength)
+	}
+	if _, err := Hash(strings.Repeat("x", maxLength+1)); err != ErrTooLong {
+		t.Errorf("error %v, want %v", err, ErrTooLong)
+	}
+}
@@ -0,0 +1,211 @@
+package search
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// most documents matched per collection, facets are counted over them
+const MaxMatches = 1000
+
+// scores of matches the text index does not find
+const (
+	prefixScore = 1
+	fuzzyScore  = 0.5
+)
+
+// a searched collection
+type Target struct {
+	Source repositories.Searchable
+	// fields matched by word prefix and with a typo, the text index covers the same fields
+	Fields []string
+	// narrows the matches, may be nil
+	Conditions []repositories.Condition
+}
+
+type Hit struct {
+	Document bson.Raw
+	Score    float64
+}
+
+// text index fields of the searched collections and their weights
+var (
+	AircraftFields = bson.D{{"general.registration", 10}, {"general.name", 5}, {"general.manufacturer", 3}, {"general.model", 3}, {"tags", 1}}
+	AirlineFields  = bson.D{{"general.iata", 10}, {"general.icao", 10}, {"general.name", 5}}
+	AirportFields  = bson.D{{"icao", 10}, {"iata", 10}, {"name", 5}, {"municipality", 1}}
+)
+
+func fieldNames(fields bson.D) []string {
+	names := make([]string, len(fields))
+	for i, x := range fields {
+		names[i] = x.Key
+	}
+	return names
+}
+
+// the fields of a text index, a collection can have one text index only
+func textIndex(fields bson.D) mongo.IndexModel {
+	keys := bson.D{}
+	for _, x := range fields {
+		keys = append(keys, bson.E{Key: x.Key, Value: "text"})
+	}
+	return mongo.IndexModel{Keys: keys, Options: options.Index().SetName("search").SetWeights(fields)}
+}
+
+// EnsureIndexes creates the text indexes of the searched collections
+func EnsureIndexes(ctx context.Context, aircraft *mongo.Collection, airlines *mongo.Collection, airports *mongo.Collection) error {
+	for _, x := range []struct {
+		collection *mongo.Collection
+		fields     bson.D
+	}{{aircraft, AircraftFields}, {airlines, AirlineFields}, {airports, AirportFields}} {
+		if _, err := x.collection.Indexes().CreateOne(ctx, textIndex(x.fields)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func NewTarget(source repositories.Searchable, fields bson.D, conditions []repositories.Condition) Target {
+	return Target{Source: source, Fields: fieldNames(fields), Conditions: conditions}
+}
+
+// a word starting with the term
+func prefixPattern(term string) string {
+	return `(^|\W)` + regexp.QuoteMeta(term)
+}
+
+// a word starting with the term with one letter changed, missing, added or swapped with the next one,
+// short terms are matched by prefix only
+func fuzzyPattern(term string) string {
+	letters := []rune(term)
+	if len(letters) < 3 {
+		return prefixPattern(term)
+	}
+	quote := func(x []rune) string {
+		return regexp.QuoteMeta(string(x))
+	}
+	var variants []string
+	for i := range letters {
+		variants = append(variants,
+			quote(letters[:i])+"."+quote(letters[i+1:]),
+			quote(letters[:i])+quote(letters[i+1:]),
+			quote(letters[:i])+"."+quote(letters[i:]))
+		if i+1 < len(letters) {
+			swapped := append(append([]rune{}, letters[:i]...), letters[i+1], letters[i])
+			variants = append(variants, quote(append(swapped, letters[i+2:]...)))
+		}
+	}
+	return `(^|\W)(` + strings.Join(variants, "|") + `)`
+}
+
+// every term matches one of the fields
+func patternConditions(fields []string, terms []string, pattern func(string) string) []repositories.Condition {
+	conditions := make([]repositories.Condition, 0, len(terms))
+	for _, term := range terms {
+		or := make([]interface{}, len(fields))
+		for i, field := range fields {
+			or[i] = repositories.Condition{Field: field, Operator: repositories.Match, Values: []interface{}{pattern(term)}}
+		}
+		conditions = append(conditions, repositories.Condition{Operator: repositories.Or, Values: or})
+	}
+	return conditions
+}
+
+// Find matches the words of query with the text index, then as word prefixes and, with fuzzy, with a typo.
+// Hits are ordered by score, text index matches first
+func Find(ctx context.Context, target Target, query string, fuzzy bool) ([]Hit, error) {
+	terms := strings.Fields(strings.ToLower(query))
+	if len(terms) == 0 {
+		return []Hit{}, nil
+	}
+	scores := make(map[primitive.ObjectID]float64)
+	documents := make(map[primitive.ObjectID]bson.Raw)
+	collect := func(matches []bson.Raw, score func(bson.Raw) float64) {
+		for _, x := range matches {
+			if len(documents) >= MaxMatches {
+				return
+			}
+			id, ok := x.Lookup("_id").ObjectIDOK()
+			if !ok {
+				continue
+			}
+			if _, found := documents[id]; found {
+				continue
+			}
+			documents[id] = x
+			scores[id] = score(x)
+		}
+	}
+	matches, err := target.Source.Text(ctx, query, target.Conditions, MaxMatches)
+	if err != nil {
+		return nil, err
+	}
+	collect(matches, func(document bson.Raw) float64 {
+		// text index matches rank above the others
+		score, _ := document.Lookup("score").DoubleOK()
+		return prefixScore + score
+	})
+	matches, err = target.Source.Match(ctx, append(patternConditions(target.Fields, terms, prefixPattern), target.Conditions...), MaxMatches)
+	if err != nil {
+		return nil, err
+	}
+	collect(matches, func(bson.Raw) float64 {
+		return prefixScore
+	})
+	if fuzzy {
+		matches, err = target.Source.Match(ctx, append(patternConditions(target.Fields, terms, fuzzyPattern), target.Conditions...), MaxMatches)
+		if err != nil {
+			return nil, err
+		}
+		collect(matches, func(bson.Raw) float64 {
+			return fuzzyScore
+		})
+	}
+	hits := make([]Hit, 0, len(documents))
+	for id, x := range documents {
+		hits = append(hits, Hit{Document: x, Score: scores[id]})
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		// the same query always gives the same order
+		return hits[i].Document.Lookup("_id").ObjectID().Hex() < hits[j].Document.Lookup("_id").ObjectID().Hex()
+	})
+	return hits, nil
+}
+
+// a value of a facet and the number of matches having it
+type Count struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// counts values, most frequent first, empty values are not counted
+func Counts(values []string) []Count {
+	counts := make(map[string]int)
+	for _, x := range values {
+		if x != "" {
+			counts[x]++
+		}
+	}
+	result := make([]Count, 0, len(counts))
+	for value, count := range counts {
+		result = append(result, Count{Value: value, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
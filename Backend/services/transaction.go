@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// runs fn inside a MongoDB transaction, the transaction is aborted when fn returns an error
+func WithTransaction(ctx context.Context, fn func(sessionContext mongo.SessionContext) error) error {
+	client := GetUserService().Collection.Database().Client()
+	session, err := client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+	_, err = session.WithTransaction(ctx, func(sessionContext mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionContext)
+	})
+	return err
+}
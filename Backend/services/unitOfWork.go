@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// a named change of a unit of work
+type Step struct {
+	Name string
+	Do   func(ctx context.Context) error
+	// reverts Do when a later step fails and there is no transaction to abort, nil when there is nothing to revert
+	Undo func(ctx context.Context) error
+}
+
+// changes across collections that succeed or fail together,
+// inside a transaction when the deployment supports them and as a saga of compensating actions when it does not
+type UnitOfWork struct {
+	name  string
+	steps []Step
+}
+
+func NewUnitOfWork(name string) *UnitOfWork {
+	return &UnitOfWork{name: name}
+}
+
+func (u *UnitOfWork) Add(name string, do func(ctx context.Context) error, undo func(ctx context.Context) error) {
+	u.steps = append(u.steps, Step{Name: name, Do: do, Undo: undo})
+}
+
+func (u *UnitOfWork) Run(ctx context.Context) error {
+	if TransactionsSupported(ctx) {
+		log.Printf("%s: start a transaction", u.name)
+		err := WithTransaction(ctx, func(sessionContext mongo.SessionContext) error {
+			for _, x := range u.steps {
+				log.Printf("%s: %s", u.name, x.Name)
+				if err := x.Do(sessionContext); err != nil {
+					log.Printf("%s: %s failed: %v", u.name, x.Name, err)
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			log.Printf("%s: the transaction has been aborted", u.name)
+			return err
+		}
+		log.Printf("%s: the transaction has been committed", u.name)
+		return nil
+	}
+	log.Printf("%s: no transactions, run as a saga", u.name)
+	for i, x := range u.steps {
+		log.Printf("%s: %s", u.name, x.Name)
+		err := x.Do(ctx)
+		if err == nil {
+			continue
+		}
+		log.Printf("%s: %s failed: %v", u.name, x.Name, err)
+		u.compensate(ctx, i)
+		return err
+	}
+	log.Printf("%s: done", u.name)
+	return nil
+}
+
+// undoes the steps before the failed one in reverse order, a failing undo does not stop the others
+func (u *UnitOfWork) compensate(ctx context.Context, failed int) {
+	for i := failed - 1; i >= 0; i-- {
+		x := u.steps[i]
+		if x.Undo == nil {
+			continue
+		}
+		log.Printf("%s: undo %s", u.name, x.Name)
+		if err := x.Undo(ctx); err != nil {
+			log.Printf("%s: undo %s failed: %v", u.name, x.Name, err)
+		}
+	}
+}
+
+// the outcome of the last successful probe, a failed probe is retried on the next call
+var transactions struct {
+	sync.Mutex
+	checked   bool
+	supported bool
+}
+
+// transactions need a replica set or a sharded cluster, a standalone server does not have them
+func TransactionsSupported(ctx context.Context) bool {
+	collection := GetUserService().Collection
+	if collection == nil {
+		return false
+	}
+	transactions.Lock()
+	defer transactions.Unlock()
+	if transactions.checked {
+		return transactions.supported
+	}
+	var hello bson.M
+	err := collection.Database().Client().Database("admin").RunCommand(ctx, bson.D{{"isMaster", 1}}).Decode(&hello)
+	if err != nil {
+		log.Printf("Check for transactions: %v", err)
+		return false
+	}
+	_, replicaSet := hello["setName"]
+	transactions.supported = replicaSet || hello["msg"] == "isdbgrid"
+	transactions.checked = true
+	log.Printf("Transactions supported: %v", transactions.supported)
+	return transactions.supported
+}
@@ -1,10 +1,13 @@
 package services
 
 import (
-	//"context"
+	"context"
+	"fmt"
 
 	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var userService UserService
@@ -22,3 +25,14 @@ func CreateUserService(collection *mongo.Collection, redisClient *redis.Client)
 func GetUserService() *UserService {
 	return &userService
 }
+
+// users sign in by name so no two users can share one
+func (s *UserService) EnsureIndexes(ctx context.Context) error {
+	_, err := s.Collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{"name", 1}},
+		Options: options.Index().SetUnique(true)})
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("users with the same name have to be renamed before the unique name index can be built: %w", err)
+	}
+	return err
+}
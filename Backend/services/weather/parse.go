@@ -0,0 +1,462 @@
+// Package weather parses METAR and TAF reports and keeps the current conditions of every airport.
+package weather
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+)
+
+const metersPerStatuteMile = 1609.344
+
+// visibility reported as 9999 or CAVOK, in statute miles
+const unlimitedVisibility = 10
+
+var (
+	stationPattern     = regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`)
+	timePattern        = regexp.MustCompile(`^(\d{2})(\d{2})(\d{2})Z$`)
+	validityPattern    = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	fromPattern        = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	probPattern        = regexp.MustCompile(`^PROB(\d{2})$`)
+	windPattern        = regexp.MustCompile(`^(\d{3}|VRB)(\d{2,3})(?:G(\d{2,3}))?(KT|MPS|KMH)$`)
+	variablePattern    = regexp.MustCompile(`^(\d{3})V(\d{3})$`)
+	metersPattern      = regexp.MustCompile(`^(\d{4})(NDV)?$`)
+	milesPattern       = regexp.MustCompile(`^(P|M)?(\d+)?(?:(\d)/(\d{1,2}))?SM$`)
+	wholeMilesPattern  = regexp.MustCompile(`^\d$`)
+	cloudPattern       = regexp.MustCompile(`^(FEW|SCT|BKN|OVC)(\d{3}|///)(CB|TCU)?$`)
+	verticalPattern    = regexp.MustCompile(`^VV(\d{3}|///)$`)
+	temperaturePattern = regexp.MustCompile(`^(M?\d{2})/(M?\d{2})?$`)
+	qnhPattern         = regexp.MustCompile(`^Q(\d{4})$`)
+	altimeterPattern   = regexp.MustCompile(`^A(\d{4})$`)
+	phenomenaPattern   = regexp.MustCompile(`^(\+|-|VC)?(MI|PR|BC|DR|BL|SH|TS|FZ)?((?:DZ|RA|SN|SG|IC|PL|GR|GS|UP|BR|FG|FU|VA|DU|SA|HZ|PY|PO|SQ|FC|SS|DS)*)$`)
+)
+
+// conditions shared by METAR and TAF groups
+type conditions struct {
+	wind       *weatherModel.Wind
+	visibility *float64
+	clouds     []weatherModel.Cloud
+	// per layer, the station could not measure the base, e.g. BKN///
+	unmeasured []bool
+	phenomena  []string
+}
+
+// ceiling is the lowest broken, overcast or vertical visibility layer
+func (c *conditions) ceiling() *int32 {
+	var ceiling *int32
+	for i, x := range c.clouds {
+		if x.Cover != "BKN" && x.Cover != "OVC" && x.Cover != "VV" {
+			continue
+		}
+		// a layer of unknown height is no ceiling, an obscured sky (VV///) is as low as it gets
+		if c.unmeasured[i] && x.Cover != "VV" {
+			continue
+		}
+		if ceiling == nil || x.Base < *ceiling {
+			base := x.Base
+			ceiling = &base
+		}
+	}
+	return ceiling
+}
+
+// parses the token at i and returns how many tokens it used, 0 when it is not a condition token
+func (c *conditions) parse(tokens []string, i int) int {
+	token := tokens[i]
+	if token == "CAVOK" {
+		visibility := float64(unlimitedVisibility)
+		c.visibility = &visibility
+		return 1
+	}
+	if token == "SKC" || token == "CLR" || token == "NSC" || token == "NCD" || token == "NSW" {
+		return 1
+	}
+	if match := windPattern.FindStringSubmatch(token); match != nil {
+		c.wind = parseWind(match)
+		return 1
+	}
+	if match := variablePattern.FindStringSubmatch(token); match != nil {
+		if c.wind != nil {
+			from, to := atoi16(match[1]), atoi16(match[2])
+			c.wind.VariableFrom = &from
+			c.wind.VariableTo = &to
+		}
+		return 1
+	}
+	if match := metersPattern.FindStringSubmatch(token); match != nil {
+		meters, _ := strconv.Atoi(match[1])
+		visibility := float64(unlimitedVisibility)
+		if meters < 9999 {
+			visibility = math.Round(float64(meters)/metersPerStatuteMile*100) / 100
+		}
+		c.visibility = &visibility
+		return 1
+	}
+	// "1 1/2SM" spreads over two tokens
+	if wholeMilesPattern.MatchString(token) && i+1 < len(tokens) {
+		if match := milesPattern.FindStringSubmatch(tokens[i+1]); match != nil && match[2] == "" {
+			visibility := parseMiles(match)
+			whole, _ := strconv.Atoi(token)
+			visibility += float64(whole)
+			c.visibility = &visibility
+			return 2
+		}
+	}
+	if match := milesPattern.FindStringSubmatch(token); match != nil && (match[2] != "" || match[3] != "") {
+		visibility := parseMiles(match)
+		c.visibility = &visibility
+		return 1
+	}
+	if match := cloudPattern.FindStringSubmatch(token); match != nil {
+		c.clouds = append(c.clouds, weatherModel.Cloud{Cover: match[1], Base: parseHeight(match[2]), Type: match[3]})
+		c.unmeasured = append(c.unmeasured, match[2] == "///")
+		return 1
+	}
+	if match := verticalPattern.FindStringSubmatch(token); match != nil {
+		c.clouds = append(c.clouds, weatherModel.Cloud{Cover: "VV", Base: parseHeight(match[1])})
+		c.unmeasured = append(c.unmeasured, match[1] == "///")
+		return 1
+	}
+	if match := phenomenaPattern.FindStringSubmatch(token); match != nil && (match[2] != "" || match[3] != "") {
+		c.phenomena = append(c.phenomena, token)
+		return 1
+	}
+	return 0
+}
+
+func atoi16(value string) int16 {
+	number, _ := strconv.Atoi(value)
+	return int16(number)
+}
+
+func parseWind(match []string) *weatherModel.Wind {
+	factor := 1.0
+	switch match[4] {
+	case "MPS":
+		factor = 1.943844
+	case "KMH":
+		factor = 0.539957
+	}
+	knots := func(value string) int16 {
+		number, _ := strconv.Atoi(value)
+		return int16(math.Round(float64(number) * factor))
+	}
+	wind := &weatherModel.Wind{Speed: knots(match[2])}
+	if match[1] != "VRB" {
+		direction := atoi16(match[1])
+		wind.Direction = &direction
+	}
+	if match[3] != "" {
+		gust := knots(match[3])
+		wind.Gust = &gust
+	}
+	return wind
+}
+
+func parseMiles(match []string) float64 {
+	var visibility float64
+	if match[2] != "" {
+		whole, _ := strconv.Atoi(match[2])
+		visibility = float64(whole)
+	}
+	if match[3] != "" {
+		numerator, _ := strconv.Atoi(match[3])
+		denominator, _ := strconv.Atoi(match[4])
+		if denominator > 0 {
+			visibility += float64(numerator) / float64(denominator)
+		}
+	}
+	return visibility
+}
+
+func parseHeight(value string) int32 {
+	hundreds, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return int32(hundreds * 100)
+}
+
+func parseTemperature(value string) int8 {
+	negative := strings.HasPrefix(value, "M")
+	number, _ := strconv.Atoi(strings.TrimPrefix(value, "M"))
+	if negative {
+		number = -number
+	}
+	return int8(number)
+}
+
+// FlightCategory classifies conditions the way the FAA does, visibility in statute miles and ceiling in feet
+func FlightCategory(visibility *float64, ceiling *int32) string {
+	switch {
+	case (ceiling != nil && *ceiling < 500) || (visibility != nil && *visibility < 1):
+		return weatherModel.LIFR
+	case (ceiling != nil && *ceiling < 1000) || (visibility != nil && *visibility < 3):
+		return weatherModel.IFR
+	case (ceiling != nil && *ceiling <= 3000) || (visibility != nil && *visibility <= 5):
+		return weatherModel.MVFR
+	default:
+		return weatherModel.VFR
+	}
+}
+
+func daysIn(monthStart time.Time) int {
+	return monthStart.AddDate(0, 1, -1).Day()
+}
+
+// resolves a day of month and time to the latest date that is not later than a day after now
+func resolveTime(now time.Time, day, hour, minute int) time.Time {
+	now = now.UTC()
+	offset := time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute
+	for months := 0; months < 3; months++ {
+		monthStart := time.Date(now.Year(), now.Month()-time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		if day > daysIn(monthStart) {
+			continue
+		}
+		// hour 24 is the end of the day
+		resolved := monthStart.AddDate(0, 0, day-1).Add(offset)
+		if !resolved.After(now.Add(24 * time.Hour)) {
+			return resolved
+		}
+	}
+	return now
+}
+
+// resolves a day of month and hour to the first date after after
+func resolveAfter(after time.Time, day, hour int) time.Time {
+	offset := time.Duration(hour) * time.Hour
+	for months := 0; months < 3; months++ {
+		monthStart := time.Date(after.Year(), after.Month()+time.Month(months), 1, 0, 0, 0, 0, time.UTC)
+		if day > daysIn(monthStart) {
+			continue
+		}
+		resolved := monthStart.AddDate(0, 0, day-1).Add(offset)
+		if resolved.After(after) {
+			return resolved
+		}
+	}
+	return after
+}
+
+// splits a report into tokens dropping the end of report marker
+func tokenize(raw string) []string {
+	raw = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(raw), "="))
+	return strings.Fields(strings.ToUpper(raw))
+}
+
+// Station returns the station a METAR or TAF report belongs to
+func Station(raw string) string {
+	for _, token := range tokenize(raw) {
+		switch token {
+		case "METAR", "SPECI", "TAF", "AMD", "COR":
+			continue
+		}
+		if stationPattern.MatchString(token) {
+			return token
+		}
+		return ""
+	}
+	return ""
+}
+
+// ParseMETAR parses a METAR or SPECI report, now is used to resolve the observation date
+func ParseMETAR(raw string, now time.Time) (weatherModel.Weather, error) {
+	report := weatherModel.Weather{Metar: strings.TrimSpace(raw), Clouds: make([]weatherModel.Cloud, 0)}
+	tokens := tokenize(raw)
+	i := 0
+	for i < len(tokens) && (tokens[i] == "METAR" || tokens[i] == "SPECI" || tokens[i] == "COR") {
+		i++
+	}
+	if i >= len(tokens) || !stationPattern.MatchString(tokens[i]) {
+		return report, errors.New("METAR has no station")
+	}
+	report.Airport = tokens[i]
+	i++
+	if i >= len(tokens) {
+		return report, errors.New("METAR has no observation time")
+	}
+	match := timePattern.FindStringSubmatch(tokens[i])
+	if match == nil {
+		return report, fmt.Errorf("invalid METAR observation time %q", tokens[i])
+	}
+	day, _ := strconv.Atoi(match[1])
+	hour, _ := strconv.Atoi(match[2])
+	minute, _ := strconv.Atoi(match[3])
+	report.ObservedAt = resolveTime(now, day, hour, minute)
+	i++
+	var observed conditions
+	for i < len(tokens) {
+		token := tokens[i]
+		// remarks and trends are not part of the observation
+		if token == "RMK" || token == "NOSIG" || token == "TEMPO" || token == "BECMG" {
+			break
+		}
+		// runway visual range groups are not kept
+		if token == "AUTO" || token == "COR" || (strings.HasPrefix(token, "R") && strings.Contains(token, "/")) {
+			i++
+			continue
+		}
+		if match := temperaturePattern.FindStringSubmatch(token); match != nil {
+			temperature := parseTemperature(match[1])
+			report.Temperature = &temperature
+			if match[2] != "" {
+				dewpoint := parseTemperature(match[2])
+				report.Dewpoint = &dewpoint
+			}
+			i++
+			continue
+		}
+		if match := qnhPattern.FindStringSubmatch(token); match != nil {
+			qnh, _ := strconv.ParseFloat(match[1], 64)
+			report.QNH = &qnh
+			i++
+			continue
+		}
+		if match := altimeterPattern.FindStringSubmatch(token); match != nil {
+			inches, _ := strconv.ParseFloat(match[1], 64)
+			qnh := math.Round(inches / 100 * 33.8639)
+			report.QNH = &qnh
+			i++
+			continue
+		}
+		if used := observed.parse(tokens, i); used > 0 {
+			i += used
+			continue
+		}
+		// unknown groups are skipped, reports in the wild are rarely perfect
+		i++
+	}
+	report.Wind = observed.wind
+	report.Visibility = observed.visibility
+	if observed.clouds != nil {
+		report.Clouds = observed.clouds
+	}
+	report.Ceiling = observed.ceiling()
+	report.Condition = strings.Join(observed.phenomena, " ")
+	report.FlightCategory = FlightCategory(report.Visibility, report.Ceiling)
+	return report, nil
+}
+
+// a parsed TAF, the issue time is the start of validity when the TAF has no issue time group
+type TAF struct {
+	Station  string
+	IssuedAt time.Time
+	Forecast []weatherModel.Forecast
+}
+
+// ParseTAF parses a TAF into its forecast periods, now is used to resolve the dates
+func ParseTAF(raw string, now time.Time) (TAF, error) {
+	var taf TAF
+	tokens := tokenize(raw)
+	i := 0
+	for i < len(tokens) && (tokens[i] == "TAF" || tokens[i] == "AMD" || tokens[i] == "COR") {
+		i++
+	}
+	if i >= len(tokens) || !stationPattern.MatchString(tokens[i]) {
+		return taf, errors.New("TAF has no station")
+	}
+	taf.Station = tokens[i]
+	i++
+	issued := false
+	if i < len(tokens) && timePattern.MatchString(tokens[i]) {
+		match := timePattern.FindStringSubmatch(tokens[i])
+		day, _ := strconv.Atoi(match[1])
+		hour, _ := strconv.Atoi(match[2])
+		minute, _ := strconv.Atoi(match[3])
+		now = resolveTime(now, day, hour, minute)
+		taf.IssuedAt = now
+		issued = true
+		i++
+	}
+	if i >= len(tokens) || !validityPattern.MatchString(tokens[i]) {
+		return taf, errors.New("TAF has no validity period")
+	}
+	validFrom, validTo := parseValidity(tokens[i], now)
+	if !issued {
+		taf.IssuedAt = validFrom
+	}
+	i++
+
+	forecasts := make([]weatherModel.Forecast, 0)
+	current := &weatherModel.Forecast{Change: "BASE", From: validFrom, To: validTo}
+	var observed conditions
+	closePeriod := func() {
+		current.Wind = observed.wind
+		current.Visibility = observed.visibility
+		current.Clouds = observed.clouds
+		if current.Clouds == nil {
+			current.Clouds = make([]weatherModel.Cloud, 0)
+		}
+		current.Ceiling = observed.ceiling()
+		current.Condition = strings.Join(observed.phenomena, " ")
+		current.FlightCategory = FlightCategory(current.Visibility, current.Ceiling)
+		forecasts = append(forecasts, *current)
+		observed = conditions{}
+	}
+	for i < len(tokens) {
+		token := tokens[i]
+		if token == "RMK" {
+			break
+		}
+		if match := fromPattern.FindStringSubmatch(token); match != nil {
+			closePeriod()
+			day, _ := strconv.Atoi(match[1])
+			hour, _ := strconv.Atoi(match[2])
+			minute, _ := strconv.Atoi(match[3])
+			current = &weatherModel.Forecast{Change: "FM", From: resolveTime(now, day, hour, minute), To: validTo}
+			// a FM group ends the previous FM or base period
+			for j := len(forecasts) - 1; j >= 0; j-- {
+				if forecasts[j].Change == "FM" || forecasts[j].Change == "BASE" {
+					forecasts[j].To = current.From
+					break
+				}
+			}
+			i++
+			continue
+		}
+		if token == "BECMG" || token == "TEMPO" || probPattern.MatchString(token) {
+			closePeriod()
+			current = &weatherModel.Forecast{Change: token}
+			if match := probPattern.FindStringSubmatch(token); match != nil {
+				probability64, _ := strconv.ParseUint(match[1], 10, 8)
+				probability := uint8(probability64)
+				current.Change = "PROB"
+				current.Probability = &probability
+				if i+1 < len(tokens) && tokens[i+1] == "TEMPO" {
+					current.Change = "PROB TEMPO"
+					i++
+				}
+			}
+			if i+1 < len(tokens) && validityPattern.MatchString(tokens[i+1]) {
+				current.From, current.To = parseValidity(tokens[i+1], now)
+				i++
+			}
+			i++
+			continue
+		}
+		if used := observed.parse(tokens, i); used > 0 {
+			i += used
+			continue
+		}
+		i++
+	}
+	closePeriod()
+	taf.Forecast = forecasts
+	return taf, nil
+}
+
+func parseValidity(token string, now time.Time) (time.Time, time.Time) {
+	match := validityPattern.FindStringSubmatch(token)
+	fromDay, _ := strconv.Atoi(match[1])
+	fromHour, _ := strconv.Atoi(match[2])
+	toDay, _ := strconv.Atoi(match[3])
+	toHour, _ := strconv.Atoi(match[4])
+	from := resolveTime(now, fromDay, fromHour, 0)
+	return from, resolveAfter(from, toDay, toHour)
+}
@@ -0,0 +1,220 @@
+package weather
+
+import (
+	"testing"
+	"time"
+
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+)
+
+// reports are resolved against this time, the day after the observations
+var now = time.Date(2026, time.October, 20, 12, 0, 0, 0, time.UTC)
+
+func int8Ptr(x int8) *int8 {
+	return &x
+}
+
+func int32Ptr(x int32) *int32 {
+	return &x
+}
+
+func float64Ptr(x float64) *float64 {
+	return &x
+}
+
+func equalFloat(a *float64, b *float64) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalInt32(a *int32, b *int32) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func equalInt8(a *int8, b *int8) bool {
+	return (a == nil && b == nil) || (a != nil && b != nil && *a == *b)
+}
+
+func TestParseMETAR(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		airport     string
+		temperature *int8
+		dewpoint    *int8
+		visibility  *float64
+		ceiling     *int32
+		clouds      int
+		qnh         *float64
+		condition   string
+		category    string
+	}{
+		{"altimeter and negative temperatures", "METAR KJFK 191751Z 31015G25KT 10SM FEW050 SCT250 M02/M13 A3012 RMK AO2 SLP203",
+			"KJFK", int8Ptr(-2), int8Ptr(-13), float64Ptr(10), nil, 2, float64Ptr(1020), "", weatherModel.VFR},
+		{"whole and fractional miles", "METAR KSFO 191756Z 28008KT 1 1/2SM BR OVC008 12/11 A2998",
+			"KSFO", int8Ptr(12), int8Ptr(11), float64Ptr(1.5), int32Ptr(800), 1, float64Ptr(1015), "BR", weatherModel.IFR},
+		{"less than a quarter mile", "SPECI KDEN 191753Z 16005KT M1/4SM FZFG VV002 M03/M03 A3001",
+			"KDEN", int8Ptr(-3), int8Ptr(-3), float64Ptr(0.25), int32Ptr(200), 1, float64Ptr(1016), "FZFG", weatherModel.LIFR},
+		{"unmeasured layer is no ceiling", "METAR EGLL 191750Z AUTO 24010KT 9999 BKN/// 15/09 Q1013",
+			"EGLL", int8Ptr(15), int8Ptr(9), float64Ptr(10), nil, 1, float64Ptr(1013), "", weatherModel.VFR},
+		{"meters and runway visual range", "METAR LFPG 191800Z VRB02KT 0800 R27L/0600N FG OVC002 10/10 Q1015 NOSIG",
+			"LFPG", int8Ptr(10), int8Ptr(10), float64Ptr(0.5), int32Ptr(200), 1, float64Ptr(1015), "FG", weatherModel.LIFR},
+		{"CAVOK without dewpoint", "METAR UUEE 191800Z 00000MPS CAVOK M15/ Q1030=",
+			"UUEE", int8Ptr(-15), nil, float64Ptr(10), nil, 0, float64Ptr(1030), "", weatherModel.VFR},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report, err := ParseMETAR(test.raw, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Airport != test.airport {
+				t.Errorf("airport %q, want %q", report.Airport, test.airport)
+			}
+			if !equalInt8(report.Temperature, test.temperature) || !equalInt8(report.Dewpoint, test.dewpoint) {
+				t.Errorf("temperature %v/%v, want %v/%v", report.Temperature, report.Dewpoint, test.temperature, test.dewpoint)
+			}
+			if !equalFloat(report.Visibility, test.visibility) {
+				t.Errorf("visibility %v, want %v", report.Visibility, test.visibility)
+			}
+			if !equalInt32(report.Ceiling, test.ceiling) {
+				t.Errorf("ceiling %v, want %v", report.Ceiling, test.ceiling)
+			}
+			if len(report.Clouds) != test.clouds {
+				t.Errorf("clouds %v, want %d layers", report.Clouds, test.clouds)
+			}
+			if !equalFloat(report.QNH, test.qnh) {
+				t.Errorf("QNH %v, want %v", report.QNH, test.qnh)
+			}
+			if report.Condition != test.condition {
+				t.Errorf("condition %q, want %q", report.Condition, test.condition)
+			}
+			if report.FlightCategory != test.category {
+				t.Errorf("flight category %s, want %s", report.FlightCategory, test.category)
+			}
+		})
+	}
+}
+
+func TestParseMETARWind(t *testing.T) {
+	report, err := ParseMETAR("METAR KJFK 191751Z 31015G25KT 280V340 10SM SKC 20/10 A3000", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wind := report.Wind
+	if wind == nil || wind.Direction == nil || *wind.Direction != 310 || wind.Speed != 15 ||
+		wind.Gust == nil || *wind.Gust != 25 || wind.VariableFrom == nil || *wind.VariableFrom != 280 {
+		t.Errorf("wind %+v, want 310 at 15 gusting 25, variable from 280", wind)
+	}
+	if want := time.Date(2026, time.October, 19, 17, 51, 0, 0, time.UTC); !report.ObservedAt.Equal(want) {
+		t.Errorf("observed at %s, want %s", report.ObservedAt, want)
+	}
+}
+
+func TestParseMETARErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"no station", "METAR 191751Z 31015KT"},
+		{"no observation time", "METAR KJFK"},
+		{"invalid observation time", "METAR KJFK 1917Z 31015KT"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseMETAR(test.raw, now); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
+
+func TestParseTAF(t *testing.T) {
+	raw := `TAF KJFK 191730Z 1918/2024 31012KT P6SM FEW050
+		FM200000 33008KT P6SM SCT040
+		TEMPO 2006/2010 3SM -SHRA BKN030
+		PROB30 2012/2016 1SM TSRA OVC010CB`
+	taf, err := ParseTAF(raw, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(day int, hour int) time.Time {
+		return time.Date(2026, time.October, day, hour, 0, 0, 0, time.UTC)
+	}
+	if taf.Station != "KJFK" || !taf.IssuedAt.Equal(at(19, 17).Add(30*time.Minute)) {
+		t.Errorf("station %s issued at %s, want KJFK at 19 17:30", taf.Station, taf.IssuedAt)
+	}
+	tests := []struct {
+		change     string
+		from       time.Time
+		to         time.Time
+		visibility float64
+		ceiling    *int32
+		condition  string
+		category   string
+	}{
+		// the FM group ends the base period
+		{"BASE", at(19, 18), at(20, 0), 6, nil, "", weatherModel.VFR},
+		{"FM", at(20, 0), at(21, 0), 6, nil, "", weatherModel.VFR},
+		{"TEMPO", at(20, 6), at(20, 10), 3, int32Ptr(3000), "-SHRA", weatherModel.MVFR},
+		{"PROB", at(20, 12), at(20, 16), 1, int32Ptr(1000), "TSRA", weatherModel.IFR},
+	}
+	if len(taf.Forecast) != len(tests) {
+		t.Fatalf("%d periods, want %d", len(taf.Forecast), len(tests))
+	}
+	for i, test := range tests {
+		t.Run(test.change, func(t *testing.T) {
+			period := taf.Forecast[i]
+			if period.Change != test.change || !period.From.Equal(test.from) || !period.To.Equal(test.to) {
+				t.Errorf("%s from %s to %s, want %s from %s to %s", period.Change, period.From, period.To, test.change, test.from, test.to)
+			}
+			if !equalFloat(period.Visibility, &test.visibility) {
+				t.Errorf("visibility %v, want %v", period.Visibility, test.visibility)
+			}
+			if !equalInt32(period.Ceiling, test.ceiling) {
+				t.Errorf("ceiling %v, want %v", period.Ceiling, test.ceiling)
+			}
+			if period.Condition != test.condition {
+				t.Errorf("condition %q, want %q", period.Condition, test.condition)
+			}
+			if period.FlightCategory != test.category {
+				t.Errorf("flight category %s, want %s", period.FlightCategory, test.category)
+			}
+		})
+	}
+	if probability := taf.Forecast[3].Probability; probability == nil || *probability != 30 {
+		t.Errorf("probability %v, want 30", probability)
+	}
+}
+
+func TestParseTAFWithoutIssueTime(t *testing.T) {
+	taf, err := ParseTAF("TAF EGLL 1918/2024 24010KT 9999 BKN/// BECMG 2000/2003 4000 BR", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, time.October, 19, 18, 0, 0, 0, time.UTC); !taf.IssuedAt.Equal(want) {
+		t.Errorf("issued at %s, want the start of validity %s", taf.IssuedAt, want)
+	}
+	if len(taf.Forecast) != 2 || taf.Forecast[0].Ceiling != nil || taf.Forecast[1].Change != "BECMG" {
+		t.Errorf("forecast %+v, want a base period without ceiling and a BECMG period", taf.Forecast)
+	}
+	if _, err = ParseTAF("TAF KJFK 191730Z", now); err == nil {
+		t.Error("no error without a validity period")
+	}
+}
+
+func TestStation(t *testing.T) {
+	tests := []struct {
+		raw     string
+		station string
+	}{
+		{"METAR KJFK 191751Z 31015KT", "KJFK"},
+		{"TAF AMD EGLL 191730Z 1918/2024", "EGLL"},
+		{"KBOS 191754Z 05012KT", "KBOS"},
+		{"METAR 191751Z", ""},
+	}
+	for _, test := range tests {
+		if station := Station(test.raw); station != test.station {
+			t.Errorf("Station(%q) = %q, want %q", test.raw, station, test.station)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package weather
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	weatherModel "github.com/arttkachev/X-Airlines/Backend/api/models/weather"
+	"github.com/arttkachev/X-Airlines/Backend/repositories"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// name of the drop directory subfolder processed files are moved to
+const processedDir = "processed"
+
+// a parsed METAR or TAF of a station, only one of the two is set
+type Report struct {
+	Station string
+	Metar   *weatherModel.Weather
+	Taf     *TAF
+	raw     string
+}
+
+// Parse parses a METAR or a TAF without storing it, now is used to resolve the dates
+func Parse(raw string, now time.Time) (Report, error) {
+	tokens := tokenize(raw)
+	if len(tokens) > 0 && tokens[0] == "TAF" {
+		taf, err := ParseTAF(raw, now)
+		if err != nil {
+			return Report{Station: taf.Station}, err
+		}
+		return Report{Station: taf.Station, Taf: &taf, raw: strings.TrimSpace(raw)}, nil
+	}
+	metar, err := ParseMETAR(raw, now)
+	if err != nil {
+		return Report{Station: metar.Airport}, err
+	}
+	return Report{Station: metar.Airport, Metar: &metar, raw: strings.TrimSpace(raw)}, nil
+}
+
+// Store stores a parsed report as the weather of its airport.
+// Reports older than the stored observation or TAF are ignored.
+func Store(ctx context.Context, weatherRepository repositories.WeatherRepository, airports repositories.AirportRepository, report Report) error {
+	stored, err := weatherRepository.FindByAirport(ctx, report.Station)
+	if err != nil && err != repositories.ErrNotFound {
+		return err
+	}
+	found := err == nil
+	var change repositories.Change
+	if report.Taf != nil {
+		if found && stored.TafIssuedAt != nil && stored.TafIssuedAt.After(report.Taf.IssuedAt) {
+			return nil
+		}
+		change = repositories.Change{
+			Set: bson.M{
+				"taf":         report.raw,
+				"tafIssuedAt": report.Taf.IssuedAt,
+				"forecast":    report.Taf.Forecast},
+			SetOnInsert: bson.M{
+				"_id":    primitive.NewObjectID(),
+				"clouds": make([]weatherModel.Cloud, 0)}}
+	} else {
+		metar := report.Metar
+		if found && stored.ObservedAt.After(metar.ObservedAt) {
+			return nil
+		}
+		change = repositories.Change{
+			Set: bson.M{
+				"condition":      metar.Condition,
+				"temperature":    metar.Temperature,
+				"dewpoint":       metar.Dewpoint,
+				"wind":           metar.Wind,
+				"visibility":     metar.Visibility,
+				"ceiling":        metar.Ceiling,
+				"clouds":         metar.Clouds,
+				"qnh":            metar.QNH,
+				"flightCategory": metar.FlightCategory,
+				"observedAt":     metar.ObservedAt,
+				"metar":          metar.Metar},
+			SetOnInsert: bson.M{
+				"_id":      primitive.NewObjectID(),
+				"forecast": make([]weatherModel.Forecast, 0)}}
+	}
+	stored, err = weatherRepository.Upsert(ctx, report.Station, change)
+	if err != nil {
+		return err
+	}
+	// link the weather to its airport
+	airportData, err := airports.FindOne(ctx, bson.M{"icao": report.Station})
+	if err == repositories.ErrNotFound {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return airports.Update(ctx, airportData.ID, bson.M{"weather": stored.ID})
+}
+
+// Ingest parses a METAR or a TAF and stores it as the weather of its airport.
+// It returns the station of the report.
+func Ingest(ctx context.Context, weatherRepository repositories.WeatherRepository, airports repositories.AirportRepository, raw string, now time.Time) (string, error) {
+	report, err := Parse(raw, now)
+	if err != nil {
+		return report.Station, err
+	}
+	return report.Station, Store(ctx, weatherRepository, airports, report)
+}
+
+// SplitReports splits the content of a drop file into reports. Reports are separated by blank lines,
+// "=" terminators or new lines, lines starting with a space continue the previous report as TAFs often do.
+func SplitReports(content string) []string {
+	reports := make([]string, 0)
+	var current strings.Builder
+	flush := func() {
+		report := strings.TrimSpace(current.String())
+		if report != "" {
+			reports = append(reports, report)
+		}
+		current.Reset()
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			flush()
+		}
+		current.WriteString(" ")
+		current.WriteString(strings.TrimSpace(line))
+		if strings.HasSuffix(strings.TrimSpace(line), "=") {
+			flush()
+		}
+	}
+	flush()
+	return reports
+}
+
+// IngestFile ingests every report of a drop file, dates are resolved against the file modification time.
+// It returns the stations that got new weather.
+func IngestFile(ctx context.Context, weatherRepository repositories.WeatherRepository, airports repositories.AirportRepository, path string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	stations := make([]string, 0)
+	for _, report := range SplitReports(string(content)) {
+		station, err := Ingest(ctx, weatherRepository, airports, report, info.ModTime())
+		if err != nil {
+			log.Printf("Skip weather report %q from %s: %v", report, path, err)
+			continue
+		}
+		stations = append(stations, station)
+	}
+	return stations, nil
+}
+
+// IngestDirectory ingests every file of the drop directory and moves it to the processed subfolder
+func IngestDirectory(ctx context.Context, weatherRepository repositories.WeatherRepository, airports repositories.AirportRepository, dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	stations := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		ingested, err := IngestFile(ctx, weatherRepository, airports, path)
+		if err != nil {
+			return stations, fmt.Errorf("%s: %w", path, err)
+		}
+		stations = append(stations, ingested...)
+		if err = os.MkdirAll(filepath.Join(dir, processedDir), 0755); err != nil {
+			return stations, err
+		}
+		if err = os.Rename(path, filepath.Join(dir, processedDir, entry.Name())); err != nil {
+			return stations, err
+		}
+	}
+	return stations, nil
+}
+
+// WatchDirectory ingests the drop directory every interval until ctx is done,
+// onIngest is called with the stations of every pass
+func WatchDirectory(ctx context.Context, weatherRepository repositories.WeatherRepository, airports repositories.AirportRepository, dir string, interval time.Duration, onIngest func([]string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		passCtx, cancel := context.WithTimeout(ctx, interval)
+		stations, err := IngestDirectory(passCtx, weatherRepository, airports, dir)
+		cancel()
+		if err != nil {
+			log.Printf("Weather drop directory: %v", err)
+		}
+		if len(stations) > 0 && onIngest != nil {
+			onIngest(stations)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
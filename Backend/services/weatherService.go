@@ -0,0 +1,22 @@
+package services
+
+import (
+	"github.com/go-redis/redis"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var weatherService WeatherService
+
+type WeatherService struct {
+	Collection  *mongo.Collection
+	RedisClient *redis.Client
+}
+
+func CreateWeatherService(collection *mongo.Collection, redisClient *redis.Client) *WeatherService {
+	weatherService.Collection = collection
+	weatherService.RedisClient = redisClient
+	return &weatherService
+}
+func GetWeatherService() *WeatherService {
+	return &weatherService
+}
@@ -0,0 +1,216 @@
+package validation
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/arttkachev/X-Airlines/Backend/apierrors"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Models declare their rules in two tags: binding rules check every request, create rules only the ones
+// creating a document. A partial update leaves fields out, so "required" belongs in the create tag
+// and binding rules start with omitempty
+
+// patterns of the code rules by rule and param
+var patterns = map[string]*regexp.Regexp{
+	"icao=airline": regexp.MustCompile(`^[A-Z]{3}$`),
+	"icao=airport": regexp.MustCompile(`^[A-Z][A-Z0-9]{3}$`),
+	"iata=airline": regexp.MustCompile(`^([A-Z][A-Z0-9]|[0-9][A-Z])$`),
+	"iata=airport": regexp.MustCompile(`^[A-Z]{3}$`),
+	// a nationality prefix, an optional dash and the registration mark, e.g. N123AB, G-ABCD or 4X-ABC
+	"tailnumber": regexp.MustCompile(`^([A-Z]{1,2}|[A-Z][0-9]|[0-9][A-Z])-?[A-Z0-9]{1,5}$`),
+}
+
+// custom rules, values are matched regardless of case
+var rules = map[string]validator.Func{
+	// icao=airline or icao=airport
+	"icao":       matches,
+	"iata":       matches,
+	"tailnumber": matches,
+}
+
+func matches(fl validator.FieldLevel) bool {
+	rule := fl.GetTag()
+	if fl.Param() != "" {
+		rule += "=" + fl.Param()
+	}
+	pattern, ok := patterns[rule]
+	return ok && pattern.MatchString(strings.ToUpper(fl.Field().String()))
+}
+
+// Pattern is the upper case pattern of a custom rule like icao=airline
+func Pattern(rule string) (string, bool) {
+	pattern, ok := patterns[rule]
+	if !ok {
+		return "", false
+	}
+	return pattern.String(), true
+}
+
+// checks the create tags
+var creating = newCreateValidator()
+
+func newCreateValidator() *validator.Validate {
+	validate := validator.New()
+	validate.SetTagName("create")
+	return validate
+}
+
+// errors name fields as requests do
+func jsonName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func configure(validate *validator.Validate) error {
+	validate.RegisterTagNameFunc(jsonName)
+	for tag, rule := range rules {
+		if err := validate.RegisterValidation(tag, rule); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Register adds the custom rules and json field names to the validator gin binds requests with
+// and to the one checking create rules
+func Register() error {
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return errors.New("Gin does not validate with go-playground/validator")
+	}
+	if err := configure(engine); err != nil {
+		return err
+	}
+	return configure(creating)
+}
+
+// Create checks the create rules of a bound model
+func Create(obj interface{}) error {
+	return creating.Struct(obj)
+}
+
+// a failed rule of a request field, the field is a dotted json path and empty for the whole body
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Fields lists the failing fields of a binding or validation error
+func Fields(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	var typeError *json.UnmarshalTypeError
+	var syntaxError *json.SyntaxError
+	switch {
+	case errors.As(err, &validationErrors):
+		fields := make([]FieldError, 0, len(validationErrors))
+		for _, x := range validationErrors {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(x.Namespace()),
+				Rule:    x.Tag(),
+				Param:   x.Param(),
+				Message: message(x)})
+		}
+		return fields
+	case errors.As(err, &typeError):
+		return []FieldError{{
+			Field:   typeError.Field,
+			Rule:    "type",
+			Param:   typeError.Type.String(),
+			Message: "must be " + typeName(typeError.Type)}}
+	case errors.As(err, &syntaxError):
+		return []FieldError{{Rule: "json", Message: err.Error()}}
+	case err == io.EOF:
+		return []FieldError{{Rule: "required", Message: "A request body is required"}}
+	}
+	return []FieldError{{Rule: "json", Message: err.Error()}}
+}
+
+// Error is the validation error of a request failing binding or validation, its details are the failing fields
+func Error(err error) *apierrors.Error {
+	return &apierrors.Error{
+		Code:    apierrors.CodeValidation,
+		Message: "Validation failed",
+		Details: Fields(err),
+		Err:     err}
+}
+
+// drops the struct name a namespace starts with
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func typeName(typ reflect.Type) string {
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+func message(fe validator.FieldError) string {
+	countable := ""
+	switch fe.Kind() {
+	case reflect.String:
+		countable = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		countable = " items"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	case "icao":
+		if fe.Param() == "airline" {
+			return "must be a 3 letter ICAO airline code"
+		}
+		return "must be a 4 character ICAO airport code"
+	case "iata":
+		if fe.Param() == "airline" {
+			return "must be a 2 character IATA airline code"
+		}
+		return "must be a 3 letter IATA airport code"
+	case "tailnumber":
+		return "must be a tail number like N123AB or G-ABCD"
+	case "min", "gte":
+		return "must be at least " + fe.Param() + countable
+	case "max", "lte":
+		return "must be at most " + fe.Param() + countable
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "lt":
+		return "must be less than " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "nefield":
+		return "must differ from " + strings.ToLower(fe.Param()[:1]) + fe.Param()[1:]
+	}
+	return "must satisfy " + fe.Tag()
+}